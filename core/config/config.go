@@ -59,6 +59,15 @@ type Config struct {
 	TemporalHostPort  string `mapstructure:"TEMPORAL_HOST_PORT"`
 	TemporalAPIKey    string `mapstructure:"TEMPORAL_API_KEY"`
 	WorkerTaskQueue   string `mapstructure:"WORKER_TASK_QUEUE"`
+
+	// Whether a closed run can be started again with the same workflow ID:
+	// reject_duplicate, allow_duplicate or allow_duplicate_failed_only. Only
+	// reject_duplicate keeps triggers idempotent, with the others a retried
+	// trigger starts a closed run, or a failed one, again.
+	WorkflowIDReusePolicy string `mapstructure:"WORKFLOW_ID_REUSE_POLICY"`
+
+	// Executor overrides, e.g. "ImageFormatConvertorV1=http:http://localhost:9000/convert"
+	ExecutorOverrides string `mapstructure:"EXECUTOR_OVERRIDES"`
 }
 
 var AppConfig *Config
@@ -89,6 +98,7 @@ func LoadConfig(path, filename, ext string) error {
 	viper.SetDefault("REDIS_ADDR", "localhost:6379")
 	viper.SetDefault("REDIS_TLS", false)
 	viper.SetDefault("WORKER_TASK_QUEUE", "queue1")
+	viper.SetDefault("WORKFLOW_ID_REUSE_POLICY", "reject_duplicate")
 	viper.SetDefault("S3_INTERMEDIATE_BUCKET", "uploadpilottest")
 
 	// Read config file
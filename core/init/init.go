@@ -62,7 +62,12 @@ func Initialize() (*http.Server, *workflow.Worker, *cron.Cron, func(), error) {
 	services := services.NewServices(repos, clients, accessManager)
 
 	// Initialize worker
-	wrk := workflow.NewWorker(clients.LambdaClient, clients.TemporalClient, config.AppConfig.WorkerTaskQueue)
+	executorOverrides, err := workflow.ParseExecutorOverrides(config.AppConfig.ExecutorOverrides)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("worker initialization failed: %w", err)
+	}
+	wrk := workflow.NewWorker(repos, clients, config.AppConfig.WorkerTaskQueue, executorOverrides,
+		services.ProcessorService)
 
 	// Initialize cron to mark timed out uploads
 	timeoutMarkerCron := NewMarkTimedOutUploadsRoutine(repos.UploadRepo)
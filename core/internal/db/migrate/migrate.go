@@ -19,6 +19,10 @@ func Migrate(db *driver.Driver) error {
 		&models.Processor{},
 		&models.APIKey{},
 		&models.Secret{},
+		&models.ProcessorRun{},
+		&models.ProcessorRunAttempt{},
+		&models.ProcessorWorkflowVersion{},
+		&models.WorkflowSnippet{},
 	); err != nil {
 		return err
 	}
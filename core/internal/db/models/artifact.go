@@ -0,0 +1,62 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// ArtifactMode decides what happens to the saved outputs of a run once its
+// workflow completed.
+type ArtifactMode string
+
+const (
+	// ArtifactModeZip bundles the outputs in a single zip archive.
+	ArtifactModeZip ArtifactMode = "zip"
+	// ArtifactModeIndividual keeps every output as its own artifact.
+	ArtifactModeIndividual ArtifactMode = "individual"
+	// ArtifactModeCopy copies the outputs under the destination prefix of the processor.
+	ArtifactModeCopy ArtifactMode = "copy"
+	// ArtifactModeSkip leaves the outputs where the activities saved them.
+	ArtifactModeSkip ArtifactMode = "skip"
+)
+
+var ArtifactModes = []ArtifactMode{ArtifactModeZip, ArtifactModeIndividual, ArtifactModeCopy, ArtifactModeSkip}
+
+// Artifact is a file produced by a run. Checksum is the hex encoded SHA-256 of
+// the content, Files lists the content of a zip archive. ActivityKey is the
+// activity that saved the file, and UploadID the upload derived from it.
+type Artifact struct {
+	Name        string     `json:"name"`
+	Key         string     `json:"key,omitempty"`
+	Size        int64      `json:"size"`
+	ContentType string     `json:"contentType"`
+	Checksum    string     `json:"checksum"`
+	ActivityKey string     `json:"activityKey,omitempty"`
+	UploadID    string     `json:"uploadId,omitempty"`
+	Files       []Artifact `json:"files,omitempty"`
+}
+
+// ArtifactManifest lists the artifacts of a run. Key is the zip archive, or the
+// prefix the individual artifacts were written under.
+type ArtifactManifest struct {
+	Mode      ArtifactMode `json:"mode"`
+	Bucket    string       `json:"bucket,omitempty"`
+	Key       string       `json:"key,omitempty"`
+	Artifacts []Artifact   `json:"artifacts"`
+}
+
+func (m ArtifactManifest) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+func (m *ArtifactManifest) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(b, m)
+}
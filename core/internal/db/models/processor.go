@@ -1,20 +1,29 @@
 package models
 
-import "github.com/uploadpilot/core/internal/db/dtypes"
+import (
+	"fmt"
+
+	"github.com/uploadpilot/core/internal/db/dtypes"
+)
 
 type Processor struct {
 	ID                        string             `gorm:"column:id;primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
 	Name                      string             `gorm:"column:name;not null" json:"name,omitempty"`
 	WorkspaceID               string             `gorm:"column:workspace_id;not null;type:uuid" json:"workspaceId,omitempty"`
 	Triggers                  dtypes.StringArray `gorm:"column:triggers;not null;type:text[]" json:"triggers,omitempty"`
+	TriggerRule               *TriggerRule       `gorm:"column:trigger_rule;type:jsonb" json:"triggerRule,omitempty"`
 	Workflow                  string             `gorm:"column:workflow;type:text;not null; default:''" json:"workflow,omitempty"`
-	MaxRetries                int32              `gorm:"column:max_retries;not null;default:3" json:"maxRetries,omitempty"`
+	WorkflowVersion           int                `gorm:"column:workflow_version;not null;default:1" json:"workflowVersion,omitempty"`
+	MaxRetries                int32              `gorm:"column:max_retries;not null;default:3" json:"maxRetries"`
 	RetryInitialIntervalS     uint64             `gorm:"column:retry_initial_interval_s;not null;default:1" json:"retryInitialIntervalS,omitempty"`
 	RetryBackoffCoefficient   float64            `gorm:"column:retry_backoff_coefficient;not null;default:2.0" json:"retryBackoffCoefficient,omitempty"`
 	RetryMaxIntervalS         uint64             `gorm:"column:retry_max_interval_s;not null;default:60" json:"retryMaxIntervalS,omitempty"`
 	WorkflowExecutionTimeoutS uint64             `gorm:"column:workflow_execution_timeout_s;not null;default:3600" json:"workflowExecutionTimeoutS,omitempty"`
 	WorkflowRunTimeoutS       uint64             `gorm:"column:workflow_run_timeout_s;not null;default:3600" json:"workflowRunTimeoutS,omitempty"`
 	TaskRunTimeoutS           uint64             `gorm:"column:task_run_timeout_s;not null;default:600" json:"taskRunTimeoutS,omitempty"`
+	ArtifactMode              ArtifactMode       `gorm:"column:artifact_mode;not null;default:'zip'" json:"artifactMode,omitempty"`
+	ArtifactDestinationPrefix string             `gorm:"column:artifact_destination_prefix;not null;default:''" json:"artifactDestinationPrefix,omitempty"`
+	DeriveUploads             bool               `gorm:"column:derive_uploads;not null;default:false" json:"deriveUploads,omitempty"`
 	Enabled                   bool               `gorm:"column:enabled;not null;default:true" json:"enabled,omitempty"`
 	Workspace                 Workspace          `gorm:"foreignKey:workspace_id;constraint:OnDelete:CASCADE" json:"-"`
 	CreatedAtColumn
@@ -26,3 +35,22 @@ type Processor struct {
 func (*Processor) TableName() string {
 	return "processors"
 }
+
+// EvaluateTrigger matches the upload against the trigger rule of the processor.
+// Processors without a rule match when any of their triggers matches the
+// content type of the upload.
+func (p *Processor) EvaluateTrigger(upload *Upload) TriggerResult {
+	if p.TriggerRule != nil {
+		return p.TriggerRule.Evaluate(upload)
+	}
+	result := TriggerResult{Condition: "any"}
+	for _, trigger := range p.Triggers {
+		matched := MatchMimeType(trigger, upload.ContentType)
+		result.Matched = result.Matched || matched
+		result.Children = append(result.Children, TriggerResult{
+			Matched:   matched,
+			Condition: fmt.Sprintf("mimeType %q matches %q", trigger, upload.ContentType),
+		})
+	}
+	return result
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+)
+
+// ProcessorRun is one run of a processor workflow for an upload. It is created
+// when the workflow starts and updated when it completes.
+type ProcessorRun struct {
+	ID              string            `gorm:"column:id;primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	WorkspaceID     string            `gorm:"column:workspace_id;not null;type:uuid;index" json:"workspaceId"`
+	UploadID        string            `gorm:"column:upload_id;not null;type:uuid;index" json:"uploadId"`
+	ProcessorID     string            `gorm:"column:processor_id;not null;type:uuid;index" json:"processorId"`
+	WorkflowID      string            `gorm:"column:workflow_id;not null;uniqueIndex:idx_processor_run_workflow_run" json:"workflowId"`
+	RunID           string            `gorm:"column:run_id;not null;uniqueIndex:idx_processor_run_workflow_run" json:"runId"`
+	Attempt         int               `gorm:"column:attempt;not null;default:1" json:"attempt"`
+	WorkflowVersion int               `gorm:"column:workflow_version;not null;default:1" json:"workflowVersion"`
+	Status          RunStatus         `gorm:"column:status;not null" json:"status"`
+	Error           string            `gorm:"column:error;type:text" json:"error,omitempty"`
+	ArtifactKey     string            `gorm:"column:artifact_key" json:"artifactKey,omitempty"`
+	Artifacts       *ArtifactManifest `gorm:"column:artifacts;type:jsonb" json:"artifacts,omitempty"`
+	StartedAt       time.Time         `gorm:"column:started_at;not null" json:"startedAt"`
+	FinishedAt      *time.Time        `gorm:"column:finished_at" json:"finishedAt,omitempty"`
+	DurationMillis  int64             `gorm:"column:duration_millis;not null;default:0" json:"durationMillis"`
+	Upload          Upload            `gorm:"foreignKey:UploadID;constraint:OnDelete:CASCADE" json:"-"`
+	Processor       Processor         `gorm:"foreignKey:ProcessorID;constraint:OnDelete:CASCADE" json:"-"`
+	CreatedAtColumn
+	UpdatedAtColumn
+}
+
+func (*ProcessorRun) TableName() string {
+	return "processor_runs"
+}
+
+type RunStatus string
+
+const (
+	RunStatusRunning   RunStatus = "Running"
+	RunStatusCompleted RunStatus = "Completed"
+	RunStatusFailed    RunStatus = "Failed"
+	RunStatusCanceled  RunStatus = "Canceled"
+)
+
+// ProcessorRunAttempt is the last attempt of a processor that was started for
+// an upload. Reruns reserve their attempt here before the workflow starts,
+// because the runs are only recorded once their workflow runs.
+type ProcessorRunAttempt struct {
+	ProcessorID string    `gorm:"column:processor_id;primaryKey;type:uuid" json:"processorId"`
+	UploadID    string    `gorm:"column:upload_id;primaryKey;type:uuid" json:"uploadId"`
+	Attempt     int       `gorm:"column:attempt;not null" json:"attempt"`
+	Upload      Upload    `gorm:"foreignKey:UploadID;constraint:OnDelete:CASCADE" json:"-"`
+	Processor   Processor `gorm:"foreignKey:ProcessorID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (*ProcessorRunAttempt) TableName() string {
+	return "processor_run_attempts"
+}
@@ -13,9 +13,9 @@ type Secret struct {
 	ID          string    `gorm:"column:id;primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
 	WorkspaceID string    `gorm:"column:workspace_id;not null;uniqueIndex:idx_secret_workspace_id_key;type:uuid" json:"workspaceId"  validate:"required,uuid"`
 	Key         string    `gorm:"column:key;not null;type:varchar(255);uniqueIndex:idx_secret_workspace_id_key" json:"key" validate:"required"`
-	Value       string    `gorm:"column:value;not null" json:"value,omitempty" validate:"required"`
-	Salt        string    `gorm:"column:salt;not null" json:"salt,omitempty" validate:"required"`
-	Workspace   Workspace `gorm:"foreignKey:WorkspaceID;constraint:OnDelete:CASCADE" json:"workspace"`
+	Value       string    `gorm:"column:value;not null" json:"-" validate:"required"`
+	Salt        string    `gorm:"column:salt;not null" json:"-" validate:"required"`
+	Workspace   Workspace `gorm:"foreignKey:WorkspaceID;constraint:OnDelete:CASCADE" json:"-"`
 	CreatedAtColumn
 	UpdatedAtColumn
 	CreatedByColumn
@@ -38,6 +38,5 @@ func (s *Secret) verifyEntry() error {
 	if !keyRegex.MatchString(s.Key) {
 		return errors.New("key must start with an alphabet and contain only alphanumeric characters and underscores")
 	}
-	s.ID = s.WorkspaceID + "_" + s.Key
 	return nil
 }
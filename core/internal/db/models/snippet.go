@@ -0,0 +1,33 @@
+package models
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// WorkflowSnippet is a workflow shared by the processors of a workspace. It is
+// run with a call statement and never on its own.
+type WorkflowSnippet struct {
+	ID          string    `gorm:"column:id;primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	WorkspaceID string    `gorm:"column:workspace_id;not null;uniqueIndex:idx_workflow_snippet_workspace_id_name;type:uuid" json:"workspaceId"`
+	Name        string    `gorm:"column:name;not null;type:varchar(255);uniqueIndex:idx_workflow_snippet_workspace_id_name" json:"name"`
+	Description string    `gorm:"column:description;type:text" json:"description"`
+	Workflow    string    `gorm:"column:workflow;type:text;not null" json:"workflow,omitempty"`
+	Workspace   Workspace `gorm:"foreignKey:WorkspaceID;constraint:OnDelete:CASCADE" json:"-"`
+	CreatedAtColumn
+	UpdatedAtColumn
+	CreatedByColumn
+	UpdatedByColumn
+}
+
+func (*WorkflowSnippet) TableName() string {
+	return "workflow_snippets"
+}
+
+func (s *WorkflowSnippet) BeforeCreate(tx *gorm.DB) error {
+	if !keyRegex.MatchString(s.Name) {
+		return errors.New("name must start with an alphabet and contain only alphanumeric characters and underscores")
+	}
+	return nil
+}
@@ -0,0 +1,341 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// MaxTriggerRuleDepth limits the nesting of all and any groups.
+const MaxTriggerRuleDepth = 8
+
+// TriggerRule decides whether a processor runs for an upload. A rule holds
+// either a group of rules in All or Any, or conditions that must all be met.
+type TriggerRule struct {
+	All       []TriggerRule      `json:"all,omitempty"`
+	Any       []TriggerRule      `json:"any,omitempty"`
+	MimeType  string             `json:"mimeType,omitempty"`
+	Extension string             `json:"extension,omitempty"`
+	FileName  string             `json:"fileName,omitempty"`
+	Size      *SizeRange         `json:"size,omitempty"`
+	Metadata  *MetadataPredicate `json:"metadata,omitempty"`
+}
+
+// SizeRange is an inclusive range of sizes in bytes, either bound is optional.
+type SizeRange struct {
+	Min *int64 `json:"min,omitempty"`
+	Max *int64 `json:"max,omitempty"`
+}
+
+type MetadataOperator string
+
+const (
+	MetadataOpEq        MetadataOperator = "eq"
+	MetadataOpNeq       MetadataOperator = "neq"
+	MetadataOpIn        MetadataOperator = "in"
+	MetadataOpExists    MetadataOperator = "exists"
+	MetadataOpNotExists MetadataOperator = "notExists"
+	MetadataOpGt        MetadataOperator = "gt"
+	MetadataOpGte       MetadataOperator = "gte"
+	MetadataOpLt        MetadataOperator = "lt"
+	MetadataOpLte       MetadataOperator = "lte"
+)
+
+var metadataOperators = []MetadataOperator{
+	MetadataOpEq, MetadataOpNeq, MetadataOpIn, MetadataOpExists, MetadataOpNotExists,
+	MetadataOpGt, MetadataOpGte, MetadataOpLt, MetadataOpLte,
+}
+
+// MetadataPredicate compares a metadata value of the upload. Key is a dot
+// separated path into the metadata, eg. "invoice.country".
+type MetadataPredicate struct {
+	Key    string           `json:"key"`
+	Op     MetadataOperator `json:"op"`
+	Value  interface{}      `json:"value,omitempty"`
+	Values []interface{}    `json:"values,omitempty"`
+}
+
+// TriggerResult is the outcome of evaluating a rule. It mirrors the rule, so
+// it explains why a processor would run or not.
+type TriggerResult struct {
+	Matched   bool            `json:"matched"`
+	Condition string          `json:"condition"`
+	Children  []TriggerResult `json:"children,omitempty"`
+}
+
+func (r TriggerRule) Value() (driver.Value, error) {
+	return json.Marshal(r)
+}
+
+func (r *TriggerRule) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(b, r)
+}
+
+// Validate reports the first invalid condition of the rule by its path.
+func (r *TriggerRule) Validate() error {
+	return r.validate("triggerRule", 1)
+}
+
+func (r *TriggerRule) validate(p string, depth int) error {
+	if depth > MaxTriggerRuleDepth {
+		return fmt.Errorf("%s: rules must not be nested deeper than %d levels", p, MaxTriggerRuleDepth)
+	}
+	isGroup := r.All != nil || r.Any != nil
+	hasConditions := r.MimeType != "" || r.Extension != "" || r.FileName != "" || r.Size != nil || r.Metadata != nil
+	switch {
+	case isGroup && hasConditions:
+		return fmt.Errorf("%s: a rule is either a group (all, any) or a list of conditions", p)
+	case r.All != nil && r.Any != nil:
+		return fmt.Errorf("%s: a group is either all or any, nest groups to combine them", p)
+	case !isGroup && !hasConditions:
+		return fmt.Errorf("%s: rule has no conditions", p)
+	}
+
+	name, rules := "all", r.All
+	if r.Any != nil {
+		name, rules = "any", r.Any
+	}
+	if isGroup && len(rules) == 0 {
+		return fmt.Errorf("%s.%s: group must not be empty", p, name)
+	}
+	for i := range rules {
+		if err := rules[i].validate(fmt.Sprintf("%s.%s[%d]", p, name, i), depth+1); err != nil {
+			return err
+		}
+	}
+
+	for _, pattern := range [][2]string{{"mimeType", r.MimeType}, {"extension", r.Extension}, {"fileName", r.FileName}} {
+		if _, err := path.Match(pattern[1], ""); err != nil {
+			return fmt.Errorf("%s.%s: invalid pattern %q", p, pattern[0], pattern[1])
+		}
+	}
+	if r.Size != nil {
+		if r.Size.Min == nil && r.Size.Max == nil {
+			return fmt.Errorf("%s.size: min or max is required", p)
+		}
+		if (r.Size.Min != nil && *r.Size.Min < 0) || (r.Size.Max != nil && *r.Size.Max < 0) {
+			return fmt.Errorf("%s.size: sizes must not be negative", p)
+		}
+		if r.Size.Min != nil && r.Size.Max != nil && *r.Size.Min > *r.Size.Max {
+			return fmt.Errorf("%s.size: min must not be greater than max", p)
+		}
+	}
+	if m := r.Metadata; m != nil {
+		if m.Key == "" {
+			return fmt.Errorf("%s.metadata.key: key is required", p)
+		}
+		if !slices.Contains(metadataOperators, m.Op) {
+			return fmt.Errorf("%s.metadata.op: unknown operator %q", p, m.Op)
+		}
+		switch m.Op {
+		case MetadataOpIn:
+			if len(m.Values) == 0 {
+				return fmt.Errorf("%s.metadata.values: values are required for the in operator", p)
+			}
+		case MetadataOpGt, MetadataOpGte, MetadataOpLt, MetadataOpLte:
+			if _, ok := toNumber(m.Value); !ok {
+				return fmt.Errorf("%s.metadata.value: a number is required for the %s operator", p, m.Op)
+			}
+		case MetadataOpEq, MetadataOpNeq:
+			if m.Value == nil {
+				return fmt.Errorf("%s.metadata.value: value is required for the %s operator", p, m.Op)
+			}
+		}
+	}
+	return nil
+}
+
+// Evaluate matches the rule against an upload.
+func (r *TriggerRule) Evaluate(upload *Upload) TriggerResult {
+	if r.All != nil || r.Any != nil {
+		rules, condition := r.All, "all"
+		if r.Any != nil {
+			rules, condition = r.Any, "any"
+		}
+		result := TriggerResult{Matched: condition == "all", Condition: condition}
+		for i := range rules {
+			child := rules[i].Evaluate(upload)
+			result.Children = append(result.Children, child)
+			if condition == "all" {
+				result.Matched = result.Matched && child.Matched
+			} else {
+				result.Matched = result.Matched || child.Matched
+			}
+		}
+		return result
+	}
+
+	var conditions []TriggerResult
+	if r.MimeType != "" {
+		conditions = append(conditions, TriggerResult{
+			Matched:   MatchMimeType(r.MimeType, upload.ContentType),
+			Condition: fmt.Sprintf("mimeType %q matches %q", r.MimeType, upload.ContentType),
+		})
+	}
+	if r.Extension != "" {
+		ext := fileExtension(upload.FileName)
+		pattern := strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(r.Extension, "*"), "."))
+		matched, _ := path.Match(pattern, ext)
+		conditions = append(conditions, TriggerResult{
+			Matched:   matched && ext != "",
+			Condition: fmt.Sprintf("extension %q matches %q", r.Extension, ext),
+		})
+	}
+	if r.FileName != "" {
+		matched, _ := path.Match(r.FileName, upload.FileName)
+		conditions = append(conditions, TriggerResult{
+			Matched:   matched,
+			Condition: fmt.Sprintf("fileName %q matches %q", r.FileName, upload.FileName),
+		})
+	}
+	if r.Size != nil {
+		conditions = append(conditions, TriggerResult{
+			Matched:   (r.Size.Min == nil || upload.ContentLength >= *r.Size.Min) && (r.Size.Max == nil || upload.ContentLength <= *r.Size.Max),
+			Condition: fmt.Sprintf("size %d bytes is within %s", upload.ContentLength, r.Size),
+		})
+	}
+	if r.Metadata != nil {
+		conditions = append(conditions, r.Metadata.evaluate(upload.Metadata))
+	}
+
+	if len(conditions) == 1 {
+		return conditions[0]
+	}
+	result := TriggerResult{Matched: true, Condition: "all", Children: conditions}
+	for _, c := range conditions {
+		result.Matched = result.Matched && c.Matched
+	}
+	return result
+}
+
+func (s *SizeRange) String() string {
+	bound := func(b *int64) string {
+		if b == nil {
+			return "*"
+		}
+		return strconv.FormatInt(*b, 10)
+	}
+	return fmt.Sprintf("[%s, %s]", bound(s.Min), bound(s.Max))
+}
+
+func (m *MetadataPredicate) evaluate(metadata map[string]interface{}) TriggerResult {
+	value, found := lookupMetadata(metadata, m.Key)
+	result := TriggerResult{Condition: fmt.Sprintf("metadata %q %s", m.Key, m.Op)}
+	switch m.Op {
+	case MetadataOpExists:
+		result.Matched = found
+	case MetadataOpNotExists:
+		result.Matched = !found
+	case MetadataOpEq:
+		result.Condition += fmt.Sprintf(" %v", m.Value)
+		result.Matched = found && metadataEqual(value, m.Value)
+	case MetadataOpNeq:
+		result.Condition += fmt.Sprintf(" %v", m.Value)
+		result.Matched = !found || !metadataEqual(value, m.Value)
+	case MetadataOpIn:
+		result.Condition += fmt.Sprintf(" %v", m.Values)
+		result.Matched = found && slices.ContainsFunc(m.Values, func(v interface{}) bool {
+			return metadataEqual(value, v)
+		})
+	case MetadataOpGt, MetadataOpGte, MetadataOpLt, MetadataOpLte:
+		result.Condition += fmt.Sprintf(" %v", m.Value)
+		actual, ok := toNumber(value)
+		expected, _ := toNumber(m.Value)
+		if found && ok {
+			switch m.Op {
+			case MetadataOpGt:
+				result.Matched = actual > expected
+			case MetadataOpGte:
+				result.Matched = actual >= expected
+			case MetadataOpLt:
+				result.Matched = actual < expected
+			case MetadataOpLte:
+				result.Matched = actual <= expected
+			}
+		}
+	}
+	if found {
+		result.Condition += fmt.Sprintf(" (value %v)", value)
+	} else {
+		result.Condition += " (not set)"
+	}
+	return result
+}
+
+// MatchMimeType matches a content type against a pattern like "image/*". The
+// parameters of the content type, eg. the charset, are ignored.
+func MatchMimeType(pattern, contentType string) bool {
+	contentType, _, _ = strings.Cut(contentType, ";")
+	contentType = strings.ToLower(strings.TrimSpace(contentType))
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if pattern == "*" {
+		return contentType != ""
+	}
+	matched, _ := path.Match(pattern, contentType)
+	return matched
+}
+
+func fileExtension(fileName string) string {
+	return strings.ToLower(strings.TrimPrefix(path.Ext(fileName), "."))
+}
+
+func lookupMetadata(metadata map[string]interface{}, key string) (interface{}, bool) {
+	var value interface{} = metadata
+	for _, part := range strings.Split(key, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// metadataEqual compares numbers by value and everything else by its JSON
+// form, metadata decoded from JSON holds float64 numbers only.
+func metadataEqual(a, b interface{}) bool {
+	if x, ok := toNumber(a); ok {
+		y, ok := toNumber(b)
+		return ok && x == y
+	}
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+	x, errA := json.Marshal(a)
+	y, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(x) == string(y)
+}
+
+func toNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
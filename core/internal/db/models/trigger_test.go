@@ -0,0 +1,103 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseRule(t *testing.T, data string) *TriggerRule {
+	var rule TriggerRule
+	require.NoError(t, json.Unmarshal([]byte(data), &rule))
+	require.NoError(t, rule.Validate())
+	return &rule
+}
+
+func TestTriggerRuleEvaluate(t *testing.T) {
+	invoice := &Upload{
+		FileName:      "March Invoice.PDF",
+		ContentType:   "application/pdf",
+		ContentLength: 2048,
+		Metadata:      map[string]interface{}{"docType": "invoice", "pages": float64(3), "customer": map[string]interface{}{"country": "DE"}},
+	}
+	photo := &Upload{FileName: "cat.jpeg", ContentType: "image/jpeg; charset=binary", ContentLength: 5 << 20}
+
+	testCases := []struct {
+		name    string
+		rule    string
+		matches []bool
+	}{
+		{name: "mime wildcard", rule: `{"mimeType": "image/*"}`, matches: []bool{false, true}},
+		{name: "any mime", rule: `{"mimeType": "*"}`, matches: []bool{true, true}},
+		{name: "extension glob", rule: `{"extension": "*.jp*g"}`, matches: []bool{false, true}},
+		{name: "extension is case insensitive", rule: `{"extension": "pdf"}`, matches: []bool{true, false}},
+		{name: "file name glob", rule: `{"fileName": "March*"}`, matches: []bool{true, false}},
+		{name: "size range", rule: `{"size": {"max": 1048576}}`, matches: []bool{true, false}},
+		{name: "metadata eq", rule: `{"metadata": {"key": "docType", "op": "eq", "value": "invoice"}}`, matches: []bool{true, false}},
+		{name: "metadata neq", rule: `{"metadata": {"key": "docType", "op": "neq", "value": "invoice"}}`, matches: []bool{false, true}},
+		{name: "nested metadata in", rule: `{"metadata": {"key": "customer.country", "op": "in", "values": ["DE", "FR"]}}`, matches: []bool{true, false}},
+		{name: "metadata number", rule: `{"metadata": {"key": "pages", "op": "gte", "value": 3}}`, matches: []bool{true, false}},
+		{name: "metadata exists", rule: `{"metadata": {"key": "docType", "op": "exists"}}`, matches: []bool{true, false}},
+		{name: "conditions of a rule are combined", rule: `{"mimeType": "application/pdf", "size": {"min": 4096}}`, matches: []bool{false, false}},
+		{
+			name: "groups",
+			rule: `{"any": [
+				{"all": [{"mimeType": "application/pdf"}, {"metadata": {"key": "docType", "op": "eq", "value": "invoice"}}]},
+				{"all": [{"mimeType": "image/*"}, {"size": {"min": 1048576}}]}
+			]}`,
+			matches: []bool{true, true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rule := parseRule(t, tc.rule)
+			assert.Equal(t, tc.matches[0], rule.Evaluate(invoice).Matched, "invoice")
+			assert.Equal(t, tc.matches[1], rule.Evaluate(photo).Matched, "photo")
+		})
+	}
+}
+
+func TestTriggerRuleExplainsResult(t *testing.T) {
+	rule := parseRule(t, `{"all": [{"mimeType": "image/*"}, {"metadata": {"key": "docType", "op": "eq", "value": "invoice"}}]}`)
+	result := rule.Evaluate(&Upload{ContentType: "image/png"})
+	assert.Equal(t, TriggerResult{
+		Matched:   false,
+		Condition: "all",
+		Children: []TriggerResult{
+			{Matched: true, Condition: `mimeType "image/*" matches "image/png"`},
+			{Matched: false, Condition: `metadata "docType" eq invoice (not set)`},
+		},
+	}, result)
+}
+
+func TestTriggerRuleValidate(t *testing.T) {
+	testCases := []struct {
+		rule string
+		err  string
+	}{
+		{rule: `{}`, err: "triggerRule: rule has no conditions"},
+		{rule: `{"all": []}`, err: "triggerRule.all: group must not be empty"},
+		{rule: `{"all": [{"mimeType": "image/*"}], "mimeType": "image/png"}`, err: "triggerRule: a rule is either a group (all, any) or a list of conditions"},
+		{rule: `{"any": [{"extension": "[jpg"}]}`, err: `triggerRule.any[0].extension: invalid pattern "[jpg"`},
+		{rule: `{"size": {"min": 10, "max": 1}}`, err: "triggerRule.size: min must not be greater than max"},
+		{rule: `{"metadata": {"key": "pages", "op": "gt", "value": "3"}}`, err: "triggerRule.metadata.value: a number is required for the gt operator"},
+		{rule: `{"metadata": {"key": "pages", "op": "like"}}`, err: `triggerRule.metadata.op: unknown operator "like"`},
+	}
+
+	for _, tc := range testCases {
+		var rule TriggerRule
+		require.NoError(t, json.Unmarshal([]byte(tc.rule), &rule))
+		assert.EqualError(t, rule.Validate(), tc.err, tc.rule)
+	}
+}
+
+func TestProcessorTriggersMatchContentTypes(t *testing.T) {
+	processor := &Processor{Triggers: []string{"image/*", "application/pdf"}}
+	assert.True(t, processor.EvaluateTrigger(&Upload{ContentType: "image/png"}).Matched)
+	assert.True(t, processor.EvaluateTrigger(&Upload{ContentType: "application/pdf"}).Matched)
+	assert.False(t, processor.EvaluateTrigger(&Upload{ContentType: "text/plain"}).Matched)
+	assert.False(t, (&Processor{}).EvaluateTrigger(&Upload{ContentType: "text/plain"}).Matched)
+}
@@ -14,9 +14,15 @@ type Upload struct {
 	ContentLength int64        `gorm:"column:content_length" json:"contentLength,omitempty"`
 	Metadata      dtypes.JSONB `gorm:"column:metadata;type:jsonb" json:"metadata,omitempty"`
 	Status        UploadStatus `gorm:"column:status;not null" json:"status,omitempty"`
-	StartedAt     time.Time    `gorm:"column:started_at;default:now()" json:"startedAt,omitempty"`
-	FinishedAt    time.Time    `gorm:"column:finished_at" json:"finishedAt,omitempty"`
-	Workspace     Workspace    `gorm:"foreignKey:workspace_id;constraint:OnDelete:CASCADE" json:"-"`
+	// A derived upload records the upload, and the processor, run and activity
+	// of the run it was produced by.
+	ParentID    *string   `gorm:"column:parent_id;type:uuid;index" json:"parentId,omitempty"`
+	ProcessorID *string   `gorm:"column:processor_id;type:uuid" json:"processorId,omitempty"`
+	RunID       string    `gorm:"column:run_id" json:"runId,omitempty"`
+	ActivityKey string    `gorm:"column:activity_key" json:"activityKey,omitempty"`
+	StartedAt   time.Time `gorm:"column:started_at;default:now()" json:"startedAt,omitempty"`
+	FinishedAt  time.Time `gorm:"column:finished_at" json:"finishedAt,omitempty"`
+	Workspace   Workspace `gorm:"foreignKey:workspace_id;constraint:OnDelete:CASCADE" json:"-"`
 }
 
 type UploadStatus string
@@ -42,6 +48,7 @@ var UploadTerminalStates = []UploadStatus{
 	UploadStatusCancelled,
 	UploadStatusProcessingFailed,
 	UploadStatusProcessingComplete,
+	UploadStatusProcessingCancelled,
 	UploadStatusDeleted,
 	UploadStatusTimedOut,
 }
@@ -0,0 +1,20 @@
+package models
+
+// ProcessorWorkflowVersion is a saved workflow of a processor. Versions are
+// numbered from 1 and never change, a rollback saves the old workflow as a new
+// version.
+type ProcessorWorkflowVersion struct {
+	ID           string    `gorm:"column:id;primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	ProcessorID  string    `gorm:"column:processor_id;not null;type:uuid;uniqueIndex:idx_processor_workflow_version" json:"processorId"`
+	WorkspaceID  string    `gorm:"column:workspace_id;not null;type:uuid" json:"workspaceId"`
+	Version      int       `gorm:"column:version;not null;uniqueIndex:idx_processor_workflow_version" json:"version"`
+	Workflow     string    `gorm:"column:workflow;type:text;not null" json:"workflow,omitempty"`
+	RestoredFrom int       `gorm:"column:restored_from;not null;default:0" json:"restoredFrom,omitempty"`
+	Processor    Processor `gorm:"foreignKey:ProcessorID;constraint:OnDelete:CASCADE" json:"-"`
+	CreatedAtColumn
+	CreatedByColumn
+}
+
+func (*ProcessorWorkflowVersion) TableName() string {
+	return "processor_workflow_versions"
+}
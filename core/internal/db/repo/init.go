@@ -11,6 +11,8 @@ type Repositories struct {
 	ProcessorRepo       *ProcessorRepo
 	APIKeyRepo          *APIKeyRepo
 	SecretsRepo         *SecretRepo
+	ProcessorRunRepo    *ProcessorRunRepo
+	SnippetRepo         *WorkflowSnippetRepo
 }
 
 func NewRepositories(driver *driver.Driver) *Repositories {
@@ -23,5 +25,7 @@ func NewRepositories(driver *driver.Driver) *Repositories {
 		ProcessorRepo:       NewProcessorRepo(driver),
 		APIKeyRepo:          NewAPIKeyRepo(driver),
 		SecretsRepo:         NewSecretRepo(driver),
+		ProcessorRunRepo:    NewProcessorRunRepo(driver),
+		SnippetRepo:         NewWorkflowSnippetRepo(driver),
 	}
 }
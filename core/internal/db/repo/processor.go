@@ -6,6 +6,8 @@ import (
 	"github.com/uploadpilot/core/internal/db/driver"
 	"github.com/uploadpilot/core/internal/db/models"
 	dbutils "github.com/uploadpilot/core/internal/db/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ProcessorRepo struct {
@@ -21,7 +23,9 @@ func NewProcessorRepo(db *driver.Driver) *ProcessorRepo {
 func (r *ProcessorRepo) GetAll(ctx context.Context, workspaceID string) ([]models.Processor, error) {
 	var processors []models.Processor
 	err := r.db.Orm.WithContext(ctx).
-		Select("id", "name", "triggers", "enabled", "workflow", "updated_at").
+		Select("id", "name", "triggers", "trigger_rule", "enabled", "workflow", "workflow_version", "max_retries",
+			"retry_initial_interval_s", "retry_backoff_coefficient", "retry_max_interval_s", "workflow_execution_timeout_s",
+			"workflow_run_timeout_s", "task_run_timeout_s", "artifact_mode", "artifact_destination_prefix", "derive_uploads", "updated_at").
 		Where("workspace_id = ?", workspaceID).
 		Order("enabled desc, updated_at desc").
 		Find(&processors).Error
@@ -45,12 +49,26 @@ func (r *ProcessorRepo) Get(ctx context.Context, processorID string) (*models.Pr
 	return &processor, nil
 }
 
+// Create saves the processor and its workflow as version 1.
 func (r *ProcessorRepo) Create(ctx context.Context, processor *models.Processor) error {
-	err := r.db.Orm.WithContext(ctx).Create(processor).Error
-	if err != nil {
-		return dbutils.DBError(ctx, r.db.Orm.Logger, err)
-	}
-	return nil
+	return r.db.Orm.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		processor.WorkflowVersion = 1
+		if err := tx.Create(processor).Error; err != nil {
+			return dbutils.DBError(ctx, r.db.Orm.Logger, err)
+		}
+
+		version := &models.ProcessorWorkflowVersion{
+			ProcessorID: processor.ID,
+			WorkspaceID: processor.WorkspaceID,
+			Version:     processor.WorkflowVersion,
+			Workflow:    processor.Workflow,
+		}
+		version.CreatedBy = processor.CreatedBy
+		if err := tx.Create(version).Error; err != nil {
+			return dbutils.DBError(ctx, r.db.Orm.Logger, err)
+		}
+		return nil
+	})
 }
 
 func (r *ProcessorRepo) Patch(ctx context.Context, workspaceID, processorID string, patch map[string]interface{}) error {
@@ -67,12 +85,92 @@ func (r *ProcessorRepo) Delete(ctx context.Context, workspaceID, processorID str
 	return nil
 }
 
-func (r *ProcessorRepo) SaveWorkflow(ctx context.Context, workspaceID, processorID string, workflow string) error {
-	patch := map[string]interface{}{
-		"workflow": workflow,
+// SaveWorkflow saves the workflow as the next version of the processor and
+// returns that version. Saving the current workflow again keeps its version.
+func (r *ProcessorRepo) SaveWorkflow(ctx context.Context, workspaceID, processorID, workflow, userID string, restoredFrom int) (int, error) {
+	var version int
+	err := r.db.Orm.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var processor models.Processor
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "workspace_id", "workflow", "workflow_version", "updated_by", "updated_at").
+			First(&processor, "id = ? AND workspace_id = ?", processorID, workspaceID).Error; err != nil {
+			return err
+		}
+		if processor.Workflow == workflow {
+			version = processor.WorkflowVersion
+			return nil
+		}
+
+		// processors saved before versions were recorded have no version yet
+		var count int64
+		if err := tx.Model(&models.ProcessorWorkflowVersion{}).
+			Where("processor_id = ? AND version = ?", processorID, processor.WorkflowVersion).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			current := &models.ProcessorWorkflowVersion{
+				ProcessorID: processorID,
+				WorkspaceID: workspaceID,
+				Version:     processor.WorkflowVersion,
+				Workflow:    processor.Workflow,
+			}
+			current.CreatedBy = processor.UpdatedBy
+			if err := tx.Create(current).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(current).UpdateColumn("created_at", processor.UpdatedAt).Error; err != nil {
+				return err
+			}
+		}
+
+		version = processor.WorkflowVersion + 1
+		patch := map[string]interface{}{
+			"workflow":         workflow,
+			"workflow_version": version,
+			"updated_by":       userID,
+		}
+		if err := tx.Model(&models.Processor{}).Where("id = ?", processorID).Updates(patch).Error; err != nil {
+			return err
+		}
+		next := &models.ProcessorWorkflowVersion{
+			ProcessorID:  processorID,
+			WorkspaceID:  workspaceID,
+			Version:      version,
+			Workflow:     workflow,
+			RestoredFrom: restoredFrom,
+		}
+		next.CreatedBy = userID
+		return tx.Create(next).Error
+	})
+	if err != nil {
+		return 0, dbutils.DBError(ctx, r.db.Orm.Logger, err)
 	}
-	if err := r.db.Orm.WithContext(ctx).Model(&models.Processor{}).Where("id = ?", processorID).Updates(patch).Error; err != nil {
-		return dbutils.DBError(ctx, r.db.Orm.Logger, err)
+	return version, nil
+}
+
+// GetWorkflowVersions returns the versions of a processor without their
+// workflows, newest first.
+func (r *ProcessorRepo) GetWorkflowVersions(ctx context.Context, workspaceID, processorID string) ([]models.ProcessorWorkflowVersion, error) {
+	var versions []models.ProcessorWorkflowVersion
+	err := r.db.Orm.WithContext(ctx).
+		Select("id", "processor_id", "workspace_id", "version", "restored_from", "created_at", "created_by").
+		Where("workspace_id = ? AND processor_id = ?", workspaceID, processorID).
+		Order("version DESC").
+		Find(&versions).Error
+	if err != nil {
+		return nil, dbutils.DBError(ctx, r.db.Orm.Logger, err)
 	}
-	return nil
+	return versions, nil
+}
+
+func (r *ProcessorRepo) GetWorkflowVersion(ctx context.Context, workspaceID, processorID string, version int) (*models.ProcessorWorkflowVersion, error) {
+	var workflowVersion models.ProcessorWorkflowVersion
+	err := r.db.Orm.WithContext(ctx).
+		Where("workspace_id = ? AND processor_id = ? AND version = ?", workspaceID, processorID, version).
+		First(&workflowVersion).Error
+	if err != nil {
+		return nil, dbutils.DBError(ctx, r.db.Orm.Logger, err)
+	}
+	return &workflowVersion, nil
 }
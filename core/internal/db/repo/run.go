@@ -0,0 +1,139 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/uploadpilot/core/internal/db/driver"
+	"github.com/uploadpilot/core/internal/db/models"
+	dbutils "github.com/uploadpilot/core/internal/db/utils"
+	"gorm.io/gorm/clause"
+)
+
+type ProcessorRunRepo struct {
+	db *driver.Driver
+}
+
+func NewProcessorRunRepo(db *driver.Driver) *ProcessorRunRepo {
+	return &ProcessorRunRepo{
+		db: db,
+	}
+}
+
+func (r *ProcessorRunRepo) GetAll(ctx context.Context, workspaceID, processorID string, paginationParams *models.PaginationParams) ([]models.ProcessorRun, int64, error) {
+	var runs []models.ProcessorRun
+
+	query := r.db.Orm.WithContext(ctx).
+		Model(&models.ProcessorRun{}).
+		Where("workspace_id = ? AND processor_id = ?", workspaceID, processorID)
+
+	query, totalRecords, sortApplied, err := dbutils.BuildPaginationQuery(
+		query,
+		&dbutils.PaginationQueryInput{
+			PaginationParams:    paginationParams,
+			AllowedSearchFields: []string{"upload_id::text", "workflow_id", "run_id", "status", "error"},
+			AllowedFilterFields: []string{"status", "upload_id", "attempt", "workflow_version"},
+		},
+	)
+	if err != nil {
+		return nil, 0, dbutils.DBError(ctx, r.db.Orm.Logger, err)
+	}
+
+	if !sortApplied {
+		query = query.Order("started_at DESC")
+	}
+
+	if err := query.Find(&runs).Error; err != nil {
+		return nil, 0, dbutils.DBError(ctx, r.db.Orm.Logger, err)
+	}
+
+	return runs, totalRecords, nil
+}
+
+func (r *ProcessorRunRepo) Get(ctx context.Context, workspaceID, runID string) (*models.ProcessorRun, error) {
+	var run models.ProcessorRun
+	if err := r.db.Orm.WithContext(ctx).First(&run, "workspace_id = ? AND run_id = ?", workspaceID, runID).Error; err != nil {
+		return nil, dbutils.DBError(ctx, r.db.Orm.Logger, err)
+	}
+	return &run, nil
+}
+
+func (r *ProcessorRunRepo) GetByWorkflowRun(ctx context.Context, workflowID, runID string) (*models.ProcessorRun, error) {
+	var run models.ProcessorRun
+	if err := r.db.Orm.WithContext(ctx).First(&run, "workflow_id = ? AND run_id = ?", workflowID, runID).Error; err != nil {
+		return nil, dbutils.DBError(ctx, r.db.Orm.Logger, err)
+	}
+	return &run, nil
+}
+
+// Create saves a new run. A run that already exists is left as it is, so the
+// start hook can be retried.
+func (r *ProcessorRunRepo) Create(ctx context.Context, run *models.ProcessorRun) error {
+	err := r.db.Orm.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(run).Error
+	if err != nil {
+		return dbutils.DBError(ctx, r.db.Orm.Logger, err)
+	}
+	return nil
+}
+
+func (r *ProcessorRunRepo) Update(ctx context.Context, run *models.ProcessorRun) error {
+	if err := r.db.Orm.WithContext(ctx).Save(run).Error; err != nil {
+		return dbutils.DBError(ctx, r.db.Orm.Logger, err)
+	}
+	return nil
+}
+
+// NextAttempt reserves the next attempt of the processor for the upload. The
+// first reservation starts after the highest recorded attempt, and never at
+// attempt 1, which is started when the upload finishes. The upsert is atomic,
+// so concurrent reruns reserve different attempts.
+func (r *ProcessorRunRepo) NextAttempt(ctx context.Context, processorID, uploadID string) (int, error) {
+	var attempt int
+	query := `
+		INSERT INTO processor_run_attempts (processor_id, upload_id, attempt)
+		SELECT ?, ?, GREATEST(COALESCE(MAX(attempt), 0), 1) + 1
+		FROM processor_runs
+		WHERE processor_id = ? AND upload_id = ?
+		ON CONFLICT (processor_id, upload_id)
+		DO UPDATE SET attempt = processor_run_attempts.attempt + 1
+		RETURNING attempt
+	`
+	err := r.db.Orm.WithContext(ctx).Raw(query, processorID, uploadID, processorID, uploadID).Scan(&attempt).Error
+	if err != nil {
+		return 0, dbutils.DBError(ctx, r.db.Orm.Logger, err)
+	}
+	return attempt, nil
+}
+
+// GetLatestStatuses returns the status of the latest run of every processor
+// that processed the upload.
+func (r *ProcessorRunRepo) GetLatestStatuses(ctx context.Context, uploadID string) ([]models.RunStatus, error) {
+	var statuses []models.RunStatus
+	query := `
+		SELECT DISTINCT ON (processor_id) status
+		FROM processor_runs
+		WHERE upload_id = ?
+		ORDER BY processor_id, started_at DESC
+	`
+	if err := r.db.Orm.WithContext(ctx).Raw(query, uploadID).Scan(&statuses).Error; err != nil {
+		return nil, dbutils.DBError(ctx, r.db.Orm.Logger, err)
+	}
+	return statuses, nil
+}
+
+// GetLatestRuns returns the latest run of every processor that processed the
+// upload.
+func (r *ProcessorRunRepo) GetLatestRuns(ctx context.Context, uploadID string) ([]models.ProcessorRun, error) {
+	var runs []models.ProcessorRun
+	query := `
+		SELECT DISTINCT ON (processor_id) *
+		FROM processor_runs
+		WHERE upload_id = ?
+		ORDER BY processor_id, started_at DESC
+	`
+	if err := r.db.Orm.WithContext(ctx).Raw(query, uploadID).Scan(&runs).Error; err != nil {
+		return nil, dbutils.DBError(ctx, r.db.Orm.Logger, err)
+	}
+	return runs, nil
+}
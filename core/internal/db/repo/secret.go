@@ -29,7 +29,7 @@ func (r *SecretRepo) GetAllSecretsWithValues(ctx context.Context, workspaceID st
 
 func (r *SecretRepo) GetAllSecretsWithoutValues(ctx context.Context, workspaceID string) ([]models.Secret, error) {
 	var secrets []models.Secret
-	err := r.db.Orm.WithContext(ctx).Omit("value").Omit("salt").Find(&secrets, "workspace_id = ?", workspaceID).Error
+	err := r.db.Orm.WithContext(ctx).Omit("value", "salt").Find(&secrets, "workspace_id = ?", workspaceID).Error
 	if err != nil {
 		return nil, dbutils.DBError(ctx, r.db.Orm.Logger, err)
 	}
@@ -47,7 +47,7 @@ func (r *SecretRepo) GetSecretWithValue(ctx context.Context, workspaceID, key st
 
 func (r *SecretRepo) GetSecretWithoutValue(ctx context.Context, workspaceID, key string) (*models.Secret, error) {
 	var secret models.Secret
-	err := r.db.Orm.WithContext(ctx).Omit("value").Omit("salt").First(&secret, "workspace_id = ? AND key = ?", workspaceID, key).Error
+	err := r.db.Orm.WithContext(ctx).Omit("value", "salt").First(&secret, "workspace_id = ? AND key = ?", workspaceID, key).Error
 	if err != nil {
 		return nil, dbutils.DBError(ctx, r.db.Orm.Logger, err)
 	}
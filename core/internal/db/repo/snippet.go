@@ -0,0 +1,58 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/uploadpilot/core/internal/db/driver"
+	"github.com/uploadpilot/core/internal/db/models"
+	dbutils "github.com/uploadpilot/core/internal/db/utils"
+)
+
+type WorkflowSnippetRepo struct {
+	db *driver.Driver
+}
+
+func NewWorkflowSnippetRepo(db *driver.Driver) *WorkflowSnippetRepo {
+	return &WorkflowSnippetRepo{
+		db: db,
+	}
+}
+
+func (r *WorkflowSnippetRepo) GetAll(ctx context.Context, workspaceID string) ([]models.WorkflowSnippet, error) {
+	var snippets []models.WorkflowSnippet
+	err := r.db.Orm.WithContext(ctx).Omit("workflow").Order("name").Find(&snippets, "workspace_id = ?", workspaceID).Error
+	if err != nil {
+		return nil, dbutils.DBError(ctx, r.db.Orm.Logger, err)
+	}
+	return snippets, nil
+}
+
+func (r *WorkflowSnippetRepo) Get(ctx context.Context, workspaceID, name string) (*models.WorkflowSnippet, error) {
+	var snippet models.WorkflowSnippet
+	err := r.db.Orm.WithContext(ctx).First(&snippet, "workspace_id = ? AND name = ?", workspaceID, name).Error
+	if err != nil {
+		return nil, dbutils.DBError(ctx, r.db.Orm.Logger, err)
+	}
+	return &snippet, nil
+}
+
+func (r *WorkflowSnippetRepo) Create(ctx context.Context, snippet *models.WorkflowSnippet) error {
+	if err := r.db.Orm.WithContext(ctx).Create(snippet).Error; err != nil {
+		return dbutils.DBError(ctx, r.db.Orm.Logger, err)
+	}
+	return nil
+}
+
+func (r *WorkflowSnippetRepo) Update(ctx context.Context, snippet *models.WorkflowSnippet) error {
+	if err := r.db.Orm.WithContext(ctx).Save(snippet).Error; err != nil {
+		return dbutils.DBError(ctx, r.db.Orm.Logger, err)
+	}
+	return nil
+}
+
+func (r *WorkflowSnippetRepo) Delete(ctx context.Context, workspaceID, name string) error {
+	if err := r.db.Orm.WithContext(ctx).Delete(&models.WorkflowSnippet{}, "workspace_id = ? AND name = ?", workspaceID, name).Error; err != nil {
+		return dbutils.DBError(ctx, r.db.Orm.Logger, err)
+	}
+	return nil
+}
@@ -9,6 +9,13 @@ import (
 	"github.com/uploadpilot/core/internal/db/driver"
 	"github.com/uploadpilot/core/internal/db/models"
 	dbutils "github.com/uploadpilot/core/internal/db/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	uploadSearchFields = []string{"file_name", "status", "content_type"}
+	uploadFilterFields = []string{"status"}
 )
 
 type UploadRepo struct {
@@ -26,15 +33,15 @@ func (r *UploadRepo) GetAll(ctx context.Context, workspaceID string, paginationP
 
 	query := r.db.Orm.WithContext(ctx).
 		Model(&models.Upload{}).
-		Select("id", "file_name", "status", "content_type", "started_at", "content_length", "finished_at", "metadata").
+		Select("id", "file_name", "status", "content_type", "started_at", "content_length", "finished_at", "metadata", "parent_id", "processor_id", "run_id", "activity_key").
 		Where("workspace_id = ?", workspaceID)
 
 	query, totalRecords, sortApplied, err := dbutils.BuildPaginationQuery(
 		query,
 		&dbutils.PaginationQueryInput{
 			PaginationParams:    paginationParams,
-			AllowedSearchFields: []string{"file_name", "status", "content_type"},
-			AllowedFilterFields: []string{"status"},
+			AllowedSearchFields: uploadSearchFields,
+			AllowedFilterFields: uploadFilterFields,
 		},
 	)
 
@@ -53,6 +60,47 @@ func (r *UploadRepo) GetAll(ctx context.Context, workspaceID string, paginationP
 	return uploads, totalRecords, nil
 }
 
+// GetIDsAfter returns the IDs of the uploads matching the search and filter of
+// the pagination params in ID order, starting after the given ID, and the
+// number of matching uploads. Unlike offsets, paging by ID skips no uploads
+// when processing moves them out of the filter. Derived uploads are left out
+// unless includeDerived is set.
+func (r *UploadRepo) GetIDsAfter(ctx context.Context, workspaceID string, paginationParams *models.PaginationParams,
+	afterID string, limit int, includeDerived bool) ([]string, int64, error) {
+	params := &models.PaginationParams{
+		Search:              paginationParams.Search,
+		CaseSensitiveSearch: paginationParams.CaseSensitiveSearch,
+		Filter:              paginationParams.Filter,
+	}
+	query := r.db.Orm.WithContext(ctx).
+		Model(&models.Upload{}).
+		Where("workspace_id = ?", workspaceID)
+	if !includeDerived {
+		query = query.Where("parent_id IS NULL")
+	}
+
+	query, totalRecords, _, err := dbutils.BuildPaginationQuery(
+		query,
+		&dbutils.PaginationQueryInput{
+			PaginationParams:    params,
+			AllowedSearchFields: uploadSearchFields,
+			AllowedFilterFields: uploadFilterFields,
+		},
+	)
+	if err != nil {
+		return nil, 0, dbutils.DBError(ctx, r.db.Orm.Logger, err)
+	}
+
+	if afterID != "" {
+		query = query.Where("id > ?", afterID)
+	}
+	var ids []string
+	if err := query.Order("id").Limit(limit).Pluck("id", &ids).Error; err != nil {
+		return nil, 0, dbutils.DBError(ctx, r.db.Orm.Logger, err)
+	}
+	return ids, totalRecords, nil
+}
+
 func (r *UploadRepo) Get(ctx context.Context, uploadID string) (*models.Upload, error) {
 	var upload models.Upload
 	if err := r.db.Orm.WithContext(ctx).First(&upload, "id = ?", uploadID).Error; err != nil {
@@ -84,6 +132,43 @@ func (r *UploadRepo) Delete(ctx context.Context, uploadID string) error {
 	return nil
 }
 
+// CreateDerived saves the uploads derived from the outputs of a run. Uploads
+// that were already saved by a previous attempt are skipped.
+func (r *UploadRepo) CreateDerived(ctx context.Context, uploads []models.Upload) error {
+	if err := r.db.Orm.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&uploads).Error; err != nil {
+		return dbutils.DBError(ctx, r.db.Orm.Logger, err)
+	}
+	return nil
+}
+
+// GetDerivatives returns the uploads derived from any of the given uploads.
+func (r *UploadRepo) GetDerivatives(ctx context.Context, parentIDs []string) ([]models.Upload, error) {
+	var uploads []models.Upload
+	if err := r.db.Orm.WithContext(ctx).
+		Where("parent_id IN ?", parentIDs).
+		Order("started_at").
+		Find(&uploads).Error; err != nil {
+		return nil, dbutils.DBError(ctx, r.db.Orm.Logger, err)
+	}
+	return uploads, nil
+}
+
+// DeleteAll deletes the uploads. The uploads derived from them that are not
+// deleted too become source uploads.
+func (r *UploadRepo) DeleteAll(ctx context.Context, uploadIDs []string) error {
+	return r.db.Orm.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Upload{}).
+			Where("parent_id IN ? AND id NOT IN ?", uploadIDs, uploadIDs).
+			Update("parent_id", nil).Error; err != nil {
+			return dbutils.DBError(ctx, r.db.Orm.Logger, err)
+		}
+		if err := tx.Delete(&models.Upload{}, "id IN ?", uploadIDs).Error; err != nil {
+			return dbutils.DBError(ctx, r.db.Orm.Logger, err)
+		}
+		return nil
+	})
+}
+
 func (r *UploadRepo) SetStatus(ctx context.Context, uploadID string, status models.UploadStatus) error {
 	update := map[string]interface{}{
 		"status": status,
@@ -27,13 +27,17 @@ func BuildPaginationQuery(
 		if input.PaginationParams.CaseSensitiveSearch {
 			matchType = "LIKE"
 		}
+		// the search fields are grouped so that the OR does not escape the
+		// conditions the caller already added to the query
+		search := query.Session(&gorm.Session{NewDB: true})
 		for i, field := range input.AllowedSearchFields {
 			if i == 0 {
-				query = query.Where(fmt.Sprintf("%s %s ?", field, matchType), searchClause)
+				search = search.Where(fmt.Sprintf("%s %s ?", field, matchType), searchClause)
 			} else {
-				query = query.Or(fmt.Sprintf("%s %s ?", field, matchType), searchClause)
+				search = search.Or(fmt.Sprintf("%s %s ?", field, matchType), searchClause)
 			}
 		}
+		query = query.Where(search)
 
 	}
 
@@ -15,6 +15,13 @@ type ProcessorParams struct {
 	ProcessorID string `json:"processorId" validate:"required,uuid"`
 }
 
+type WorkflowVersionParams struct {
+	TenantID    string `json:"tenantId" validate:"required,uuid"`
+	WorkspaceID string `json:"workspaceId" validate:"required,uuid"`
+	ProcessorID string `json:"processorId" validate:"required,uuid"`
+	Version     string `json:"version" validate:"required,number"`
+}
+
 type RunParams struct {
 	TenantID    string `json:"tenantId" validate:"required,uuid"`
 	WorkspaceID string `json:"workspaceId" validate:"required,uuid"`
@@ -22,17 +29,50 @@ type RunParams struct {
 	RunID       string `json:"runId" validate:"required,uuid"`
 }
 
+type TestRunParams struct {
+	TenantID    string `json:"tenantId" validate:"required,uuid"`
+	WorkspaceID string `json:"workspaceId" validate:"required,uuid"`
+	ProcessorID string `json:"processorId" validate:"required,uuid"`
+	WorkflowID  string `json:"workflowId" validate:"required,max=255"`
+}
+
+type ApprovalParams struct {
+	TenantID    string `json:"tenantId" validate:"required,uuid"`
+	WorkspaceID string `json:"workspaceId" validate:"required,uuid"`
+	ProcessorID string `json:"processorId" validate:"required,uuid"`
+	RunID       string `json:"runId" validate:"required,uuid"`
+	ApprovalKey string `json:"approvalKey" validate:"required,max=255"`
+}
+
 type UploadParams struct {
 	TenantID    string `json:"tenantId" validate:"required,uuid"`
 	WorkspaceID string `json:"workspaceId" validate:"required,uuid"`
 	UploadID    string `json:"uploadId" validate:"required,uuid"`
 }
 
+type ReprocessJobParams struct {
+	TenantID    string `json:"tenantId" validate:"required,uuid"`
+	WorkspaceID string `json:"workspaceId" validate:"required,uuid"`
+	JobID       string `json:"jobId" validate:"required,max=255"`
+}
+
 type ApiKeyParams struct {
 	TenantID string `json:"tenantId" validate:"required,uuid"`
 	ApiKeyID string `json:"apiKeyId" validate:"required,uuid"`
 }
 
+type SecretParams struct {
+	TenantID    string `json:"tenantId" validate:"required,uuid"`
+	WorkspaceID string `json:"workspaceId" validate:"required,uuid"`
+	SecretKey   string `json:"secretKey" validate:"required,max=255"`
+}
+
+type SnippetParams struct {
+	TenantID    string `json:"tenantId" validate:"required,uuid"`
+	WorkspaceID string `json:"workspaceId" validate:"required,uuid"`
+	SnippetName string `json:"snippetName" validate:"required,max=255"`
+}
+
 type PaginatedQuery struct {
 	Offset              string `json:"offset" validate:"omitempty,integer"`
 	Limit               string `json:"limit" validate:"omitempty,integer"`
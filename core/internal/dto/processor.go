@@ -4,18 +4,55 @@ import (
 	"time"
 
 	"github.com/uploadpilot/core/internal/db/dtypes"
+	"github.com/uploadpilot/core/internal/db/models"
+	"github.com/uploadpilot/core/internal/workflow/dsl"
 )
 
 type CreateProcessorRequest struct {
-	Name        string             `json:"name" validate:"required,min=3,max=25,alphanumspace"`
-	WorkspaceID string             `json:"workspaceId" validate:"required,uuid"`
-	Triggers    dtypes.StringArray `json:"triggers" validate:"required,max=500"`
-	TemplateKey string             `json:"templateKey"`
+	Name        string              `json:"name" validate:"required,min=3,max=25,alphanumspace"`
+	WorkspaceID string              `json:"workspaceId" validate:"required,uuid"`
+	Triggers    dtypes.StringArray  `json:"triggers" validate:"required,max=500"`
+	TriggerRule *models.TriggerRule `json:"triggerRule,omitempty"`
+	TemplateKey string              `json:"templateKey"`
 }
 
 type EditProcRequest struct {
-	Name     string             `json:"name" validate:"required,min=3,max=25,alphanumspace"`
-	Triggers dtypes.StringArray `json:"triggers" validate:"required,max=500"`
+	Name        string              `json:"name" validate:"required,min=3,max=25,alphanumspace"`
+	Triggers    dtypes.StringArray  `json:"triggers" validate:"required,max=500"`
+	TriggerRule *models.TriggerRule `json:"triggerRule,omitempty"`
+}
+
+type ProcessorSettings struct {
+	MaxRetries                int32   `json:"maxRetries" validate:"min=0,max=100"`
+	RetryInitialIntervalS     uint64  `json:"retryInitialIntervalS" validate:"required,min=1"`
+	RetryBackoffCoefficient   float64 `json:"retryBackoffCoefficient" validate:"required,min=1"`
+	RetryMaxIntervalS         uint64  `json:"retryMaxIntervalS" validate:"required,min=1"`
+	WorkflowExecutionTimeoutS uint64  `json:"workflowExecutionTimeoutS" validate:"required,min=1"`
+	WorkflowRunTimeoutS       uint64  `json:"workflowRunTimeoutS" validate:"required,min=1"`
+	TaskRunTimeoutS           uint64  `json:"taskRunTimeoutS" validate:"required,min=1"`
+	// ArtifactMode defaults to zip, the destination prefix is required by the copy mode.
+	ArtifactMode              models.ArtifactMode `json:"artifactMode" validate:"omitempty,oneof=zip individual copy skip"`
+	ArtifactDestinationPrefix string              `json:"artifactDestinationPrefix" validate:"max=512"`
+	// DeriveUploads saves a copy of every finalized output as an upload derived
+	// from the upload of the run, the outputs are stored twice.
+	DeriveUploads bool `json:"deriveUploads"`
+}
+
+// ExplainTriggersRequest describes a hypothetical upload to match against the
+// trigger rules of the processors.
+type ExplainTriggersRequest struct {
+	FileName      string                 `json:"fileName" validate:"required"`
+	ContentType   string                 `json:"contentType" validate:"required"`
+	ContentLength int64                  `json:"contentLength" validate:"min=0"`
+	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+}
+
+type TriggerExplanation struct {
+	ProcessorID string               `json:"processorId"`
+	Name        string               `json:"name"`
+	Enabled     bool                 `json:"enabled"`
+	WillTrigger bool                 `json:"willTrigger"`
+	Result      models.TriggerResult `json:"result"`
 }
 
 type EnableDisableProcessorRequest struct {
@@ -26,24 +63,20 @@ type WorkflowUpdate struct {
 	Workflow string `json:"workflow"`
 }
 
+type ApprovalDecisionRequest struct {
+	Action  string `json:"action" validate:"required,oneof=approve reject"`
+	Comment string `json:"comment" validate:"max=1000"`
+}
+
+type RerunProcessorRequest struct {
+	UploadID string `json:"uploadId" validate:"required,uuid"`
+}
+
 type TriggerWorkflowResp struct {
 	WorkflowID string `json:"workflowId"`
 	RunID      string `json:"runId"`
 }
 
-type WorkflowRun struct {
-	ID                 string    `json:"id"`
-	WorkspaceID        string    `json:"workspaceId"`
-	UploadID           string    `json:"uploadId"`
-	WorkflowID         string    `json:"workflowId"`
-	RunID              string    `json:"runId"`
-	StartTime          time.Time `json:"startTime,omitempty"`
-	EndTime            time.Time `json:"endTime,omitempty"`
-	WorkflowTimeMillis int64     `json:"workflowTimeMillis"`
-	ExecutionTimeMilis int64     `json:"executionTimeMillis"`
-	Status             string    `json:"status,omitempty"`
-}
-
 type WorkflowRunLogs struct {
 	Timestamp time.Time `json:"timestamp"`
 	EventType string    `json:"eventType"`
@@ -63,3 +96,65 @@ type WorkflowQuery struct {
 type UploadQuery struct {
 	UploadID string `json:"uploadId"`
 }
+
+// ArtifactQuery selects an artifact of a run by the name it is listed with.
+// Disposition decides whether the URL downloads the artifact or previews it
+// in the browser, it downloads by default.
+type ArtifactQuery struct {
+	WorkflowID  string `json:"workflowId"`
+	Name        string `json:"name" validate:"required,max=1024"`
+	Disposition string `json:"disposition" validate:"omitempty,oneof=inline attachment"`
+}
+
+// PromoteArtifactRequest promotes an artifact of a run into an upload derived
+// from the upload of the run. The name of the artifact is used as the file
+// name unless one is given.
+type PromoteArtifactRequest struct {
+	Name     string                 `json:"name" validate:"required,max=1024"`
+	FileName string                 `json:"fileName" validate:"max=255"`
+	Metadata map[string]interface{} `json:"metadata"`
+}
+
+type ArtifactURL struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type WorkflowDiffQuery struct {
+	From string `json:"from" validate:"required,number"`
+	To   string `json:"to" validate:"required,number"`
+}
+
+type WorkflowDiff struct {
+	From int    `json:"from"`
+	To   int    `json:"to"`
+	Diff string `json:"diff"`
+}
+
+type RollbackWorkflowResp struct {
+	Version int `json:"version"`
+}
+
+// TestProcessorRequest selects the upload a test run processes. The saved
+// workflow of the processor runs unless a workflow is given.
+type TestProcessorRequest struct {
+	UploadID string `json:"uploadId" validate:"required,uuid"`
+	Workflow string `json:"workflow"`
+}
+
+type TestRunResult struct {
+	WorkflowID string `json:"workflowId"`
+	RunID      string `json:"runId"`
+	dsl.TestRunResult
+}
+
+type CreateSnippetRequest struct {
+	Name        string `json:"name" validate:"required,max=255"`
+	Description string `json:"description" validate:"max=1000"`
+	Workflow    string `json:"workflow" validate:"required"`
+}
+
+type UpdateSnippetRequest struct {
+	Description string `json:"description" validate:"max=1000"`
+	Workflow    string `json:"workflow" validate:"required"`
+}
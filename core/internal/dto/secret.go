@@ -0,0 +1,10 @@
+package dto
+
+type CreateSecretData struct {
+	Key   string `json:"key" validate:"required,max=255"`
+	Value string `json:"value" validate:"required,max=4096"`
+}
+
+type UpdateSecretData struct {
+	Value string `json:"value" validate:"required,max=4096"`
+}
@@ -1,6 +1,9 @@
 package dto
 
-import "github.com/uploadpilot/core/internal/db/models"
+import (
+	"github.com/uploadpilot/core/internal/db/models"
+	"github.com/uploadpilot/core/internal/workflow"
+)
 
 type CreateUploadRequest struct {
 	FileName              string                 `json:"fileName" validate:"required"`
@@ -20,3 +23,36 @@ type CreateUploadResponse struct {
 type FinishUploadRequest struct {
 	Status models.UploadStatus `json:"status" validate:"required"`
 }
+
+// ReprocessUploadsRequest selects the uploads to reprocess with the search and
+// filter syntax of the uploads list. Derived uploads are only selected with
+// IncludeDerived.
+type ReprocessUploadsRequest struct {
+	Search           string   `json:"search,omitempty" validate:"omitempty,max=100"`
+	Filter           string   `json:"filter,omitempty" validate:"omitempty,keyvaluepairs,max=300"`
+	ProcessorIDs     []string `json:"processorIds,omitempty" validate:"omitempty,max=50,dive,uuid"`
+	OnlyFailed       bool     `json:"onlyFailed"`
+	IncludeDerived   bool     `json:"includeDerived"`
+	MaxConcurrency   int      `json:"maxConcurrency" validate:"min=0,max=50"`
+	UploadsPerMinute int      `json:"uploadsPerMinute" validate:"min=0,max=10000"`
+}
+
+// DeleteUploadQuery decides what happens to the uploads derived from the
+// deleted upload. They are deleted with it when cascade is set, and become
+// source uploads when detach is set.
+type DeleteUploadQuery struct {
+	Cascade string `json:"cascade" validate:"omitempty,boolean"`
+	Detach  string `json:"detach" validate:"omitempty,boolean"`
+}
+
+// UploadLineage is an upload with the uploads derived from it.
+type UploadLineage struct {
+	models.Upload
+	Derivatives []*UploadLineage `json:"derivatives"`
+}
+
+type ReprocessJob struct {
+	JobID    string                      `json:"jobId"`
+	Status   string                      `json:"status"`
+	Progress *workflow.ReprocessProgress `json:"progress,omitempty"`
+}
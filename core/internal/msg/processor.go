@@ -0,0 +1,15 @@
+package msg
+
+// Processor errors
+const (
+	ErrTestRunUploadRequired   = "an upload id is required to test a processor"
+	ErrTestUploadNotFound      = "upload %s not found in the workspace"
+	ErrRunNotFound             = "run %s not found"
+	ErrReprocessJobNotFound    = "reprocess job %s not found"
+	ErrCalledProcessorNotFound = "called processor %s not found in the workspace"
+	ErrSnippetNotFound         = "snippet %s not found"
+	ErrSnippetAlreadyExists    = "snippet %s already exists in the workspace"
+	ErrApprovalNotPending      = "the run is not waiting on approval %s"
+	ErrArtifactNotFound        = "artifact %s not found in the run"
+	ErrArtifactInArchive       = "artifact %s is only available in the archive of the run"
+)
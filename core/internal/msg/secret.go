@@ -0,0 +1,8 @@
+package msg
+
+// Secret errors
+const (
+	ErrSecretAlreadyExists    = "secret %s already exists in the workspace"
+	ErrSecretNotFound         = "secret %s not found"
+	ErrSecretEncryptionFailed = "failed to encrypt secret. please try again later"
+)
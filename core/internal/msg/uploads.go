@@ -8,4 +8,5 @@ const (
 	ErrUploadURLValidityExceedsAllowedLimit = "requested upload url validity exceeds allowed limit. validity: %d, limit: %d"
 	ErrUploadNotFinished                    = "upload not finished"
 	ErrUploadAlreadyIsTerminalState         = "upload already is a terminal state"
+	ErrUploadHasDerivatives                 = "upload has %d derived uploads. delete them with it or detach them"
 )
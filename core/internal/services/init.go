@@ -12,13 +12,17 @@ type Services struct {
 	UploadService    *UploadService
 	ProcessorService *ProcessorService
 	APIKeyService    *APIKeyService
+	SecretService    *SecretService
+	SnippetService   *SnippetService
 }
 
 func NewServices(repos *repo.Repositories, clients *clients.Clients, accessManager *rbac.AccessManager) *Services {
 	tenantSvc := NewTenantService(accessManager, repos.TenantRepo)
 	workspaceSvc := NewWorkspaceService(accessManager, repos.WorkspaceRepo, repos.WorkspaceConfigRepo, clients.S3Client)
 	apiKeySvc := NewAPIKeyService(accessManager, repos.APIKeyRepo, clients.KMSClient)
-	processorSvc := NewProcessorService(accessManager, repos.ProcessorRepo, clients.TemporalClient, clients.S3Client)
+	secretSvc := NewSecretService(accessManager, repos.SecretsRepo, clients.KMSClient)
+	processorSvc := NewProcessorService(accessManager, repos.ProcessorRepo, repos.ProcessorRunRepo, repos.UploadRepo, repos.SnippetRepo, clients.TemporalClient, clients.S3Client)
+	snippetSvc := NewSnippetService(accessManager, repos.SnippetRepo, processorSvc)
 	uploadSvc := NewUploadService(accessManager, repos.UploadRepo, workspaceSvc, processorSvc, clients.S3Client)
 
 	return &Services{
@@ -27,5 +31,7 @@ func NewServices(repos *repo.Repositories, clients *clients.Clients, accessManag
 		UploadService:    uploadSvc,
 		ProcessorService: processorSvc,
 		APIKeyService:    apiKeySvc,
+		SecretService:    secretSvc,
+		SnippetService:   snippetSvc,
 	}
 }
@@ -2,45 +2,82 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"mime"
 	"os"
+	"path"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/google/uuid"
 	"github.com/phuslu/log"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/uploadpilot/core/config"
+	"github.com/uploadpilot/core/internal/db/errs"
 	"github.com/uploadpilot/core/internal/db/models"
 	"github.com/uploadpilot/core/internal/db/repo"
 	"github.com/uploadpilot/core/internal/dto"
 	"github.com/uploadpilot/core/internal/msg"
 	"github.com/uploadpilot/core/internal/rbac"
 	"github.com/uploadpilot/core/internal/templates"
+	"github.com/uploadpilot/core/internal/workflow"
+	"github.com/uploadpilot/core/internal/workflow/activities"
 	"github.com/uploadpilot/core/internal/workflow/catalog"
 	"github.com/uploadpilot/core/internal/workflow/dsl"
+	"github.com/uploadpilot/core/pkg/utils"
 	"github.com/uploadpilot/core/pkg/validator"
 	"github.com/uploadpilot/core/web/webutils"
 	"go.temporal.io/api/enums/v1"
-	"go.temporal.io/api/workflowservice/v1"
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/temporal"
 	"gopkg.in/yaml.v3"
 )
 
+const reprocessJobPrefix = "reprocess-"
+
+// testRunWaitTimeout bounds how long a test request waits for its run.
+const testRunWaitTimeout = 30 * time.Second
+
 type ProcessorService struct {
 	accessManager  *rbac.AccessManager
 	procRepo       *repo.ProcessorRepo
+	runRepo        *repo.ProcessorRunRepo
+	uploadRepo     *repo.UploadRepo
+	snippetRepo    *repo.WorkflowSnippetRepo
 	validator      *validator.Validator
 	temporalClient client.Client
 	s3Client       *s3.Client
+	taskQueue      string
+	idReusePolicy  enums.WorkflowIdReusePolicy
 }
 
-func NewProcessorService(accessManager *rbac.AccessManager, procRepo *repo.ProcessorRepo, temporalClient client.Client, s3Client *s3.Client) *ProcessorService {
+func NewProcessorService(accessManager *rbac.AccessManager, procRepo *repo.ProcessorRepo, runRepo *repo.ProcessorRunRepo,
+	uploadRepo *repo.UploadRepo, snippetRepo *repo.WorkflowSnippetRepo, temporalClient client.Client, s3Client *s3.Client) *ProcessorService {
+	idReusePolicy, err := workflow.ParseIDReusePolicy(config.AppConfig.WorkflowIDReusePolicy)
+	if err != nil {
+		log.Warn().Err(err).Msg("falling back to the reject_duplicate workflow id reuse policy")
+		idReusePolicy = enums.WORKFLOW_ID_REUSE_POLICY_REJECT_DUPLICATE
+	}
+	if idReusePolicy != enums.WORKFLOW_ID_REUSE_POLICY_REJECT_DUPLICATE {
+		log.Warn().Str("policy", idReusePolicy.String()).
+			Msg("workflow id reuse policy allows duplicates, retried triggers can start closed runs again")
+	}
+
 	return &ProcessorService{
 		accessManager:  accessManager,
 		procRepo:       procRepo,
+		runRepo:        runRepo,
+		uploadRepo:     uploadRepo,
+		snippetRepo:    snippetRepo,
 		validator:      validator.NewValidator(),
 		temporalClient: temporalClient,
 		s3Client:       s3Client,
+		taskQueue:      config.AppConfig.WorkerTaskQueue,
+		idReusePolicy:  idReusePolicy,
 	}
 }
 
@@ -80,6 +117,12 @@ func (s *ProcessorService) CreateProcessor(ctx context.Context, workspaceID stri
 	}
 	wfData := string(sampleWflow)
 
+	if processor.TriggerRule != nil {
+		if err := processor.TriggerRule.Validate(); err != nil {
+			return err
+		}
+	}
+
 	processor.CreatedBy = user.UserID
 	processor.UpdatedBy = user.UserID
 	processor.WorkspaceID = workspaceID
@@ -89,8 +132,106 @@ func (s *ProcessorService) CreateProcessor(ctx context.Context, workspaceID stri
 }
 
 func (s *ProcessorService) UpdateWorkflow(ctx context.Context, workspaceID, processorID string, workflow string) error {
-	// TODO: Validate workflow
-	//TODO: Validate tasks
+	user, err := webutils.GetSessionFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+
+	processor, err := s.procRepo.Get(ctx, processorID)
+	if err != nil {
+		return err
+	}
+	if err := s.validateWorkflow(ctx, processor, workflow); err != nil {
+		return err
+	}
+
+	_, err = s.procRepo.SaveWorkflow(ctx, workspaceID, processorID, workflow, user.UserID, 0)
+	return err
+}
+
+// GetWorkflowVersions lists the saved workflow versions of a processor, newest
+// first.
+func (s *ProcessorService) GetWorkflowVersions(ctx context.Context, tenantID, workspaceID, processorID string) ([]models.ProcessorWorkflowVersion, error) {
+	if err := s.checkAccess(ctx, tenantID, workspaceID, rbac.Reader); err != nil {
+		return nil, err
+	}
+	return s.procRepo.GetWorkflowVersions(ctx, workspaceID, processorID)
+}
+
+func (s *ProcessorService) GetWorkflowVersion(ctx context.Context, tenantID, workspaceID, processorID string,
+	version int) (*models.ProcessorWorkflowVersion, error) {
+	if err := s.checkAccess(ctx, tenantID, workspaceID, rbac.Reader); err != nil {
+		return nil, err
+	}
+	return s.procRepo.GetWorkflowVersion(ctx, workspaceID, processorID, version)
+}
+
+// DiffWorkflowVersions returns the unified diff between two workflow versions.
+func (s *ProcessorService) DiffWorkflowVersions(ctx context.Context, tenantID, workspaceID, processorID string,
+	from, to int) (*dto.WorkflowDiff, error) {
+	if err := s.checkAccess(ctx, tenantID, workspaceID, rbac.Reader); err != nil {
+		return nil, err
+	}
+	fromVersion, err := s.procRepo.GetWorkflowVersion(ctx, workspaceID, processorID, from)
+	if err != nil {
+		return nil, err
+	}
+	toVersion, err := s.procRepo.GetWorkflowVersion(ctx, workspaceID, processorID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(fromVersion.Workflow),
+		B:        difflib.SplitLines(toVersion.Workflow),
+		FromFile: fmt.Sprintf("v%d", from),
+		ToFile:   fmt.Sprintf("v%d", to),
+		Context:  3,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &dto.WorkflowDiff{From: from, To: to, Diff: diff}, nil
+}
+
+// RollbackWorkflow saves the workflow of an earlier version as the next
+// version of the processor, and returns the new version.
+func (s *ProcessorService) RollbackWorkflow(ctx context.Context, tenantID, workspaceID, processorID string, version int) (int, error) {
+	if err := s.checkAccess(ctx, tenantID, workspaceID, rbac.Admin); err != nil {
+		return 0, err
+	}
+	session, err := webutils.GetSessionFromCtx(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	processor, err := s.procRepo.Get(ctx, processorID)
+	if err != nil {
+		return 0, err
+	}
+	old, err := s.procRepo.GetWorkflowVersion(ctx, workspaceID, processorID, version)
+	if err != nil {
+		return 0, err
+	}
+	// the activities or settings may have changed since the version was saved
+	if err := s.validateWorkflow(ctx, processor, old.Workflow); err != nil {
+		return 0, err
+	}
+	return s.procRepo.SaveWorkflow(ctx, workspaceID, processorID, old.Workflow, session.UserID, version)
+}
+
+func (s *ProcessorService) validateWorkflow(ctx context.Context, processor *models.Processor, workflow string) error {
+	return s.checkWorkflow(ctx, processor.WorkspaceID, dsl.CallTarget{Processor: processor.ID}, workflow,
+		executionSettings(processor))
+}
+
+// ValidateSnippet checks the workflow of a snippet and the workflows it calls.
+func (s *ProcessorService) ValidateSnippet(ctx context.Context, workspaceID, name, workflow string) error {
+	return s.checkWorkflow(ctx, workspaceID, dsl.CallTarget{Snippet: name}, workflow, nil)
+}
+
+func (s *ProcessorService) checkWorkflow(ctx context.Context, workspaceID string, self dsl.CallTarget, workflow string,
+	settings *dsl.ExecutionSettings) error {
 	var json map[string]interface{}
 	if err := yaml.Unmarshal([]byte(workflow), &json); err != nil {
 		log.Error().Msgf("failed to unmarshal workflow: %s", err.Error())
@@ -101,7 +242,58 @@ func (s *ProcessorService) UpdateWorkflow(ctx context.Context, workspaceID, proc
 		return err
 	}
 
-	return s.procRepo.SaveWorkflow(ctx, workspaceID, processorID, workflow)
+	var wf dsl.Workflow
+	if err := yaml.Unmarshal([]byte(workflow), &wf); err != nil {
+		return err
+	}
+	wf.Settings = settings
+	if err := wf.Validate(); err != nil {
+		return err
+	}
+	return wf.ResolveCalls(self, s.callLoader(ctx, workspaceID))
+}
+
+// callLoader loads the workflows called by a workflow of the workspace.
+func (s *ProcessorService) callLoader(ctx context.Context, workspaceID string) dsl.WorkflowLoader {
+	return func(target dsl.CallTarget) (*dsl.Workflow, error) {
+		var source string
+		if target.Processor != "" {
+			processor, err := s.procRepo.Get(ctx, target.Processor)
+			if errors.Is(err, errs.ErrRecordNotFound) || (err == nil && processor.WorkspaceID != workspaceID) {
+				return nil, fmt.Errorf(msg.ErrCalledProcessorNotFound, target.Processor)
+			}
+			if err != nil {
+				return nil, err
+			}
+			source = processor.Workflow
+		} else {
+			snippet, err := s.snippetRepo.Get(ctx, workspaceID, target.Snippet)
+			if errors.Is(err, errs.ErrRecordNotFound) {
+				return nil, fmt.Errorf(msg.ErrSnippetNotFound, target.Snippet)
+			}
+			if err != nil {
+				return nil, err
+			}
+			source = snippet.Workflow
+		}
+
+		var wf dsl.Workflow
+		if err := yaml.Unmarshal([]byte(source), &wf); err != nil {
+			return nil, err
+		}
+		return &wf, nil
+	}
+}
+
+func (s *ProcessorService) checkAccess(ctx context.Context, tenantID, workspaceID string, role rbac.AppRole) error {
+	session, err := webutils.GetSessionFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+	if !s.accessManager.CheckAccess(session.Sub, tenantID, workspaceID, role) {
+		return fmt.Errorf(msg.ErrAccessDenied)
+	}
+	return nil
 }
 
 func (s *ProcessorService) DeleteProcessor(ctx context.Context, workspaceID, processorID string) error {
@@ -123,11 +315,76 @@ func (s *ProcessorService) EditNameAndTrigger(ctx context.Context, workspaceID,
 	if err != nil {
 		return err
 	}
-	patch := map[string]interface{}{"name": update.Name, "triggers": update.Triggers}
+	if update.TriggerRule != nil {
+		if err := update.TriggerRule.Validate(); err != nil {
+			return err
+		}
+	}
+	patch := map[string]interface{}{"name": update.Name, "triggers": update.Triggers, "trigger_rule": update.TriggerRule}
 	patch["updated_by"] = user.UserID
 	return s.procRepo.Patch(ctx, workspaceID, processorID, patch)
 }
 
+// UpdateSettings saves the retry, timeout and artifact settings of a processor. The
+// settings are rejected if no run could succeed with them, also when the
+// activities of the current workflow conflict with them.
+func (s *ProcessorService) UpdateSettings(ctx context.Context, tenantID, workspaceID, processorID string, update *dto.ProcessorSettings) error {
+	session, err := webutils.GetSessionFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+	if !s.accessManager.CheckAccess(session.Sub, tenantID, workspaceID, rbac.Admin) {
+		return fmt.Errorf(msg.ErrAccessDenied)
+	}
+
+	processor, err := s.procRepo.Get(ctx, processorID)
+	if err != nil {
+		return err
+	}
+	processor.MaxRetries = update.MaxRetries
+	processor.RetryInitialIntervalS = update.RetryInitialIntervalS
+	processor.RetryBackoffCoefficient = update.RetryBackoffCoefficient
+	processor.RetryMaxIntervalS = update.RetryMaxIntervalS
+	processor.WorkflowExecutionTimeoutS = update.WorkflowExecutionTimeoutS
+	processor.WorkflowRunTimeoutS = update.WorkflowRunTimeoutS
+	processor.TaskRunTimeoutS = update.TaskRunTimeoutS
+	processor.ArtifactMode = update.ArtifactMode
+	if processor.ArtifactMode == "" {
+		processor.ArtifactMode = models.ArtifactModeZip
+	}
+	processor.ArtifactDestinationPrefix = strings.Trim(update.ArtifactDestinationPrefix, "/")
+	processor.DeriveUploads = update.DeriveUploads
+
+	settings := executionSettings(processor)
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	if processor.Workflow != "" {
+		var wf dsl.Workflow
+		if err := yaml.Unmarshal([]byte(processor.Workflow), &wf); err == nil {
+			wf.Settings = settings
+			if err := wf.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+
+	patch := map[string]interface{}{
+		"max_retries":                  update.MaxRetries,
+		"retry_initial_interval_s":     update.RetryInitialIntervalS,
+		"retry_backoff_coefficient":    update.RetryBackoffCoefficient,
+		"retry_max_interval_s":         update.RetryMaxIntervalS,
+		"workflow_execution_timeout_s": update.WorkflowExecutionTimeoutS,
+		"workflow_run_timeout_s":       update.WorkflowRunTimeoutS,
+		"task_run_timeout_s":           update.TaskRunTimeoutS,
+		"artifact_mode":                processor.ArtifactMode,
+		"artifact_destination_prefix":  processor.ArtifactDestinationPrefix,
+		"derive_uploads":               processor.DeriveUploads,
+		"updated_by":                   session.UserID,
+	}
+	return s.procRepo.Patch(ctx, workspaceID, processorID, patch)
+}
+
 func (s *ProcessorService) GetAllActivities(ctx context.Context) []catalog.ActivityMetadata {
 	var tsks []catalog.ActivityMetadata
 	for _, task := range catalog.ActivityCatalog {
@@ -136,28 +393,59 @@ func (s *ProcessorService) GetAllActivities(ctx context.Context) []catalog.Activ
 	return tsks
 }
 
-func (s *ProcessorService) TriggerWorkflows(ctx context.Context, workspaceID string, upload *models.Upload) error {
+// ExplainTriggers evaluates the triggers of every processor in the workspace
+// against a hypothetical upload, without starting anything.
+func (s *ProcessorService) ExplainTriggers(ctx context.Context, tenantID, workspaceID string,
+	req *dto.ExplainTriggersRequest) ([]dto.TriggerExplanation, error) {
+	if err := s.checkAccess(ctx, tenantID, workspaceID, rbac.Reader); err != nil {
+		return nil, err
+	}
+
+	processors, err := s.procRepo.GetAll(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	upload := &models.Upload{
+		WorkspaceID:   workspaceID,
+		FileName:      req.FileName,
+		ContentType:   req.ContentType,
+		ContentLength: req.ContentLength,
+		Metadata:      req.Metadata,
+	}
+	explanations := make([]dto.TriggerExplanation, 0, len(processors))
+	for _, processor := range processors {
+		result := processor.EvaluateTrigger(upload)
+		explanations = append(explanations, dto.TriggerExplanation{
+			ProcessorID: processor.ID,
+			Name:        processor.Name,
+			Enabled:     processor.Enabled,
+			WillTrigger: processor.Enabled && result.Matched,
+			Result:      result,
+		})
+	}
+	return explanations, nil
+}
+
+// TriggerWorkflows starts the enabled processors matching the upload. The first
+// run of an upload is attempt 1, so retried triggers resolve to the runs that
+// were already started. A rerun starts the next attempt instead.
+func (s *ProcessorService) TriggerWorkflows(ctx context.Context, workspaceID string, upload *models.Upload, rerun bool) error {
 	processors, err := s.GetAllProcessorsInWorkspace(ctx, workspaceID)
 	if err != nil {
 		return err
 	}
 	for _, processor := range processors {
 		if processor.Enabled {
-			var doTrigger bool = false
-			if len(processor.Triggers) != 0 {
-				for _, trigger := range processor.Triggers {
-					if trigger == upload.ContentType {
-						doTrigger = true
-						break
-					}
-				}
-			} else {
-				doTrigger = false
-			}
-			if !doTrigger {
+			if !processor.EvaluateTrigger(upload).Matched {
 				continue
 			}
-			_, err := s.TriggerWorkflow(ctx, upload, processor.Workflow, workspaceID, processor.ID)
+			attempt := 1
+			if rerun {
+				if attempt, err = s.runRepo.NextAttempt(ctx, processor.ID, upload.ID); err != nil {
+					return err
+				}
+			}
+			_, err := s.TriggerWorkflow(ctx, workspaceID, upload, &processor, attempt)
 			if err != nil {
 				return err
 			}
@@ -166,15 +454,32 @@ func (s *ProcessorService) TriggerWorkflows(ctx context.Context, workspaceID str
 	return nil
 }
 
-func (s *ProcessorService) TriggerWorkflow(ctx context.Context, upload *models.Upload, yamlContent, workspaceID, processorID string) (*dto.TriggerWorkflowResp, error) {
+// TriggerWorkflow starts the processor workflow for an upload. Starting the same
+// attempt twice is idempotent, the run that already exists is returned. This
+// only holds for closed runs with the reject_duplicate reuse policy, see
+// workflow.ParseIDReusePolicy.
+func (s *ProcessorService) TriggerWorkflow(ctx context.Context, workspaceID string, upload *models.Upload,
+	processor *models.Processor, attempt int) (*dto.TriggerWorkflowResp, error) {
 	var dslWorkflow dsl.Workflow
-	if err := yaml.Unmarshal([]byte(yamlContent), &dslWorkflow); err != nil {
+	if err := yaml.Unmarshal([]byte(processor.Workflow), &dslWorkflow); err != nil {
 		return nil, err
 	}
+	// the called workflows are part of the input, a run is not affected by
+	// later changes to them
+	if err := dslWorkflow.ResolveCalls(dsl.CallTarget{Processor: processor.ID}, s.callLoader(ctx, workspaceID)); err != nil {
+		return nil, err
+	}
+
+	processorID := processor.ID
 
+	settings := executionSettings(processor)
+	workflowID := workflow.RunWorkflowID(processorID, upload.ID, attempt)
 	workflowOptions := client.StartWorkflowOptions{
-		ID:        processorID,
-		TaskQueue: "queue1",
+		ID:                       workflowID,
+		TaskQueue:                s.taskQueue,
+		WorkflowIDReusePolicy:    s.idReusePolicy,
+		WorkflowExecutionTimeout: time.Duration(settings.WorkflowExecutionTimeoutSeconds) * time.Second,
+		WorkflowRunTimeout:       time.Duration(settings.WorkflowRunTimeoutSeconds) * time.Second,
 		TypedSearchAttributes: temporal.NewSearchAttributes(
 			temporal.NewSearchAttributeKeyKeyword("processorId").ValueSet(processorID),
 		),
@@ -186,6 +491,7 @@ func (s *ProcessorService) TriggerWorkflow(ctx context.Context, upload *models.U
 			"workspaceId": workspaceID,
 			"fileType":    upload.ContentType,
 			"fileName":    upload.FileName,
+			"attempt":     attempt,
 		},
 	}
 
@@ -194,9 +500,22 @@ func (s *ProcessorService) TriggerWorkflow(ctx context.Context, upload *models.U
 	dslWorkflow.ProcessorID = processorID
 	dslWorkflow.FileName = upload.FileName
 	dslWorkflow.ContentType = upload.ContentType
+	dslWorkflow.Attempt = attempt
+	dslWorkflow.WorkflowVersion = processor.WorkflowVersion
+	dslWorkflow.Settings = settings
 
-	fmt.Println("workflowOptions", workflowOptions)
+	// a running workflow with the same ID is returned instead of an error
 	we, err := s.temporalClient.ExecuteWorkflow(context.Background(), workflowOptions, dsl.SimpleDSLWorkflow, dslWorkflow)
+	if temporal.IsWorkflowExecutionAlreadyStartedError(err) {
+		// the run is closed and the reuse policy does not allow to start it again
+		desc, derr := s.temporalClient.DescribeWorkflowExecution(ctx, workflowID, "")
+		if derr != nil {
+			log.Error().Err(derr).Str("workflow_id", workflowID).Msg("failed to describe existing workflow")
+			return nil, err
+		}
+		log.Info().Str("workflow_id", workflowID).Msg("workflow was already started, skipping")
+		return &dto.TriggerWorkflowResp{WorkflowID: workflowID, RunID: desc.WorkflowExecutionInfo.Execution.RunId}, nil
+	}
 	if err != nil {
 		log.Error().Err(err).Msg("failed to start workflow")
 		return nil, err
@@ -204,54 +523,362 @@ func (s *ProcessorService) TriggerWorkflow(ctx context.Context, upload *models.U
 	return &dto.TriggerWorkflowResp{WorkflowID: we.GetID(), RunID: we.GetRunID()}, nil
 }
 
-func (s *ProcessorService) GetWorkflowRuns(ctx context.Context, tenantID, workspaceID, processorID string) ([]dto.WorkflowRun, error) {
-	session, err := webutils.GetSessionFromCtx(ctx)
+// TestProcessor runs the workflow of a processor, or an unsaved workflow, as a
+// test run and waits for its result. Test runs write to their own prefix and
+// leave the runs, the upload status and the artifacts untouched. A run that is
+// still running after testRunWaitTimeout, e.g. because it waits on an approval
+// or a timer, is returned as running and its result is polled with GetTestRun.
+func (s *ProcessorService) TestProcessor(ctx context.Context, tenantID, workspaceID, processorID string,
+	req *dto.TestProcessorRequest) (*dto.TestRunResult, error) {
+	if err := s.checkAccess(ctx, tenantID, workspaceID, rbac.Admin); err != nil {
+		return nil, err
+	}
+	if req.UploadID == "" {
+		return nil, fmt.Errorf(msg.ErrTestRunUploadRequired)
+	}
+
+	processor, err := s.procRepo.Get(ctx, processorID)
 	if err != nil {
 		return nil, err
 	}
-	if !s.accessManager.CheckAccess(session.Sub, tenantID, workspaceID, rbac.Reader) {
+	if processor.WorkspaceID != workspaceID {
 		return nil, fmt.Errorf(msg.ErrAccessDenied)
 	}
+	source := processor.Workflow
+	if req.Workflow != "" {
+		if err := s.validateWorkflow(ctx, processor, req.Workflow); err != nil {
+			return nil, err
+		}
+		source = req.Workflow
+	}
+	var dslWorkflow dsl.Workflow
+	if err := yaml.Unmarshal([]byte(source), &dslWorkflow); err != nil {
+		return nil, err
+	}
+	if err := dslWorkflow.ResolveCalls(dsl.CallTarget{Processor: processorID}, s.callLoader(ctx, workspaceID)); err != nil {
+		return nil, err
+	}
+
+	// the activities read the file of the upload, a test run cannot do without it
+	upload, err := s.uploadRepo.Get(ctx, req.UploadID)
+	if err != nil || upload.WorkspaceID != workspaceID {
+		return nil, fmt.Errorf(msg.ErrTestUploadNotFound, req.UploadID)
+	}
+
+	settings := executionSettings(processor)
+	dslWorkflow.WorkspaceID = workspaceID
+	dslWorkflow.UploadID = upload.ID
+	dslWorkflow.ProcessorID = processorID
+	dslWorkflow.FileName = upload.FileName
+	dslWorkflow.ContentType = upload.ContentType
+	dslWorkflow.WorkflowVersion = processor.WorkflowVersion
+	dslWorkflow.Settings = settings
+	dslWorkflow.TestRun = true
+
+	workflowOptions := client.StartWorkflowOptions{
+		ID:                       testRunWorkflowID(processorID, uuid.New().String()),
+		TaskQueue:                s.taskQueue,
+		WorkflowExecutionTimeout: time.Duration(settings.WorkflowExecutionTimeoutSeconds) * time.Second,
+		WorkflowRunTimeout:       time.Duration(settings.WorkflowRunTimeoutSeconds) * time.Second,
+		TypedSearchAttributes: temporal.NewSearchAttributes(
+			temporal.NewSearchAttributeKeyKeyword("processorId").ValueSet(processorID),
+		),
+		RetryPolicy: &temporal.RetryPolicy{
+			MaximumAttempts: 1,
+		},
+		Memo: map[string]interface{}{
+			"uploadId":    upload.ID,
+			"workspaceId": workspaceID,
+			"testRun":     true,
+		},
+	}
+	we, err := s.temporalClient.ExecuteWorkflow(context.Background(), workflowOptions, dsl.SimpleDSLWorkflow, dslWorkflow)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to start test run")
+		return nil, err
+	}
+
+	return s.awaitTestRun(ctx, we.GetID(), we.GetRunID())
+}
+
+// GetTestRun returns the result of a test run of the processor, or its status
+// while it is still running.
+func (s *ProcessorService) GetTestRun(ctx context.Context, tenantID, workspaceID, processorID,
+	workflowID string) (*dto.TestRunResult, error) {
+	if err := s.checkAccess(ctx, tenantID, workspaceID, rbac.Admin); err != nil {
+		return nil, err
+	}
+	processor, err := s.procRepo.Get(ctx, processorID)
+	if err != nil {
+		return nil, err
+	}
+	if processor.WorkspaceID != workspaceID || !strings.HasPrefix(workflowID, testRunWorkflowID(processorID, "")) {
+		return nil, fmt.Errorf(msg.ErrRunNotFound, workflowID)
+	}
+
+	desc, err := s.temporalClient.DescribeWorkflowExecution(ctx, workflowID, "")
+	if err != nil {
+		return nil, err
+	}
+	runID := desc.WorkflowExecutionInfo.Execution.RunId
+	if desc.WorkflowExecutionInfo.Status == enums.WORKFLOW_EXECUTION_STATUS_RUNNING {
+		result := &dto.TestRunResult{WorkflowID: workflowID, RunID: runID}
+		result.Status = models.RunStatusRunning
+		return result, nil
+	}
+	return s.awaitTestRun(ctx, workflowID, runID)
+}
+
+// awaitTestRun waits at most testRunWaitTimeout for the result of a test run.
+func (s *ProcessorService) awaitTestRun(ctx context.Context, workflowID, runID string) (*dto.TestRunResult, error) {
+	result := &dto.TestRunResult{WorkflowID: workflowID, RunID: runID}
+	waitCtx, cancel := context.WithTimeout(ctx, testRunWaitTimeout)
+	defer cancel()
+
+	var raw []byte
+	if err := s.temporalClient.GetWorkflow(waitCtx, workflowID, runID).Get(waitCtx, &raw); err != nil {
+		if errors.Is(waitCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+			result.Status = models.RunStatusRunning
+			return result, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, &result.TestRunResult); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func testRunWorkflowID(processorID, id string) string {
+	return fmt.Sprintf("test-%s-%s", processorID, id)
+}
+
+// RerunWorkflowRun starts the next attempt of the processor for the upload of
+// a run.
+func (s *ProcessorService) RerunWorkflowRun(ctx context.Context, tenantID, workspaceID, processorID, workflowID,
+	runID string) (*dto.TriggerWorkflowResp, error) {
+	if err := s.checkAccess(ctx, tenantID, workspaceID, rbac.Admin); err != nil {
+		return nil, err
+	}
+	run, err := s.getRun(ctx, workspaceID, processorID, workflowID, runID)
+	if err != nil {
+		return nil, err
+	}
+	return s.rerun(ctx, workspaceID, processorID, run.UploadID)
+}
+
+// getRun returns a recorded run of the processor.
+func (s *ProcessorService) getRun(ctx context.Context, workspaceID, processorID, workflowID, runID string) (*models.ProcessorRun, error) {
+	run, err := s.runRepo.GetByWorkflowRun(ctx, workflowID, runID)
+	if err != nil {
+		return nil, err
+	}
+	if run.WorkspaceID != workspaceID || run.ProcessorID != processorID {
+		return nil, fmt.Errorf(msg.ErrRunNotFound, runID)
+	}
+	return run, nil
+}
+
+// GetPendingApprovals lists the approval statements a run is waiting on.
+func (s *ProcessorService) GetPendingApprovals(ctx context.Context, tenantID, workspaceID, processorID, workflowID,
+	runID string) ([]dsl.PendingApproval, error) {
+	if err := s.checkAccess(ctx, tenantID, workspaceID, rbac.Reader); err != nil {
+		return nil, err
+	}
+	if _, err := s.getRun(ctx, workspaceID, processorID, workflowID, runID); err != nil {
+		return nil, err
+	}
+	return s.pendingApprovals(ctx, workflowID, runID)
+}
+
+// DecideApproval sends the decision of the reviewer to a run that waits on the
+// approval.
+func (s *ProcessorService) DecideApproval(ctx context.Context, tenantID, workspaceID, processorID, workflowID, runID,
+	key string, decision *dto.ApprovalDecisionRequest) error {
+	if err := s.checkAccess(ctx, tenantID, workspaceID, rbac.Admin); err != nil {
+		return err
+	}
+	session, err := webutils.GetSessionFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := s.getRun(ctx, workspaceID, processorID, workflowID, runID); err != nil {
+		return err
+	}
+
+	pending, err := s.pendingApprovals(ctx, workflowID, runID)
+	if err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(pending, func(p dsl.PendingApproval) bool { return p.Key == key }) {
+		return fmt.Errorf(msg.ErrApprovalNotPending, key)
+	}
 
-	result, err := s.temporalClient.ListWorkflow(ctx, &workflowservice.ListWorkflowExecutionsRequest{
-		Query: "processorId = '" + processorID + "'",
+	approver := session.Email
+	if approver == "" {
+		approver = session.UserID
+	}
+	return s.temporalClient.SignalWorkflow(ctx, workflowID, runID, dsl.ApprovalSignalName(key), dsl.ApprovalDecision{
+		Action:     dsl.ApprovalAction(decision.Action),
+		ApproverID: session.UserID,
+		Approver:   approver,
+		Comment:    decision.Comment,
 	})
+}
+
+func (s *ProcessorService) pendingApprovals(ctx context.Context, workflowID, runID string) ([]dsl.PendingApproval, error) {
+	value, err := s.temporalClient.QueryWorkflow(ctx, workflowID, runID, dsl.PendingApprovalsQuery)
+	if err != nil {
+		return nil, err
+	}
+	pending := []dsl.PendingApproval{}
+	if err := value.Get(&pending); err != nil {
+		return nil, err
+	}
+	return pending, nil
+}
+
+// RerunProcessor starts the next attempt of the processor for an upload, even
+// if the processor does not trigger on the upload.
+func (s *ProcessorService) RerunProcessor(ctx context.Context, tenantID, workspaceID, processorID,
+	uploadID string) (*dto.TriggerWorkflowResp, error) {
+	if err := s.checkAccess(ctx, tenantID, workspaceID, rbac.Admin); err != nil {
+		return nil, err
+	}
+	return s.rerun(ctx, workspaceID, processorID, uploadID)
+}
+
+func (s *ProcessorService) rerun(ctx context.Context, workspaceID, processorID, uploadID string) (*dto.TriggerWorkflowResp, error) {
+	processor, err := s.procRepo.Get(ctx, processorID)
+	if err != nil {
+		return nil, err
+	}
+	upload, err := s.uploadRepo.Get(ctx, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if processor.WorkspaceID != workspaceID || upload.WorkspaceID != workspaceID {
+		return nil, fmt.Errorf(msg.ErrAccessDenied)
+	}
+	attempt, err := s.runRepo.NextAttempt(ctx, processorID, uploadID)
 	if err != nil {
 		return nil, err
 	}
-	var runs []dto.WorkflowRun
-	for _, run := range result.Executions {
+	return s.TriggerWorkflow(ctx, workspaceID, upload, processor, attempt)
+}
 
-		r := dto.WorkflowRun{
-			ID:         run.Execution.RunId,
-			WorkflowID: run.Execution.WorkflowId,
-			RunID:      run.Execution.RunId,
-			StartTime:  run.StartTime.AsTime(),
-			Status:     run.Status.Enum().String(),
+// ReprocessUpload starts the next attempt of the processors of an upload for
+// the bulk reprocess workflow, see workflow.ReprocessRequest for the processors
+// it selects. It returns the number of runs started.
+func (s *ProcessorService) ReprocessUpload(ctx context.Context, workspaceID, uploadID string, processorIDs []string,
+	onlyFailed bool) (int, error) {
+	upload, err := s.uploadRepo.Get(ctx, uploadID)
+	if err != nil {
+		return 0, err
+	}
+	processors, err := s.procRepo.GetAll(ctx, workspaceID)
+	if err != nil {
+		return 0, err
+	}
+
+	failed := make(map[string]bool)
+	if onlyFailed {
+		runs, err := s.runRepo.GetLatestRuns(ctx, uploadID)
+		if err != nil {
+			return 0, err
 		}
-		if workspaceIDField, ok := run.Memo.Fields["workspaceId"]; ok && workspaceIDField != nil {
-			r.WorkspaceID = strings.Trim(string(workspaceIDField.GetData()), "\"")
-		} else {
-			return nil, fmt.Errorf("workspaceId not found in run")
+		for _, run := range runs {
+			failed[run.ProcessorID] = run.Status == models.RunStatusFailed
 		}
+	}
 
-		if uploadIDField, ok := run.Memo.Fields["uploadId"]; ok && uploadIDField != nil {
-			r.UploadID = strings.Trim(string(uploadIDField.GetData()), "\"")
-		} else {
-			return nil, fmt.Errorf("uploadId not found in run")
+	started := 0
+	for _, processor := range processors {
+		if len(processorIDs) > 0 {
+			if !slices.Contains(processorIDs, processor.ID) {
+				continue
+			}
+		} else if !processor.Enabled || !processor.EvaluateTrigger(upload).Matched {
+			continue
+		}
+		if onlyFailed && !failed[processor.ID] {
+			continue
 		}
 
-		if run.CloseTime != nil {
-			r.EndTime = run.CloseTime.AsTime()
-			r.WorkflowTimeMillis = run.CloseTime.AsTime().UnixMilli() - run.StartTime.AsTime().UnixMilli()
-			r.ExecutionTimeMilis = run.ExecutionDuration.AsDuration().Milliseconds()
-		} else {
-			r.WorkflowTimeMillis = time.Now().Unix() - run.StartTime.Seconds
+		attempt, err := s.runRepo.NextAttempt(ctx, processor.ID, uploadID)
+		if err != nil {
+			return started, err
+		}
+		if _, err := s.TriggerWorkflow(ctx, workspaceID, upload, &processor, attempt); err != nil {
+			return started, err
 		}
-		runs = append(runs, r)
+		started++
+	}
+	return started, nil
+}
+
+// StartBulkReprocess starts a bulk reprocess workflow and returns its ID, which
+// is the ID of the job.
+func (s *ProcessorService) StartBulkReprocess(ctx context.Context, req *workflow.ReprocessRequest) (string, error) {
+	jobID := fmt.Sprintf("%s%s-%s", reprocessJobPrefix, req.WorkspaceID, uuid.New().String())
+	workflowOptions := client.StartWorkflowOptions{
+		ID:        jobID,
+		TaskQueue: s.taskQueue,
+		Memo: map[string]interface{}{
+			"workspaceId": req.WorkspaceID,
+		},
+	}
+	if _, err := s.temporalClient.ExecuteWorkflow(context.Background(), workflowOptions, workflow.BulkReprocessWorkflow, req); err != nil {
+		log.Error().Err(err).Msg("failed to start bulk reprocess")
+		return "", err
+	}
+	return jobID, nil
+}
+
+// GetBulkReprocess returns the status and the progress of a bulk reprocess.
+func (s *ProcessorService) GetBulkReprocess(ctx context.Context, workspaceID, jobID string) (*dto.ReprocessJob, error) {
+	if !strings.HasPrefix(jobID, reprocessJobPrefix+workspaceID+"-") {
+		return nil, fmt.Errorf(msg.ErrReprocessJobNotFound, jobID)
+	}
+	desc, err := s.temporalClient.DescribeWorkflowExecution(ctx, jobID, "")
+	if err != nil {
+		return nil, err
+	}
+	job := &dto.ReprocessJob{
+		JobID:  jobID,
+		Status: desc.WorkflowExecutionInfo.Status.String(),
+	}
+
+	value, err := s.temporalClient.QueryWorkflow(ctx, jobID, "", workflow.ReprocessProgressQuery)
+	if err != nil {
+		// the progress is only known while a worker can answer the query
+		log.Warn().Err(err).Str("job_id", jobID).Msg("failed to query bulk reprocess progress")
+		return job, nil
+	}
+	if err := value.Get(&job.Progress); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
 
+func (s *ProcessorService) CancelBulkReprocess(ctx context.Context, workspaceID, jobID string) error {
+	if !strings.HasPrefix(jobID, reprocessJobPrefix+workspaceID+"-") {
+		return fmt.Errorf(msg.ErrReprocessJobNotFound, jobID)
 	}
-	return runs, nil
+	return s.temporalClient.CancelWorkflow(ctx, jobID, "")
+}
+
+func (s *ProcessorService) GetWorkflowRuns(ctx context.Context, tenantID, workspaceID, processorID string,
+	paginationParams *models.PaginationParams) ([]models.ProcessorRun, int64, error) {
+	session, err := webutils.GetSessionFromCtx(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !s.accessManager.CheckAccess(session.Sub, tenantID, workspaceID, rbac.Reader) {
+		return nil, 0, fmt.Errorf(msg.ErrAccessDenied)
+	}
+
+	return s.runRepo.GetAll(ctx, workspaceID, processorID, paginationParams)
 }
 
 func (s *ProcessorService) GetWorkflowHistory(ctx context.Context, tenantID, workspaceID, procesorID, workflowID, runID string) ([]dto.WorkflowRunLogs, error) {
@@ -355,7 +982,7 @@ func (s *ProcessorService) GetRunArtifactsSignedURL(ctx context.Context, tenantI
 		return "", fmt.Errorf(msg.ErrAccessDenied)
 	}
 
-	objectKey := fmt.Sprintf("%s/artifacts/%s/%s.zip", uploadID, procesorID, runID)
+	objectKey := workflow.ArtifactArchiveKey(uploadID, procesorID, runID)
 
 	expiry := time.Now().Add(15 * time.Minute)
 	resp, err := s3.NewPresignClient(s.s3Client).PresignGetObject(ctx, &s3.GetObjectInput{
@@ -369,3 +996,146 @@ func (s *ProcessorService) GetRunArtifactsSignedURL(ctx context.Context, tenantI
 
 	return resp.URL, nil
 }
+
+// GetRunArtifacts lists the artifacts of a run with their sizes and content
+// types.
+func (s *ProcessorService) GetRunArtifacts(ctx context.Context, tenantID, workspaceID, processorID, workflowID,
+	runID string) ([]models.Artifact, error) {
+	if err := s.checkAccess(ctx, tenantID, workspaceID, rbac.Reader); err != nil {
+		return nil, err
+	}
+	run, err := s.getRun(ctx, workspaceID, processorID, workflowID, runID)
+	if err != nil {
+		return nil, err
+	}
+	return workflow.RunArtifacts(ctx, activities.NewS3Storage(s.s3Client), run)
+}
+
+// GetRunArtifactSignedURL returns a presigned URL that downloads a single
+// artifact of a run, or previews it in the browser when the disposition is
+// inline.
+func (s *ProcessorService) GetRunArtifactSignedURL(ctx context.Context, tenantID, workspaceID, processorID,
+	runID string, query *dto.ArtifactQuery) (*dto.ArtifactURL, error) {
+	if err := s.checkAccess(ctx, tenantID, workspaceID, rbac.Reader); err != nil {
+		return nil, err
+	}
+	run, err := s.getRun(ctx, workspaceID, processorID, query.WorkflowID, runID)
+	if err != nil {
+		return nil, err
+	}
+	artifact, err := s.getRunArtifact(ctx, run, query.Name)
+	if err != nil {
+		return nil, err
+	}
+	if artifact.Key == "" {
+		return nil, fmt.Errorf(msg.ErrArtifactInArchive, query.Name)
+	}
+
+	disposition := query.Disposition
+	if disposition == "" {
+		disposition = "attachment"
+	}
+	contentDisposition := mime.FormatMediaType(disposition, map[string]string{"filename": path.Base(artifact.Name)})
+	expiry := time.Now().Add(15 * time.Minute)
+	input := &s3.GetObjectInput{
+		Bucket:                     &workspaceID,
+		Key:                        &artifact.Key,
+		ResponseContentDisposition: &contentDisposition,
+		ResponseExpires:            &expiry,
+	}
+	if artifact.ContentType != "" {
+		input.ResponseContentType = &artifact.ContentType
+	}
+	resp, err := s3.NewPresignClient(s.s3Client).PresignGetObject(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.ArtifactURL{URL: resp.URL, ExpiresAt: expiry}, nil
+}
+
+// PromoteRunArtifact returns the upload derived from an artifact of a run. The
+// outputs of processors deriving uploads are saved as derived uploads already,
+// the other outputs are copied into a new upload. The new upload is
+// finished, but does not trigger the processors of the workspace.
+func (s *ProcessorService) PromoteRunArtifact(ctx context.Context, tenantID, workspaceID, processorID, workflowID,
+	runID string, req *dto.PromoteArtifactRequest) (*models.Upload, error) {
+	if err := s.checkAccess(ctx, tenantID, workspaceID, rbac.Admin); err != nil {
+		return nil, err
+	}
+	run, err := s.getRun(ctx, workspaceID, processorID, workflowID, runID)
+	if err != nil {
+		return nil, err
+	}
+	artifact, err := s.getRunArtifact(ctx, run, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if artifact.UploadID != "" {
+		return s.uploadRepo.Get(ctx, artifact.UploadID)
+	}
+	if artifact.Key == "" {
+		return nil, fmt.Errorf(msg.ErrArtifactInArchive, req.Name)
+	}
+
+	fileName := req.FileName
+	if fileName == "" {
+		fileName = path.Base(artifact.Name)
+	}
+	uploadID := uuid.New().String()
+	fileName = utils.ConvertToS3CompatibleFilename(fileName)
+	objectKey := fmt.Sprintf("%s/raw/%s", uploadID, fileName)
+	if err := activities.NewS3Storage(s.s3Client).Copy(ctx, workspaceID, artifact.Key, objectKey); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	upload := &models.Upload{
+		ID:            uploadID,
+		WorkspaceID:   workspaceID,
+		FileName:      fileName,
+		ContentType:   artifact.ContentType,
+		ContentLength: artifact.Size,
+		Metadata:      req.Metadata,
+		Status:        models.UploadStatusFinished,
+		ParentID:      &run.UploadID,
+		ProcessorID:   &run.ProcessorID,
+		RunID:         run.RunID,
+		ActivityKey:   artifact.ActivityKey,
+		StartedAt:     now,
+		FinishedAt:    now,
+	}
+	if err := s.uploadRepo.Create(ctx, upload); err != nil {
+		return nil, err
+	}
+	return upload, nil
+}
+
+// getRunArtifact returns an artifact of the run, or a file of its zip archive.
+func (s *ProcessorService) getRunArtifact(ctx context.Context, run *models.ProcessorRun, name string) (*models.Artifact, error) {
+	artifacts, err := workflow.RunArtifacts(ctx, activities.NewS3Storage(s.s3Client), run)
+	if err != nil {
+		return nil, err
+	}
+	artifact, ok := workflow.FindRunArtifact(artifacts, name)
+	if !ok {
+		return nil, fmt.Errorf(msg.ErrArtifactNotFound, name)
+	}
+	return artifact, nil
+}
+
+// executionSettings returns the retry, timeout and artifact settings of the
+// processor that are applied to its runs.
+func executionSettings(processor *models.Processor) *dsl.ExecutionSettings {
+	return &dsl.ExecutionSettings{
+		MaxRetries:                      processor.MaxRetries,
+		RetryInitialIntervalSeconds:     int64(processor.RetryInitialIntervalS),
+		RetryBackoffCoefficient:         processor.RetryBackoffCoefficient,
+		RetryMaxIntervalSeconds:         int64(processor.RetryMaxIntervalS),
+		ActivityTimeoutSeconds:          int64(processor.TaskRunTimeoutS),
+		WorkflowRunTimeoutSeconds:       int64(processor.WorkflowRunTimeoutS),
+		WorkflowExecutionTimeoutSeconds: int64(processor.WorkflowExecutionTimeoutS),
+		ArtifactMode:                    processor.ArtifactMode,
+		ArtifactDestinationPrefix:       processor.ArtifactDestinationPrefix,
+		DeriveUploads:                   processor.DeriveUploads,
+	}
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	dbdriver "github.com/uploadpilot/core/internal/db/driver"
+	"github.com/uploadpilot/core/internal/db/models"
+	"github.com/uploadpilot/core/internal/db/repo"
+	"github.com/uploadpilot/core/internal/workflow/dsl"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/mocks"
+	"go.temporal.io/sdk/testsuite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// tableRows is a database/sql connector answering every query with its rows,
+// restricted to the selected columns the way postgres does.
+type tableRows []map[string]driver.Value
+
+func (t tableRows) Connect(context.Context) (driver.Conn, error) { return t, nil }
+func (t tableRows) Driver() driver.Driver                        { return nil }
+func (t tableRows) Prepare(string) (driver.Stmt, error)          { return nil, errors.New("not supported") }
+func (t tableRows) Close() error                                 { return nil }
+func (t tableRows) Begin() (driver.Tx, error)                    { return nil, errors.New("not supported") }
+
+func (t tableRows) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	selected := strings.TrimPrefix(query[:strings.Index(query, " FROM ")], "SELECT ")
+	rows := &selectedRows{rows: t}
+	for _, column := range strings.Split(selected, ",") {
+		rows.columns = append(rows.columns, strings.Trim(column, `" `))
+	}
+	return rows, nil
+}
+
+type selectedRows struct {
+	columns []string
+	rows    tableRows
+}
+
+func (r *selectedRows) Columns() []string { return r.columns }
+func (r *selectedRows) Close() error      { return nil }
+
+func (r *selectedRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	for i, column := range r.columns {
+		dest[i] = r.rows[0][column]
+	}
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestTriggerWorkflowsAppliesArtifactSettings(t *testing.T) {
+	orm, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(tableRows{{
+		"id":                          "p1",
+		"name":                        "export",
+		"triggers":                    "{image/*}",
+		"enabled":                     true,
+		"workflow":                    "root:\n  activity:\n    key: convert\n    uses: ImageFormatConvertorV1\n    with:\n      format: png\n",
+		"workflow_version":            int64(1),
+		"max_retries":                 int64(0),
+		"artifact_mode":               string(models.ArtifactModeCopy),
+		"artifact_destination_prefix": "exports",
+	}})}), &gorm.Config{})
+	require.NoError(t, err)
+
+	temporalClient := &mocks.Client{}
+	run := &mocks.WorkflowRun{}
+	run.On("GetID").Return("wf")
+	run.On("GetRunID").Return("run")
+	var started dsl.Workflow
+	temporalClient.On("ExecuteWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { started = args.Get(3).(dsl.Workflow) }).
+		Return(run, nil)
+
+	s := &ProcessorService{
+		procRepo:       repo.NewProcessorRepo(&dbdriver.Driver{Orm: orm}),
+		temporalClient: temporalClient,
+	}
+	upload := &models.Upload{ID: "u1", WorkspaceID: "ws", FileName: "a.jpg", ContentType: "image/jpeg"}
+	require.NoError(t, s.TriggerWorkflows(context.Background(), "ws", upload, false))
+	temporalClient.AssertNumberOfCalls(t, "ExecuteWorkflow", 1)
+
+	var finalized []dsl.FinalizeArtifactsRequest
+	env := (&testsuite.WorkflowTestSuite{}).NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(dsl.SimpleDSLWorkflow)
+	env.RegisterActivityWithOptions(func(ctx context.Context, uses, payload string, scope dsl.ActivityScope) ([]byte, error) {
+		return []byte(`{"status_code": 200}`), nil
+	}, activity.RegisterOptions{Name: dsl.ExecutorActivityName})
+	env.RegisterActivityWithOptions(func(ctx context.Context, run dsl.RunEvent) error {
+		return nil
+	}, activity.RegisterOptions{Name: dsl.RecordRunActivityName})
+	env.RegisterActivityWithOptions(func(ctx context.Context, req dsl.FinalizeArtifactsRequest) (*models.ArtifactManifest, error) {
+		finalized = append(finalized, req)
+		return &models.ArtifactManifest{Mode: req.Mode}, nil
+	}, activity.RegisterOptions{Name: dsl.FinalizeArtifactsActivityName})
+	env.ExecuteWorkflow(dsl.SimpleDSLWorkflow, started)
+	require.NoError(t, env.GetWorkflowError())
+
+	require.Len(t, finalized, 1)
+	assert.Equal(t, models.ArtifactModeCopy, finalized[0].Mode)
+	assert.Equal(t, "exports", finalized[0].DestinationPrefix)
+}
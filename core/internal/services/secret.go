@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/phuslu/log"
+	"github.com/uploadpilot/core/internal/db/errs"
+	"github.com/uploadpilot/core/internal/db/models"
+	"github.com/uploadpilot/core/internal/db/repo"
+	"github.com/uploadpilot/core/internal/dto"
+	"github.com/uploadpilot/core/internal/msg"
+	"github.com/uploadpilot/core/internal/rbac"
+	"github.com/uploadpilot/core/pkg/vault"
+	"github.com/uploadpilot/core/web/webutils"
+)
+
+// SecretService manages the workspace secrets used by workflows. Values are
+// encrypted with the KMS and never returned once they are saved.
+type SecretService struct {
+	accessManager *rbac.AccessManager
+	secretRepo    *repo.SecretRepo
+	kms           vault.KMS
+}
+
+func NewSecretService(accessManager *rbac.AccessManager, secretRepo *repo.SecretRepo, kms vault.KMS) *SecretService {
+	return &SecretService{
+		accessManager: accessManager,
+		secretRepo:    secretRepo,
+		kms:           kms,
+	}
+}
+
+func (s *SecretService) GetAllSecrets(ctx context.Context, tenantID, workspaceID string) ([]models.Secret, error) {
+	if _, err := s.checkAdmin(ctx, tenantID, workspaceID); err != nil {
+		return nil, err
+	}
+
+	return s.secretRepo.GetAllSecretsWithoutValues(ctx, workspaceID)
+}
+
+func (s *SecretService) GetSecret(ctx context.Context, tenantID, workspaceID, key string) (*models.Secret, error) {
+	if _, err := s.checkAdmin(ctx, tenantID, workspaceID); err != nil {
+		return nil, err
+	}
+
+	secret, err := s.secretRepo.GetSecretWithoutValue(ctx, workspaceID, key)
+	if err != nil {
+		if errors.Is(err, errs.ErrRecordNotFound) {
+			return nil, fmt.Errorf(msg.ErrSecretNotFound, key)
+		}
+		return nil, err
+	}
+	return secret, nil
+}
+
+func (s *SecretService) CreateSecret(ctx context.Context, tenantID, workspaceID string, data *dto.CreateSecretData) (*models.Secret, error) {
+	session, err := s.checkAdmin(ctx, tenantID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.secretRepo.GetSecretWithoutValue(ctx, workspaceID, data.Key)
+	if err == nil {
+		return nil, fmt.Errorf(msg.ErrSecretAlreadyExists, data.Key)
+	}
+	if !errors.Is(err, errs.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	value, salt, err := s.kms.Encrypt(data.Value)
+	if err != nil {
+		log.Error().Err(err).Str("workspace_id", workspaceID).Msg("failed to encrypt secret")
+		return nil, errors.New(msg.ErrSecretEncryptionFailed)
+	}
+
+	secret := &models.Secret{
+		WorkspaceID: workspaceID,
+		Key:         data.Key,
+		Value:       value,
+		Salt:        salt,
+	}
+	secret.CreatedBy = session.UserID
+	secret.UpdatedBy = session.UserID
+
+	if err := s.secretRepo.CreateSecret(ctx, secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+func (s *SecretService) UpdateSecret(ctx context.Context, tenantID, workspaceID, key string, data *dto.UpdateSecretData) error {
+	session, err := s.checkAdmin(ctx, tenantID, workspaceID)
+	if err != nil {
+		return err
+	}
+
+	secret, err := s.secretRepo.GetSecretWithValue(ctx, workspaceID, key)
+	if err != nil {
+		if errors.Is(err, errs.ErrRecordNotFound) {
+			return fmt.Errorf(msg.ErrSecretNotFound, key)
+		}
+		return err
+	}
+
+	value, salt, err := s.kms.Encrypt(data.Value)
+	if err != nil {
+		log.Error().Err(err).Str("workspace_id", workspaceID).Msg("failed to encrypt secret")
+		return errors.New(msg.ErrSecretEncryptionFailed)
+	}
+
+	secret.Value = value
+	secret.Salt = salt
+	secret.UpdatedBy = session.UserID
+
+	return s.secretRepo.UpdateSecret(ctx, secret)
+}
+
+func (s *SecretService) DeleteSecret(ctx context.Context, tenantID, workspaceID, key string) error {
+	if _, err := s.checkAdmin(ctx, tenantID, workspaceID); err != nil {
+		return err
+	}
+
+	if _, err := s.secretRepo.GetSecretWithoutValue(ctx, workspaceID, key); err != nil {
+		if errors.Is(err, errs.ErrRecordNotFound) {
+			return fmt.Errorf(msg.ErrSecretNotFound, key)
+		}
+		return err
+	}
+
+	return s.secretRepo.DeleteSecret(ctx, workspaceID, key)
+}
+
+// checkAdmin returns the session of the user when they are an admin of the
+// workspace.
+func (s *SecretService) checkAdmin(ctx context.Context, tenantID, workspaceID string) (*dto.Session, error) {
+	session, err := webutils.GetSessionFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.accessManager.CheckAccess(session.Sub, tenantID, workspaceID, rbac.Admin) {
+		return nil, fmt.Errorf(msg.ErrAccessDenied)
+	}
+	return session, nil
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/uploadpilot/core/internal/db/errs"
+	"github.com/uploadpilot/core/internal/db/models"
+	"github.com/uploadpilot/core/internal/db/repo"
+	"github.com/uploadpilot/core/internal/dto"
+	"github.com/uploadpilot/core/internal/msg"
+	"github.com/uploadpilot/core/internal/rbac"
+	"github.com/uploadpilot/core/web/webutils"
+)
+
+// SnippetService manages the workflow snippets of a workspace. Snippets are
+// validated like processor workflows, including the workflows they call.
+type SnippetService struct {
+	accessManager *rbac.AccessManager
+	snippetRepo   *repo.WorkflowSnippetRepo
+	procSvc       *ProcessorService
+}
+
+func NewSnippetService(accessManager *rbac.AccessManager, snippetRepo *repo.WorkflowSnippetRepo,
+	procSvc *ProcessorService) *SnippetService {
+	return &SnippetService{
+		accessManager: accessManager,
+		snippetRepo:   snippetRepo,
+		procSvc:       procSvc,
+	}
+}
+
+func (s *SnippetService) GetAllSnippets(ctx context.Context, tenantID, workspaceID string) ([]models.WorkflowSnippet, error) {
+	if err := s.procSvc.checkAccess(ctx, tenantID, workspaceID, rbac.Reader); err != nil {
+		return nil, err
+	}
+	return s.snippetRepo.GetAll(ctx, workspaceID)
+}
+
+func (s *SnippetService) GetSnippet(ctx context.Context, tenantID, workspaceID, name string) (*models.WorkflowSnippet, error) {
+	if err := s.procSvc.checkAccess(ctx, tenantID, workspaceID, rbac.Reader); err != nil {
+		return nil, err
+	}
+	return s.get(ctx, workspaceID, name)
+}
+
+func (s *SnippetService) CreateSnippet(ctx context.Context, tenantID, workspaceID string,
+	data *dto.CreateSnippetRequest) (*models.WorkflowSnippet, error) {
+	if err := s.procSvc.checkAccess(ctx, tenantID, workspaceID, rbac.Admin); err != nil {
+		return nil, err
+	}
+	session, err := webutils.GetSessionFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.snippetRepo.Get(ctx, workspaceID, data.Name)
+	if err == nil {
+		return nil, fmt.Errorf(msg.ErrSnippetAlreadyExists, data.Name)
+	}
+	if !errors.Is(err, errs.ErrRecordNotFound) {
+		return nil, err
+	}
+	if err := s.procSvc.ValidateSnippet(ctx, workspaceID, data.Name, data.Workflow); err != nil {
+		return nil, err
+	}
+
+	snippet := &models.WorkflowSnippet{
+		WorkspaceID: workspaceID,
+		Name:        data.Name,
+		Description: data.Description,
+		Workflow:    data.Workflow,
+	}
+	snippet.CreatedBy = session.UserID
+	snippet.UpdatedBy = session.UserID
+
+	if err := s.snippetRepo.Create(ctx, snippet); err != nil {
+		return nil, err
+	}
+	return snippet, nil
+}
+
+// UpdateSnippet saves a new workflow for the snippet. Runs that already started
+// keep the workflow they started with.
+func (s *SnippetService) UpdateSnippet(ctx context.Context, tenantID, workspaceID, name string,
+	data *dto.UpdateSnippetRequest) error {
+	if err := s.procSvc.checkAccess(ctx, tenantID, workspaceID, rbac.Admin); err != nil {
+		return err
+	}
+	session, err := webutils.GetSessionFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+
+	snippet, err := s.get(ctx, workspaceID, name)
+	if err != nil {
+		return err
+	}
+	if err := s.procSvc.ValidateSnippet(ctx, workspaceID, name, data.Workflow); err != nil {
+		return err
+	}
+
+	snippet.Description = data.Description
+	snippet.Workflow = data.Workflow
+	snippet.UpdatedBy = session.UserID
+	return s.snippetRepo.Update(ctx, snippet)
+}
+
+func (s *SnippetService) DeleteSnippet(ctx context.Context, tenantID, workspaceID, name string) error {
+	if err := s.procSvc.checkAccess(ctx, tenantID, workspaceID, rbac.Admin); err != nil {
+		return err
+	}
+	if _, err := s.get(ctx, workspaceID, name); err != nil {
+		return err
+	}
+	return s.snippetRepo.Delete(ctx, workspaceID, name)
+}
+
+func (s *SnippetService) get(ctx context.Context, workspaceID, name string) (*models.WorkflowSnippet, error) {
+	snippet, err := s.snippetRepo.Get(ctx, workspaceID, name)
+	if err != nil {
+		if errors.Is(err, errs.ErrRecordNotFound) {
+			return nil, fmt.Errorf(msg.ErrSnippetNotFound, name)
+		}
+		return nil, err
+	}
+	return snippet, nil
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"slices"
 	"time"
@@ -13,11 +14,13 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/google/uuid"
 	"github.com/phuslu/log"
+	"github.com/uploadpilot/core/internal/db/errs"
 	"github.com/uploadpilot/core/internal/db/models"
 	"github.com/uploadpilot/core/internal/db/repo"
 	"github.com/uploadpilot/core/internal/dto"
 	"github.com/uploadpilot/core/internal/msg"
 	"github.com/uploadpilot/core/internal/rbac"
+	"github.com/uploadpilot/core/internal/workflow"
 	"github.com/uploadpilot/core/pkg/utils"
 	"github.com/uploadpilot/core/web/webutils"
 )
@@ -126,14 +129,15 @@ func (s *UploadService) FinishUpload(ctx context.Context, tenantID, workspaceID,
 	upload.FinishedAt = time.Now()
 	upload.Status = status
 
-	if err = s.processorSvc.TriggerWorkflows(ctx, workspaceID, upload); err != nil {
-		log.Error().Str("workspace_id", workspaceID).Str("upload_id", uploadID).Err(err).Msg("failed to trigger workflows")
-		upload.Status = models.UploadStatusFailed
-		s.uploadRepo.Update(ctx, uploadID, upload)
+	// the upload is saved before the workflows start, they move it to processing
+	if err := s.uploadRepo.Update(ctx, uploadID, upload); err != nil {
 		return err
 	}
 
-	if err := s.uploadRepo.Update(ctx, uploadID, upload); err != nil {
+	if err = s.processorSvc.TriggerWorkflows(ctx, workspaceID, upload, false); err != nil {
+		log.Error().Str("workspace_id", workspaceID).Str("upload_id", uploadID).Err(err).Msg("failed to trigger workflows")
+		upload.Status = models.UploadStatusFailed
+		s.uploadRepo.Update(ctx, uploadID, upload)
 		return err
 	}
 
@@ -180,10 +184,65 @@ func (s *UploadService) ProcessUpload(ctx context.Context, tenantID, workspaceID
 	if err != nil {
 		return err
 	}
-	return s.processorSvc.TriggerWorkflows(ctx, workspaceID, upload)
+	return s.processorSvc.TriggerWorkflows(ctx, workspaceID, upload, true)
+}
+
+// ReprocessUploads starts a bulk reprocess of the uploads matching the search
+// and filter, and returns the ID of the job.
+func (s *UploadService) ReprocessUploads(ctx context.Context, tenantID, workspaceID string, req *dto.ReprocessUploadsRequest) (string, error) {
+	session, err := webutils.GetSessionFromCtx(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if !s.accessManager.CheckAccess(session.Sub, tenantID, workspaceID, rbac.Admin) {
+		return "", fmt.Errorf(msg.ErrAccessDenied)
+	}
+
+	paginationParams, err := utils.GetPaginatedQueryParams(&dto.PaginatedQuery{Search: req.Search, Filter: req.Filter})
+	if err != nil {
+		return "", err
+	}
+	return s.processorSvc.StartBulkReprocess(ctx, &workflow.ReprocessRequest{
+		WorkspaceID:      workspaceID,
+		Pagination:       *paginationParams,
+		ProcessorIDs:     req.ProcessorIDs,
+		OnlyFailed:       req.OnlyFailed,
+		IncludeDerived:   req.IncludeDerived,
+		MaxConcurrency:   req.MaxConcurrency,
+		UploadsPerMinute: req.UploadsPerMinute,
+	})
+}
+
+func (s *UploadService) GetReprocessJob(ctx context.Context, tenantID, workspaceID, jobID string) (*dto.ReprocessJob, error) {
+	session, err := webutils.GetSessionFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.accessManager.CheckAccess(session.Sub, tenantID, workspaceID, rbac.Reader) {
+		return nil, fmt.Errorf(msg.ErrAccessDenied)
+	}
+
+	return s.processorSvc.GetBulkReprocess(ctx, workspaceID, jobID)
+}
+
+func (s *UploadService) CancelReprocessJob(ctx context.Context, tenantID, workspaceID, jobID string) error {
+	session, err := webutils.GetSessionFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !s.accessManager.CheckAccess(session.Sub, tenantID, workspaceID, rbac.Admin) {
+		return fmt.Errorf(msg.ErrAccessDenied)
+	}
+
+	return s.processorSvc.CancelBulkReprocess(ctx, workspaceID, jobID)
 }
 
-func (s *UploadService) DeleteUpload(ctx context.Context, tenantID, workspaceID, uploadID string) error {
+// DeleteUpload deletes the upload. An upload with derived uploads is only
+// deleted when they are deleted with it, or detached from it.
+func (s *UploadService) DeleteUpload(ctx context.Context, tenantID, workspaceID, uploadID string, cascade, detach bool) error {
 	session, err := webutils.GetSessionFromCtx(ctx)
 	if err != nil {
 		return err
@@ -193,7 +252,114 @@ func (s *UploadService) DeleteUpload(ctx context.Context, tenantID, workspaceID,
 		return fmt.Errorf(msg.ErrAccessDenied)
 	}
 
-	return s.uploadRepo.Delete(ctx, uploadID)
+	upload, err := s.getWorkspaceUpload(ctx, workspaceID, uploadID)
+	if err != nil {
+		return err
+	}
+	derivatives, err := s.getDerivatives(ctx, upload)
+	if err != nil {
+		return err
+	}
+
+	ids := []string{upload.ID}
+	switch {
+	case cascade:
+		for _, derivative := range derivatives {
+			ids = append(ids, derivative.ID)
+		}
+	case !detach && len(derivatives) > 0:
+		return fmt.Errorf(msg.ErrUploadHasDerivatives, len(derivatives))
+	}
+	return s.uploadRepo.DeleteAll(ctx, ids)
+}
+
+// GetUploadLineage returns the tree of uploads the upload belongs to, starting
+// from the source upload it was derived from.
+func (s *UploadService) GetUploadLineage(ctx context.Context, tenantID, workspaceID, uploadID string) (*dto.UploadLineage, error) {
+	session, err := webutils.GetSessionFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.accessManager.CheckAccess(session.Sub, tenantID, workspaceID, rbac.Reader) {
+		return nil, fmt.Errorf(msg.ErrAccessDenied)
+	}
+
+	root, err := s.getWorkspaceUpload(ctx, workspaceID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{root.ID: true}
+	for root.ParentID != nil && !seen[*root.ParentID] {
+		parent, err := s.getWorkspaceUpload(ctx, workspaceID, *root.ParentID)
+		if err != nil {
+			if errors.Is(err, errs.ErrRecordNotFound) {
+				// the source upload of detached derivatives may be gone
+				break
+			}
+			return nil, err
+		}
+		seen[parent.ID] = true
+		root = parent
+	}
+
+	derivatives, err := s.getDerivatives(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+	return buildLineage(*root, derivatives), nil
+}
+
+func (s *UploadService) getWorkspaceUpload(ctx context.Context, workspaceID, uploadID string) (*models.Upload, error) {
+	upload, err := s.uploadRepo.Get(ctx, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if upload.WorkspaceID != workspaceID {
+		return nil, fmt.Errorf(msg.UploadNotFound, uploadID)
+	}
+	return upload, nil
+}
+
+// getDerivatives returns the uploads derived from the upload, directly or
+// through other derived uploads, level by level.
+func (s *UploadService) getDerivatives(ctx context.Context, upload *models.Upload) ([]models.Upload, error) {
+	var derivatives []models.Upload
+	seen := map[string]bool{upload.ID: true}
+	parents := []string{upload.ID}
+	for len(parents) > 0 {
+		level, err := s.uploadRepo.GetDerivatives(ctx, parents)
+		if err != nil {
+			return nil, err
+		}
+		parents = nil
+		for _, derivative := range level {
+			if seen[derivative.ID] {
+				continue
+			}
+			seen[derivative.ID] = true
+			parents = append(parents, derivative.ID)
+			derivatives = append(derivatives, derivative)
+		}
+	}
+	return derivatives, nil
+}
+
+// buildLineage arranges the derived uploads in a tree under the root upload.
+func buildLineage(root models.Upload, derivatives []models.Upload) *dto.UploadLineage {
+	byParent := make(map[string][]models.Upload)
+	for _, derivative := range derivatives {
+		byParent[*derivative.ParentID] = append(byParent[*derivative.ParentID], derivative)
+	}
+	var build func(upload models.Upload) *dto.UploadLineage
+	build = func(upload models.Upload) *dto.UploadLineage {
+		node := &dto.UploadLineage{Upload: upload, Derivatives: []*dto.UploadLineage{}}
+		for _, child := range byParent[upload.ID] {
+			node.Derivatives = append(node.Derivatives, build(child))
+		}
+		return node
+	}
+	return build(root)
 }
 
 func (s *UploadService) GetUploadSignedURL(ctx context.Context, tenantID, workspaceID, uploadID string) (string, error) {
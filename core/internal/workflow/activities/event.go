@@ -0,0 +1,142 @@
+// Package activities contains the activities that run inside the worker
+// process. They follow the same event format as the lambda activities, see
+// lambdas/event_helper.
+package activities
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Event is the input of an activity, built from the workflow bindings.
+type Event struct {
+	WorkspaceID string
+	UploadID    string
+	ProcessorID string
+	RunID       string
+
+	ActivityKey      string
+	InputBucket      string
+	InputKey         string
+	InputFilename    string
+	InputContentType string
+	Args             map[string]any
+	OutputBucket     string
+	OutputKeyPrefix  string
+
+	// Bindings are all the workflow bindings the event was built from.
+	Bindings map[string]any
+}
+
+// Output is the result every activity returns.
+type Output struct {
+	StatusCode        int    `json:"status_code"`
+	Message           string `json:"message"`
+	Bucket            string `json:"bucket"`
+	OutputKey         string `json:"output_key"`
+	OutputFilename    string `json:"output_filename"`
+	OutputContentType string `json:"output_content_type"`
+	Error             string `json:"error"`
+}
+
+// TestRunPrefix is the prefix of all outputs of test runs.
+const TestRunPrefix = "test-runs"
+
+var requiredEventKeys = []string{"workspace_id", "upload_id", "processor_id", "run_id", "file_name", "content_type", "current_activity_key"}
+
+// ParseEvent reads the bindings sent by the workflow. The input file is the
+// output of the `input` activity when one is set, otherwise the uploaded file
+// under the raw/ prefix.
+func ParseEvent(payload []byte) (*Event, error) {
+	var bindings map[string]any
+	if err := json.Unmarshal(payload, &bindings); err != nil {
+		return nil, fmt.Errorf("invalid event: %w", err)
+	}
+	for _, key := range requiredEventKeys {
+		if _, ok := bindings[key]; !ok {
+			return nil, fmt.Errorf("missing required key %s in event", key)
+		}
+	}
+
+	str := func(key string) string {
+		s, _ := bindings[key].(string)
+		return s
+	}
+
+	ev := &Event{
+		WorkspaceID: str("workspace_id"),
+		UploadID:    str("upload_id"),
+		ProcessorID: str("processor_id"),
+		RunID:       str("run_id"),
+		ActivityKey: str("current_activity_key"),
+		Bindings:    bindings,
+	}
+
+	if input := str(ev.ActivityKey + ".input"); input != "" {
+		for _, field := range []string{"bucket", "output_key", "output_filename", "output_content_type"} {
+			if _, ok := bindings[input+"."+field]; !ok {
+				return nil, fmt.Errorf("missing required key %s.%s in event", input, field)
+			}
+		}
+		ev.InputBucket = str(input + ".bucket")
+		ev.InputKey = str(input + ".output_key")
+		ev.InputFilename = str(input + ".output_filename")
+		ev.InputContentType = str(input + ".output_content_type")
+	} else {
+		ev.InputBucket = ev.WorkspaceID
+		ev.InputKey = fmt.Sprintf("%s/raw/%s", ev.UploadID, str("file_name"))
+		ev.InputFilename = str("file_name")
+		ev.InputContentType = str("content_type")
+	}
+
+	outputFolder := "staging"
+	if saveOutput, _ := bindings[ev.ActivityKey+".save_output"].(bool); saveOutput {
+		outputFolder = "processed"
+	}
+	ev.OutputBucket = ev.WorkspaceID
+	ev.OutputKeyPrefix = fmt.Sprintf("%s/%s/%s/%s/%s/", ev.UploadID, outputFolder, ev.ProcessorID, ev.RunID, ev.ActivityKey)
+	if testRun, _ := bindings["test_run"].(bool); testRun {
+		// test runs never write next to the outputs of real runs
+		ev.OutputKeyPrefix = fmt.Sprintf("%s/%s/%s/%s/", TestRunPrefix, ev.ProcessorID, ev.RunID, ev.ActivityKey)
+	}
+
+	ev.Args = make(map[string]any)
+	for key, value := range bindings {
+		if name, arg, ok := strings.Cut(key, "."); ok && name == ev.ActivityKey {
+			ev.Args[arg] = value
+		}
+	}
+
+	return ev, nil
+}
+
+func (e *Event) StringArg(name, fallback string) string {
+	if s, ok := e.Args[name].(string); ok && s != "" {
+		return s
+	}
+	return fallback
+}
+
+func (e *Event) FloatArg(name string, fallback float64) float64 {
+	switch v := e.Args[name].(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	}
+	return fallback
+}
+
+func (e *Event) IntArg(name string, fallback int) int {
+	return int(e.FloatArg(name, float64(fallback)))
+}
+
+// failure builds the output returned for a failed activity.
+func failure(err error) ([]byte, error) {
+	return json.Marshal(&Output{
+		StatusCode: 500,
+		Message:    "Error",
+		Error:      err.Error(),
+	})
+}
@@ -0,0 +1,26 @@
+package activities
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEventOutputPrefix(t *testing.T) {
+	ev, err := ParseEvent(testEvent(t, nil))
+	require.NoError(t, err)
+	assert.Equal(t, "up/processed/proc/run/step/", ev.OutputKeyPrefix)
+
+	var bindings map[string]any
+	require.NoError(t, json.Unmarshal(testEvent(t, nil), &bindings))
+	bindings["test_run"] = true
+	payload, err := json.Marshal(bindings)
+	require.NoError(t, err)
+
+	ev, err = ParseEvent(payload)
+	require.NoError(t, err)
+	assert.Equal(t, "test-runs/proc/run/step/", ev.OutputKeyPrefix)
+	assert.Equal(t, "up/raw/photo.png", ev.InputKey)
+}
@@ -0,0 +1,348 @@
+package activities
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/phuslu/log"
+	"github.com/uploadpilot/core/internal/workflow/catalog"
+)
+
+const (
+	defaultHTTPTimeout      = 30 * time.Second
+	maxHTTPTimeout          = 5 * time.Minute
+	maxHTTPAttempts         = 10
+	defaultHTTPRetryBackoff = time.Second
+	maxHTTPResponseBody     = 1 << 20
+
+	defaultSignatureHeader = "X-Signature"
+	signatureTimestamp     = "X-Signature-Timestamp"
+)
+
+var defaultRetryOnStatus = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+type httpArgs struct {
+	URL                 string            `json:"url"`
+	Method              string            `json:"method"`
+	Headers             map[string]string `json:"headers"`
+	Query               map[string]any    `json:"query"`
+	Body                any               `json:"body"`
+	BodyFromInput       bool              `json:"body_from_input"`
+	TimeoutSeconds      float64           `json:"timeout_seconds"`
+	MaxAttempts         int               `json:"max_attempts"`
+	RetryOnStatus       []int             `json:"retry_on_status"`
+	RetryBackoffSeconds float64           `json:"retry_backoff_seconds"`
+	Auth                *httpAuth         `json:"auth"`
+}
+
+// httpAuth refers to workspace secrets by name, the values are resolved when
+// the request is sent.
+type httpAuth struct {
+	Type           string `json:"type"`
+	Username       string `json:"username"`
+	PasswordSecret string `json:"password_secret"`
+	TokenSecret    string `json:"token_secret"`
+	Secret         string `json:"secret"`
+	Header         string `json:"header"`
+	Algorithm      string `json:"algorithm"`
+}
+
+// httpOutput is the activity output. The response is saved under its own names
+// so that it does not shadow the headers and body arguments when the activity
+// runs again, e.g. in a loop.
+type httpOutput struct {
+	StatusCode      int               `json:"status_code"`
+	Message         string            `json:"message"`
+	ResponseHeaders map[string]string `json:"response_headers"`
+	ResponseBody    any               `json:"response_body"`
+	Attempts        int               `json:"attempts"`
+	Error           string            `json:"error"`
+}
+
+type HTTPActivity struct {
+	storage Storage
+	secrets SecretResolver
+	client  *http.Client
+}
+
+func NewHTTPActivity(storage Storage, secrets SecretResolver, client *http.Client) *HTTPActivity {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPActivity{
+		storage: storage,
+		secrets: secrets,
+		client:  client,
+	}
+}
+
+// Functions returns the activities by the name they are registered with on the native backend.
+func (a *HTTPActivity) Functions() map[string]func(ctx context.Context, payload []byte) ([]byte, error) {
+	return map[string]func(ctx context.Context, payload []byte) ([]byte, error){
+		catalog.HTTP_V_01.Name: a.Send,
+	}
+}
+
+// Send sends the configured request, retrying on network errors and on the
+// configured status codes.
+func (a *HTTPActivity) Send(ctx context.Context, payload []byte) ([]byte, error) {
+	ev, err := ParseEvent(payload)
+	if err != nil {
+		return failure(fmt.Errorf("the activity does not have a valid input: %w", err))
+	}
+
+	args, err := parseHTTPArgs(ev)
+	if err != nil {
+		return failure(err)
+	}
+
+	sign, err := a.authenticator(ctx, ev.WorkspaceID, args.Auth)
+	if err != nil {
+		return failure(err)
+	}
+
+	timeout := defaultHTTPTimeout
+	if args.TimeoutSeconds > 0 {
+		timeout = min(time.Duration(args.TimeoutSeconds*float64(time.Second)), maxHTTPTimeout)
+	}
+	attempts := min(max(args.MaxAttempts, 1), maxHTTPAttempts)
+	retryOn := args.RetryOnStatus
+	if retryOn == nil {
+		retryOn = defaultRetryOnStatus
+	}
+	backoff := defaultHTTPRetryBackoff
+	if args.RetryBackoffSeconds > 0 {
+		backoff = time.Duration(args.RetryBackoffSeconds * float64(time.Second))
+	}
+
+	var out *httpOutput
+	for attempt := 1; ; attempt++ {
+		out, err = a.send(ctx, ev, args, sign, timeout)
+		retry := err != nil || slices.Contains(retryOn, out.StatusCode)
+		if !retry || attempt >= attempts {
+			if out != nil {
+				out.Attempts = attempt
+			}
+			break
+		}
+
+		log.Warn().Err(err).Int("attempt", attempt).Str("url", args.URL).Msg("retrying http request")
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff << (attempt - 1)):
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if out.StatusCode < 200 || out.StatusCode > 299 {
+		out.Message = "Error"
+		out.Error = fmt.Sprintf("request to %s failed with status %d", args.URL, out.StatusCode)
+	}
+	return json.Marshal(out)
+}
+
+func parseHTTPArgs(ev *Event) (*httpArgs, error) {
+	raw, err := json.Marshal(ev.Args)
+	if err != nil {
+		return nil, err
+	}
+	var args httpArgs
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if args.URL == "" {
+		return nil, errors.New("url is required")
+	}
+	if args.Method == "" {
+		args.Method = http.MethodGet
+		if args.Body != nil || args.BodyFromInput {
+			args.Method = http.MethodPost
+		}
+	}
+	return &args, nil
+}
+
+// authenticator resolves the secrets of the auth block and returns the
+// function that adds the credentials to a request.
+func (a *HTTPActivity) authenticator(ctx context.Context, workspaceID string, auth *httpAuth) (func(req *http.Request, body []byte), error) {
+	if auth == nil || auth.Type == "" {
+		return func(*http.Request, []byte) {}, nil
+	}
+
+	resolve := func(field, name string) (string, error) {
+		if name == "" {
+			return "", fmt.Errorf("auth.%s is required for %s auth", field, auth.Type)
+		}
+		if a.secrets == nil {
+			return "", errors.New("workspace secrets are not available")
+		}
+		return a.secrets.Resolve(ctx, workspaceID, name)
+	}
+
+	switch auth.Type {
+	case "basic":
+		password, err := resolve("password_secret", auth.PasswordSecret)
+		if err != nil {
+			return nil, err
+		}
+		return func(req *http.Request, _ []byte) {
+			req.SetBasicAuth(auth.Username, password)
+		}, nil
+
+	case "bearer":
+		token, err := resolve("token_secret", auth.TokenSecret)
+		if err != nil {
+			return nil, err
+		}
+		return func(req *http.Request, _ []byte) {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}, nil
+
+	case "hmac":
+		key, err := resolve("secret", auth.Secret)
+		if err != nil {
+			return nil, err
+		}
+		var newHash func() hash.Hash
+		switch auth.Algorithm {
+		case "", "sha256":
+			newHash, auth.Algorithm = sha256.New, "sha256"
+		case "sha512":
+			newHash = sha512.New
+		default:
+			return nil, fmt.Errorf("unsupported hmac algorithm %s", auth.Algorithm)
+		}
+		header := auth.Header
+		if header == "" {
+			header = defaultSignatureHeader
+		}
+		return func(req *http.Request, body []byte) {
+			ts := strconv.FormatInt(time.Now().Unix(), 10)
+			mac := hmac.New(newHash, []byte(key))
+			mac.Write([]byte(ts + "."))
+			mac.Write(body)
+			req.Header.Set(signatureTimestamp, ts)
+			req.Header.Set(header, auth.Algorithm+"="+hex.EncodeToString(mac.Sum(nil)))
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported auth type %s", auth.Type)
+}
+
+// send makes a single attempt. Every attempt builds the request again so that a
+// streamed body is read from the start.
+func (a *HTTPActivity) send(ctx context.Context, ev *Event, args *httpArgs, sign func(*http.Request, []byte),
+	timeout time.Duration) (*httpOutput, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	target, err := url.Parse(args.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	if len(args.Query) > 0 {
+		q := target.Query()
+		for k, v := range args.Query {
+			q.Set(k, fmt.Sprint(v))
+		}
+		target.RawQuery = q.Encode()
+	}
+
+	var body io.Reader
+	var signed []byte
+	contentType := ""
+	var contentLength int64 = -1
+	switch {
+	case args.BodyFromInput:
+		rc, size, err := a.storage.Open(ctx, ev.InputBucket, ev.InputKey)
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		body, contentType, contentLength = rc, ev.InputContentType, size
+		if args.Auth != nil && args.Auth.Type == "hmac" {
+			// the signature covers the whole body, so it cannot be streamed
+			if signed, err = io.ReadAll(rc); err != nil {
+				return nil, err
+			}
+			body = bytes.NewReader(signed)
+		}
+	case args.Body == nil:
+	default:
+		if s, ok := args.Body.(string); ok {
+			signed, contentType = []byte(s), "text/plain"
+		} else {
+			if signed, err = json.Marshal(args.Body); err != nil {
+				return nil, err
+			}
+			contentType = "application/json"
+		}
+		body, contentLength = bytes.NewReader(signed), int64(len(signed))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(args.Method), target.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if contentLength >= 0 {
+		req.ContentLength = contentLength
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for k, v := range args.Headers {
+		req.Header.Set(k, v)
+	}
+	sign(req, signed)
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", args.URL, err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPResponseBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	out := &httpOutput{
+		StatusCode:      resp.StatusCode,
+		Message:         "Success",
+		ResponseHeaders: make(map[string]string, len(resp.Header)),
+		ResponseBody:    string(raw),
+	}
+	for k, v := range resp.Header {
+		out.ResponseHeaders[k] = strings.Join(v, ", ")
+	}
+	if strings.Contains(resp.Header.Get("Content-Type"), "json") {
+		var decoded any
+		if err := json.Unmarshal(raw, &decoded); err == nil {
+			out.ResponseBody = decoded
+		}
+	}
+	return out, nil
+}
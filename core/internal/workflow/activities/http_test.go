@@ -0,0 +1,144 @@
+package activities
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type staticSecrets map[string]string
+
+func (s staticSecrets) Resolve(ctx context.Context, workspaceID, name string) (string, error) {
+	v, ok := s[name]
+	if !ok {
+		return "", fmt.Errorf("secret %s not found", name)
+	}
+	return v, nil
+}
+
+func sendHTTP(t *testing.T, storage Storage, args map[string]any) (*httpOutput, error) {
+	t.Helper()
+	act := NewHTTPActivity(storage, staticSecrets{"TOKEN": "t0ken", "PASSWORD": "pa55", "SIGNING_KEY": "k3y"}, nil)
+	result, err := act.Send(context.Background(), testEvent(t, args))
+	if err != nil {
+		return nil, err
+	}
+	var out httpOutput
+	require.NoError(t, json.Unmarshal(result, &out))
+	return &out, nil
+}
+
+func TestHTTPRetriesOnConfiguredStatus(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", "abc")
+		_, _ = w.Write([]byte(`{"id": 7}`))
+	}))
+	defer srv.Close()
+
+	out, err := sendHTTP(t, nil, map[string]any{
+		"url":                   srv.URL,
+		"max_attempts":          3,
+		"retry_backoff_seconds": 0.001,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 200, out.StatusCode)
+	assert.Equal(t, 3, out.Attempts)
+	assert.Equal(t, "abc", out.ResponseHeaders["X-Request-Id"])
+	assert.Equal(t, map[string]any{"id": float64(7)}, out.ResponseBody)
+}
+
+func TestHTTPReportsFailedStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	out, err := sendHTTP(t, nil, map[string]any{"url": srv.URL, "max_attempts": 3})
+	require.NoError(t, err)
+	assert.Equal(t, 404, out.StatusCode)
+	assert.Equal(t, 1, out.Attempts)
+	assert.Contains(t, out.Error, "failed with status 404")
+}
+
+func TestHTTPAuth(t *testing.T) {
+	var got *http.Request
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		gotBody, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	_, err := sendHTTP(t, nil, map[string]any{
+		"url":  srv.URL,
+		"auth": map[string]any{"type": "bearer", "token_secret": "TOKEN"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer t0ken", got.Header.Get("Authorization"))
+
+	_, err = sendHTTP(t, nil, map[string]any{
+		"url":  srv.URL,
+		"auth": map[string]any{"type": "basic", "username": "bob", "password_secret": "PASSWORD"},
+	})
+	require.NoError(t, err)
+	user, pass, ok := got.BasicAuth()
+	assert.True(t, ok)
+	assert.Equal(t, "bob", user)
+	assert.Equal(t, "pa55", pass)
+
+	_, err = sendHTTP(t, nil, map[string]any{
+		"url":  srv.URL,
+		"body": map[string]any{"event": "uploaded"},
+		"auth": map[string]any{"type": "hmac", "secret": "SIGNING_KEY"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodPost, got.Method)
+	mac := hmac.New(sha256.New, []byte("k3y"))
+	mac.Write([]byte(got.Header.Get("X-Signature-Timestamp") + "."))
+	mac.Write(gotBody)
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), got.Header.Get("X-Signature"))
+
+	out, err := sendHTTP(t, nil, map[string]any{
+		"url":  srv.URL,
+		"auth": map[string]any{"type": "bearer", "token_secret": "MISSING"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 500, out.StatusCode)
+	assert.Contains(t, out.Error, "MISSING")
+}
+
+func TestHTTPStreamsUploadedFile(t *testing.T) {
+	var gotBody []byte
+	var gotType string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotType = r.Header.Get("Content-Type")
+	}))
+	defer srv.Close()
+
+	storage := newMemoryStorage()
+	require.NoError(t, storage.Put(context.Background(), "ws", "up/raw/photo.png", []byte("file-content"), "image/png"))
+
+	out, err := sendHTTP(t, storage, map[string]any{"url": srv.URL, "method": "PUT", "body_from_input": true})
+	require.NoError(t, err)
+	assert.Equal(t, 200, out.StatusCode)
+	assert.Equal(t, "file-content", string(gotBody))
+	assert.Equal(t, "image/png", gotType)
+}
@@ -0,0 +1,302 @@
+package activities
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/uploadpilot/core/internal/workflow/catalog"
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	defaultJpegQuality      = 90
+	defaultBlurRadius       = 2
+	defaultWatermarkSize    = 40
+	defaultWatermarkOpacity = 0.5
+	defaultWatermarkPlace   = "bottom-right"
+)
+
+// imageTransform changes the decoded image and returns it along with the
+// format it should be encoded in.
+type imageTransform func(ev *Event, img image.Image, format string) (image.Image, string, error)
+
+type ImageActivities struct {
+	storage Storage
+}
+
+func NewImageActivities(storage Storage) *ImageActivities {
+	return &ImageActivities{
+		storage: storage,
+	}
+}
+
+// Functions returns the activities by the name they are registered with on the native backend.
+func (a *ImageActivities) Functions() map[string]func(ctx context.Context, payload []byte) ([]byte, error) {
+	return map[string]func(ctx context.Context, payload []byte) ([]byte, error){
+		catalog.ImageResizeV1_0.Name:               a.Resize,
+		catalog.ImageAddWatermarkActivityV1_0.Name: a.AddWatermark,
+		catalog.ImageBlurActivityV1_0.Name:         a.Blur,
+		catalog.ImageConvertToJpegV1_0.Name:        a.ConvertToJpeg,
+		catalog.ImageConvertToBmpV1_0.Name:         a.ConvertToBmp,
+	}
+}
+
+func (a *ImageActivities) Resize(ctx context.Context, payload []byte) ([]byte, error) {
+	return a.run(ctx, payload, func(ev *Event, img image.Image, format string) (image.Image, string, error) {
+		width, height := ev.IntArg("width", 0), ev.IntArg("height", 0)
+		if width <= 0 && height <= 0 {
+			return nil, "", fmt.Errorf("width or height is required")
+		}
+
+		b := img.Bounds()
+		scaleW, scaleH := float64(width)/float64(b.Dx()), float64(height)/float64(b.Dy())
+		var scale float64
+		switch {
+		case width > 0 && height > 0:
+			scale = math.Min(scaleW, scaleH)
+		case width > 0:
+			scale = scaleW
+		default:
+			scale = scaleH
+		}
+
+		w := max(1, int(math.Round(float64(b.Dx())*scale)))
+		h := max(1, int(math.Round(float64(b.Dy())*scale)))
+		dst := image.NewRGBA(image.Rect(0, 0, w, h))
+		draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+		return dst, format, nil
+	})
+}
+
+func (a *ImageActivities) Blur(ctx context.Context, payload []byte) ([]byte, error) {
+	return a.run(ctx, payload, func(ev *Event, img image.Image, format string) (image.Image, string, error) {
+		radius := ev.FloatArg("radius", defaultBlurRadius)
+		if radius <= 0 {
+			return nil, "", fmt.Errorf("radius must be greater than 0")
+		}
+		return gaussianBlur(img, radius), format, nil
+	})
+}
+
+func (a *ImageActivities) AddWatermark(ctx context.Context, payload []byte) ([]byte, error) {
+	return a.run(ctx, payload, func(ev *Event, img image.Image, format string) (image.Image, string, error) {
+		text := ev.StringArg("text", "")
+		if text == "" {
+			return nil, "", fmt.Errorf("text is required")
+		}
+		opacity := ev.FloatArg("opacity", defaultWatermarkOpacity)
+		if opacity < 0 || opacity > 1 {
+			return nil, "", fmt.Errorf("opacity must be between 0 and 1")
+		}
+		return watermark(img, text, ev.FloatArg("size", defaultWatermarkSize), opacity,
+			ev.StringArg("position", defaultWatermarkPlace)), format, nil
+	})
+}
+
+func (a *ImageActivities) ConvertToJpeg(ctx context.Context, payload []byte) ([]byte, error) {
+	return a.run(ctx, payload, func(ev *Event, img image.Image, format string) (image.Image, string, error) {
+		return img, "jpeg", nil
+	})
+}
+
+func (a *ImageActivities) ConvertToBmp(ctx context.Context, payload []byte) ([]byte, error) {
+	return a.run(ctx, payload, func(ev *Event, img image.Image, format string) (image.Image, string, error) {
+		return img, "bmp", nil
+	})
+}
+
+// run reads the input image, applies the transform and writes the result
+// under the output prefix of the activity. Storage errors are returned as
+// errors so that the activity is retried, bad input is reported in the output.
+func (a *ImageActivities) run(ctx context.Context, payload []byte, transform imageTransform) ([]byte, error) {
+	ev, err := ParseEvent(payload)
+	if err != nil {
+		return failure(fmt.Errorf("the activity does not have a valid input: %w", err))
+	}
+
+	data, err := a.storage.Get(ctx, ev.InputBucket, ev.InputKey)
+	if err != nil {
+		return nil, err
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return failure(fmt.Errorf("failed to decode %s: %w", ev.InputFilename, err))
+	}
+
+	out, outFormat, err := transform(ev, img, format)
+	if err != nil {
+		return failure(err)
+	}
+
+	body, contentType, ext, err := encodeImage(out, outFormat, ev.IntArg("quality", defaultJpegQuality))
+	if err != nil {
+		return failure(err)
+	}
+
+	filename := strings.TrimSuffix(ev.InputFilename, filepath.Ext(ev.InputFilename)) + "." + ext
+	key := ev.OutputKeyPrefix + filename
+	if err := a.storage.Put(ctx, ev.OutputBucket, key, body, contentType); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(&Output{
+		StatusCode:        200,
+		Message:           "Success",
+		Bucket:            ev.OutputBucket,
+		OutputKey:         key,
+		OutputFilename:    filename,
+		OutputContentType: contentType,
+	})
+}
+
+// encodeImage encodes img in the given format. Formats that can only be
+// decoded, like webp, are written as png.
+func encodeImage(img image.Image, format string, quality int) ([]byte, string, string, error) {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "jpeg":
+		err = jpeg.Encode(&buf, flatten(img), &jpeg.Options{Quality: min(max(quality, 1), 100)})
+		return buf.Bytes(), "image/jpeg", "jpg", err
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+		return buf.Bytes(), "image/gif", "gif", err
+	case "bmp":
+		err = bmp.Encode(&buf, img)
+		return buf.Bytes(), "image/bmp", "bmp", err
+	case "tiff":
+		err = tiff.Encode(&buf, img, nil)
+		return buf.Bytes(), "image/tiff", "tiff", err
+	default:
+		err = png.Encode(&buf, img)
+		return buf.Bytes(), "image/png", "png", err
+	}
+}
+
+// flatten draws img on a white background, formats without alpha channel
+// would otherwise render transparent pixels black.
+func flatten(img image.Image) image.Image {
+	dst := image.NewRGBA(img.Bounds())
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Over)
+	return dst
+}
+
+func toRGBA(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	return dst
+}
+
+// gaussianBlur blurs img with a separable gaussian kernel using radius as sigma.
+func gaussianBlur(img image.Image, radius float64) *image.RGBA {
+	src := toRGBA(img)
+	size := int(math.Ceil(radius * 3))
+	kernel := make([]float64, 2*size+1)
+	var sum float64
+	for i := range kernel {
+		x := float64(i - size)
+		kernel[i] = math.Exp(-(x * x) / (2 * radius * radius))
+		sum += kernel[i]
+	}
+	for i := range kernel {
+		kernel[i] /= sum
+	}
+
+	tmp := image.NewRGBA(src.Bounds())
+	convolve(tmp, src, kernel, 1, 0)
+	dst := image.NewRGBA(src.Bounds())
+	convolve(dst, tmp, kernel, 0, 1)
+	return dst
+}
+
+// convolve applies the kernel along one axis, clamping at the edges.
+func convolve(dst, src *image.RGBA, kernel []float64, dx, dy int) {
+	b := src.Bounds()
+	size := len(kernel) / 2
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			var r, g, bl, a float64
+			for k, weight := range kernel {
+				sx := min(max(x+(k-size)*dx, b.Min.X), b.Max.X-1)
+				sy := min(max(y+(k-size)*dy, b.Min.Y), b.Max.Y-1)
+				i := src.PixOffset(sx, sy)
+				r += float64(src.Pix[i]) * weight
+				g += float64(src.Pix[i+1]) * weight
+				bl += float64(src.Pix[i+2]) * weight
+				a += float64(src.Pix[i+3]) * weight
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(math.Round(r))
+			dst.Pix[i+1] = uint8(math.Round(g))
+			dst.Pix[i+2] = uint8(math.Round(bl))
+			dst.Pix[i+3] = uint8(math.Round(a))
+		}
+	}
+}
+
+// watermark draws text with the given height in pixels at one of the corners
+// or the center of the image.
+func watermark(img image.Image, text string, size, opacity float64, position string) *image.RGBA {
+	dst := toRGBA(img)
+	b := dst.Bounds()
+
+	face := basicfont.Face7x13
+	textW := font.MeasureString(face, text).Ceil()
+	textH := face.Height
+	rendered := image.NewRGBA(image.Rect(0, 0, textW, textH))
+	drawer := font.Drawer{
+		Dst:  rendered,
+		Src:  image.White,
+		Face: face,
+		Dot:  fixed.P(0, face.Ascent),
+	}
+	drawer.DrawString(text)
+
+	margin := int(size / 2)
+	scale := size / float64(textH)
+	if maxW := float64(b.Dx() - 2*margin); float64(textW)*scale > maxW && maxW > 0 {
+		scale = maxW / float64(textW)
+	}
+	w := max(1, int(float64(textW)*scale))
+	h := max(1, int(float64(textH)*scale))
+
+	var origin image.Point
+	switch position {
+	case "top-left":
+		origin = image.Pt(margin, margin)
+	case "top-right":
+		origin = image.Pt(b.Dx()-w-margin, margin)
+	case "bottom-left":
+		origin = image.Pt(margin, b.Dy()-h-margin)
+	case "center":
+		origin = image.Pt((b.Dx()-w)/2, (b.Dy()-h)/2)
+	default:
+		origin = image.Pt(b.Dx()-w-margin, b.Dy()-h-margin)
+	}
+
+	scaled := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.BiLinear.Scale(scaled, scaled.Bounds(), rendered, rendered.Bounds(), draw.Src, nil)
+	mask := image.NewUniform(color.Alpha{A: uint8(math.Round(opacity * 255))})
+	draw.DrawMask(dst, image.Rectangle{Min: origin, Max: origin.Add(image.Pt(w, h))}, scaled, image.Point{}, mask, image.Point{}, draw.Over)
+
+	return dst
+}
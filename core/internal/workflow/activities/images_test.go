@@ -0,0 +1,138 @@
+package activities
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStorage struct {
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{objects: map[string][]byte{}, types: map[string]string{}}
+}
+
+func (m *memoryStorage) Get(ctx context.Context, bucket, key string) ([]byte, error) {
+	data, ok := m.objects[bucket+"/"+key]
+	if !ok {
+		return nil, fmt.Errorf("%s not found", key)
+	}
+	return data, nil
+}
+
+func (m *memoryStorage) Open(ctx context.Context, bucket, key string) (io.ReadCloser, int64, error) {
+	data, err := m.Get(ctx, bucket, key)
+	if err != nil {
+		return nil, 0, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), int64(len(data)), nil
+}
+
+func (m *memoryStorage) Put(ctx context.Context, bucket, key string, body []byte, contentType string) error {
+	m.objects[bucket+"/"+key] = body
+	m.types[bucket+"/"+key] = contentType
+	return nil
+}
+
+func testEvent(t *testing.T, args map[string]any) []byte {
+	t.Helper()
+	bindings := map[string]any{
+		"workspace_id":         "ws",
+		"upload_id":            "up",
+		"processor_id":         "proc",
+		"run_id":               "run",
+		"file_name":            "photo.png",
+		"content_type":         "image/png",
+		"current_activity_key": "step",
+		"step.save_output":     true,
+		"step.input":           "",
+	}
+	for k, v := range args {
+		bindings["step."+k] = v
+	}
+	payload, err := json.Marshal(bindings)
+	require.NoError(t, err)
+	return payload
+}
+
+func runImageActivity(t *testing.T, name string, args map[string]any) (*Output, image.Image, *memoryStorage) {
+	t.Helper()
+
+	src := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			src.Set(x, y, color.RGBA{R: uint8(x * 6), G: uint8(y * 12), B: 100, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, src))
+
+	storage := newMemoryStorage()
+	require.NoError(t, storage.Put(context.Background(), "ws", "up/raw/photo.png", buf.Bytes(), "image/png"))
+
+	fn := NewImageActivities(storage).Functions()[name]
+	require.NotNil(t, fn, "activity %s is not registered", name)
+
+	result, err := fn(context.Background(), testEvent(t, args))
+	require.NoError(t, err)
+	var out Output
+	require.NoError(t, json.Unmarshal(result, &out))
+	if out.StatusCode != 200 {
+		return &out, nil, storage
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(storage.objects["ws/"+out.OutputKey]))
+	require.NoError(t, err)
+	return &out, img, storage
+}
+
+func TestResizeKeepsAspectRatio(t *testing.T) {
+	out, img, _ := runImageActivity(t, "ImageResize@v1.0", map[string]any{"width": 20, "height": 20})
+
+	assert.Equal(t, "up/processed/proc/run/step/photo.png", out.OutputKey)
+	assert.Equal(t, "image/png", out.OutputContentType)
+	assert.Equal(t, image.Rect(0, 0, 20, 10), img.Bounds())
+}
+
+func TestConvertToBmpAndJpeg(t *testing.T) {
+	out, img, storage := runImageActivity(t, "ImageConvertToBmp@v1.0", nil)
+	assert.Equal(t, "photo.bmp", out.OutputFilename)
+	assert.Equal(t, "image/bmp", storage.types["ws/"+out.OutputKey])
+	assert.Equal(t, image.Rect(0, 0, 40, 20), img.Bounds())
+
+	out, _, _ = runImageActivity(t, "ImageConvertToJpeg@v1.0", map[string]any{"quality": 50})
+	assert.Equal(t, "photo.jpg", out.OutputFilename)
+	assert.Equal(t, "image/jpeg", out.OutputContentType)
+}
+
+func TestBlurAndWatermarkChangeTheImage(t *testing.T) {
+	for name, args := range map[string]map[string]any{
+		"ImageBlur@v1.0":         {"radius": 3},
+		"ImageAddWatermark@v1.0": {"text": "hi", "size": 10, "opacity": 1, "position": "center"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			out, img, storage := runImageActivity(t, name, args)
+			assert.Equal(t, 200, out.StatusCode)
+			assert.Equal(t, image.Rect(0, 0, 40, 20), img.Bounds())
+			assert.NotEqual(t, storage.objects["ws/up/raw/photo.png"], storage.objects["ws/"+out.OutputKey])
+		})
+	}
+}
+
+func TestImageActivityReportsInvalidArguments(t *testing.T) {
+	out, _, _ := runImageActivity(t, "ImageResize@v1.0", nil)
+	assert.Equal(t, 500, out.StatusCode)
+	assert.Equal(t, "width or height is required", out.Error)
+}
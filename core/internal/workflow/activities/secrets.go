@@ -0,0 +1,39 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/uploadpilot/core/internal/db/repo"
+	"github.com/uploadpilot/core/pkg/vault"
+)
+
+// SecretResolver returns the plaintext value of a workspace secret. Secrets are
+// only resolved inside activities so that they never end up in the workflow history.
+type SecretResolver interface {
+	Resolve(ctx context.Context, workspaceID, name string) (string, error)
+}
+
+type WorkspaceSecrets struct {
+	secretRepo *repo.SecretRepo
+	kms        vault.KMS
+}
+
+func NewWorkspaceSecrets(secretRepo *repo.SecretRepo, kms vault.KMS) *WorkspaceSecrets {
+	return &WorkspaceSecrets{
+		secretRepo: secretRepo,
+		kms:        kms,
+	}
+}
+
+func (s *WorkspaceSecrets) Resolve(ctx context.Context, workspaceID, name string) (string, error) {
+	secret, err := s.secretRepo.GetSecretWithValue(ctx, workspaceID, name)
+	if err != nil {
+		return "", fmt.Errorf("secret %s: %w", name, err)
+	}
+	value, err := s.kms.Decrypt(secret.Value, secret.Salt)
+	if err != nil {
+		return "", fmt.Errorf("secret %s could not be decrypted", name)
+	}
+	return value, nil
+}
@@ -0,0 +1,141 @@
+package activities
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// maxDeleteObjects is the most keys a single DeleteObjects request accepts.
+const maxDeleteObjects = 1000
+
+// Storage reads and writes the files processed by the activities.
+type Storage interface {
+	Get(ctx context.Context, bucket, key string) ([]byte, error)
+	// Open streams the object instead of reading it into memory.
+	Open(ctx context.Context, bucket, key string) (io.ReadCloser, int64, error)
+	Put(ctx context.Context, bucket, key string, body []byte, contentType string) error
+}
+
+// Object is an entry of a listing.
+type Object struct {
+	Key  string
+	Size int64
+}
+
+type S3Storage struct {
+	s3Client *s3.Client
+}
+
+func NewS3Storage(s3Client *s3.Client) *S3Storage {
+	return &S3Storage{
+		s3Client: s3Client,
+	}
+}
+
+func (s *S3Storage) Get(ctx context.Context, bucket, key string) ([]byte, error) {
+	obj, err := s.s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", key, err)
+	}
+	defer obj.Body.Close()
+	return io.ReadAll(obj.Body)
+}
+
+func (s *S3Storage) Open(ctx context.Context, bucket, key string) (io.ReadCloser, int64, error) {
+	obj, err := s.s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get %s: %w", key, err)
+	}
+	return obj.Body, aws.ToInt64(obj.ContentLength), nil
+}
+
+func (s *S3Storage) Put(ctx context.Context, bucket, key string, body []byte, contentType string) error {
+	_, err := s.s3Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put %s: %w", key, err)
+	}
+	return nil
+}
+
+// PutFile uploads a file without reading it into memory, the size is taken
+// from seeking to its end.
+func (s *S3Storage) PutFile(ctx context.Context, bucket, key string, file io.ReadSeeker, contentType string) error {
+	_, err := s.s3Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		Body:        file,
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put %s: %w", key, err)
+	}
+	return nil
+}
+
+// List returns every object under prefix.
+func (s *S3Storage) List(ctx context.Context, bucket, prefix string) ([]Object, error) {
+	var objects []Object
+	paginator := s3.NewListObjectsV2Paginator(s.s3Client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", prefix, err)
+		}
+		for _, obj := range page.Contents {
+			objects = append(objects, Object{Key: aws.ToString(obj.Key), Size: aws.ToInt64(obj.Size)})
+		}
+	}
+	return objects, nil
+}
+
+// Copy copies the object at srcKey to dstKey within the bucket.
+func (s *S3Storage) Copy(ctx context.Context, bucket, srcKey, dstKey string) error {
+	_, err := s.s3Client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:     aws.String(bucket),
+		CopySource: aws.String(url.PathEscape(bucket + "/" + srcKey)),
+		Key:        aws.String(dstKey),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to copy %s to %s: %w", srcKey, dstKey, err)
+	}
+	return nil
+}
+
+func (s *S3Storage) Delete(ctx context.Context, bucket string, keys []string) error {
+	for start := 0; start < len(keys); start += maxDeleteObjects {
+		end := min(start+maxDeleteObjects, len(keys))
+		ids := make([]types.ObjectIdentifier, 0, end-start)
+		for _, key := range keys[start:end] {
+			ids = append(ids, types.ObjectIdentifier{Key: aws.String(key)})
+		}
+		_, err := s.s3Client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(bucket),
+			Delete: &types.Delete{Objects: ids, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete %d objects: %w", end-start, err)
+		}
+	}
+	return nil
+}
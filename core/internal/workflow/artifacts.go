@@ -0,0 +1,378 @@
+package workflow
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/phuslu/log"
+	"github.com/uploadpilot/core/internal/db/models"
+	"github.com/uploadpilot/core/internal/workflow/activities"
+	"github.com/uploadpilot/core/internal/workflow/dsl"
+	"go.temporal.io/sdk/temporal"
+)
+
+// ArtifactStorage is the storage the outputs and artifacts of the runs are saved in.
+type ArtifactStorage interface {
+	List(ctx context.Context, bucket, prefix string) ([]activities.Object, error)
+	Open(ctx context.Context, bucket, key string) (io.ReadCloser, int64, error)
+	PutFile(ctx context.Context, bucket, key string, file io.ReadSeeker, contentType string) error
+	Copy(ctx context.Context, bucket, srcKey, dstKey string) error
+	Delete(ctx context.Context, bucket string, keys []string) error
+}
+
+// sniffLen is the number of bytes http.DetectContentType looks at.
+const sniffLen = 512
+
+// DerivedUploads saves the uploads derived from the outputs of the runs.
+type DerivedUploads interface {
+	CreateDerived(ctx context.Context, uploads []models.Upload) error
+}
+
+// ArtifactFinalizer turns the outputs that the activities of a run saved under
+// processed/ into the artifacts of the run, and into uploads derived from the
+// upload of the run when the processor derives uploads. It removes the
+// intermediate outputs under staging/.
+type ArtifactFinalizer struct {
+	storage ArtifactStorage
+	uploads DerivedUploads
+}
+
+func NewArtifactFinalizer(storage ArtifactStorage, uploads DerivedUploads) *ArtifactFinalizer {
+	return &ArtifactFinalizer{
+		storage: storage,
+		uploads: uploads,
+	}
+}
+
+// ProcessedPrefix is the prefix the activities of a run save their outputs under.
+func ProcessedPrefix(uploadID, processorID, runID string) string {
+	return fmt.Sprintf("%s/processed/%s/%s/", uploadID, processorID, runID)
+}
+
+// ArtifactArchiveKey is the zip archive of a run finalized in the zip mode.
+func ArtifactArchiveKey(uploadID, processorID, runID string) string {
+	return fmt.Sprintf("%s/artifacts/%s/%s.zip", uploadID, processorID, runID)
+}
+
+// ArtifactPrefix is the prefix of the artifacts of a run finalized in the individual mode.
+func ArtifactPrefix(uploadID, processorID, runID string) string {
+	return fmt.Sprintf("%s/artifacts/%s/%s/", uploadID, processorID, runID)
+}
+
+type runOutput struct {
+	key      string
+	name     string
+	artifact models.Artifact
+}
+
+// Finalize is registered as the "FinalizeArtifacts" activity. The outputs are
+// streamed from the storage, or copied within it, and never held in memory.
+// They are only removed once all artifacts were written, so that a failed
+// attempt can be retried, and failing to remove them does not fail the
+// activity. In the skip mode the outputs are left in place and only the
+// derived uploads are saved.
+func (f *ArtifactFinalizer) Finalize(ctx context.Context, req dsl.FinalizeArtifactsRequest) (*models.ArtifactManifest, error) {
+	if !slices.Contains(models.ArtifactModes, req.Mode) {
+		return nil, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("unknown artifact mode %q", req.Mode), "InvalidArtifactMode", nil)
+	}
+	bucket := req.WorkspaceID
+	processedPrefix := ProcessedPrefix(req.UploadID, req.ProcessorID, req.RunID)
+	objects, err := f.storage.List(ctx, bucket, processedPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var outputs []runOutput
+	for _, obj := range objects {
+		if strings.HasSuffix(obj.Key, "/") {
+			continue
+		}
+		outputs = append(outputs, runOutput{key: obj.Key, name: strings.TrimPrefix(obj.Key, processedPrefix)})
+	}
+
+	// the archive is written to a temporary file while the outputs are described
+	archiveKey := ArtifactArchiveKey(req.UploadID, req.ProcessorID, req.RunID)
+	var archive *os.File
+	var archiveArtifact models.Artifact
+	if req.Mode == models.ArtifactModeZip && len(outputs) > 0 {
+		archive, archiveArtifact, err = f.zipOutputs(ctx, bucket, archiveKey, outputs)
+		if archive != nil {
+			defer os.Remove(archive.Name())
+			defer archive.Close()
+		}
+	} else {
+		for i := range outputs {
+			if outputs[i].artifact, err = f.describeOutput(ctx, bucket, outputs[i]); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if req.DeriveUploads {
+		if err := f.saveDerivedUploads(ctx, bucket, req, outputs); err != nil {
+			return nil, err
+		}
+	}
+
+	manifest := &models.ArtifactManifest{Mode: req.Mode, Bucket: bucket, Artifacts: []models.Artifact{}}
+	switch req.Mode {
+	case models.ArtifactModeZip:
+		if archive == nil {
+			break
+		}
+		if err := f.storage.PutFile(ctx, bucket, archiveKey, archive, "application/zip"); err != nil {
+			return nil, err
+		}
+		manifest.Key = archiveKey
+		archiveArtifact.Key = archiveKey
+		for _, out := range outputs {
+			archiveArtifact.Files = append(archiveArtifact.Files, out.artifact)
+		}
+		manifest.Artifacts = append(manifest.Artifacts, archiveArtifact)
+
+	case models.ArtifactModeIndividual, models.ArtifactModeCopy:
+		manifest.Key = ArtifactPrefix(req.UploadID, req.ProcessorID, req.RunID)
+		if req.Mode == models.ArtifactModeCopy {
+			manifest.Key = fmt.Sprintf("%s/%s/%s/", strings.Trim(req.DestinationPrefix, "/"), req.UploadID, req.RunID)
+		}
+		for _, out := range outputs {
+			artifact := out.artifact
+			artifact.Key = manifest.Key + out.name
+			if err := f.storage.Copy(ctx, bucket, out.key, artifact.Key); err != nil {
+				return nil, err
+			}
+			manifest.Artifacts = append(manifest.Artifacts, artifact)
+		}
+
+	case models.ArtifactModeSkip:
+		manifest.Key = processedPrefix
+		for _, out := range outputs {
+			artifact := out.artifact
+			artifact.Key = out.key
+			manifest.Artifacts = append(manifest.Artifacts, artifact)
+		}
+		return manifest, nil
+	}
+
+	f.removeOutputs(ctx, bucket, req, objects)
+	return manifest, nil
+}
+
+// saveDerivedUploads copies every output to an upload derived from the upload
+// of the run, recording the processor, run and activity that produced it, and
+// sets the IDs of the uploads on the artifacts of the outputs. The IDs are
+// derived from the run and the output, so that a retried attempt does not
+// save them twice.
+func (f *ArtifactFinalizer) saveDerivedUploads(ctx context.Context, bucket string, req dsl.FinalizeArtifactsRequest,
+	outputs []runOutput) error {
+	if len(outputs) == 0 {
+		return nil
+	}
+	uploads := make([]models.Upload, 0, len(outputs))
+	now := time.Now()
+	for i, out := range outputs {
+		id := uuid.NewSHA1(uuid.NameSpaceURL, []byte(strings.Join(
+			[]string{req.WorkspaceID, req.UploadID, req.ProcessorID, req.RunID, out.name}, "/"))).String()
+		fileName := path.Base(out.name)
+		if err := f.storage.Copy(ctx, bucket, out.key, fmt.Sprintf("%s/raw/%s", id, fileName)); err != nil {
+			return err
+		}
+		uploads = append(uploads, models.Upload{
+			ID:            id,
+			WorkspaceID:   req.WorkspaceID,
+			FileName:      fileName,
+			ContentType:   out.artifact.ContentType,
+			ContentLength: out.artifact.Size,
+			Status:        models.UploadStatusFinished,
+			ParentID:      &req.UploadID,
+			ProcessorID:   &req.ProcessorID,
+			RunID:         req.RunID,
+			ActivityKey:   out.artifact.ActivityKey,
+			StartedAt:     now,
+			FinishedAt:    now,
+		})
+		outputs[i].artifact.UploadID = id
+	}
+	return f.uploads.CreateDerived(ctx, uploads)
+}
+
+// removeOutputs deletes the saved outputs that were turned into artifacts and
+// the intermediate outputs of the run.
+func (f *ArtifactFinalizer) removeOutputs(ctx context.Context, bucket string, req dsl.FinalizeArtifactsRequest, processed []activities.Object) {
+	keys := make([]string, 0, len(processed))
+	for _, obj := range processed {
+		keys = append(keys, obj.Key)
+	}
+	stagingPrefix := fmt.Sprintf("%s/staging/%s/%s/", req.UploadID, req.ProcessorID, req.RunID)
+	staging, err := f.storage.List(ctx, bucket, stagingPrefix)
+	if err != nil {
+		log.Error().Err(err).Str("prefix", stagingPrefix).Msg("failed to list staging outputs")
+	}
+	for _, obj := range staging {
+		keys = append(keys, obj.Key)
+	}
+	if len(keys) == 0 {
+		return
+	}
+	if err := f.storage.Delete(ctx, bucket, keys); err != nil {
+		log.Error().Err(err).Str("runId", req.RunID).Msg("failed to remove the outputs of the run")
+	}
+}
+
+// RunArtifacts lists the artifacts of a run. Finalized runs list the artifacts
+// of their manifest, the others list the outputs saved under processed/, which
+// is where runs that skip the finalization keep them.
+func RunArtifacts(ctx context.Context, storage ArtifactStorage, run *models.ProcessorRun) ([]models.Artifact, error) {
+	if run.Artifacts != nil && run.Artifacts.Artifacts != nil {
+		return run.Artifacts.Artifacts, nil
+	}
+	prefix := ProcessedPrefix(run.UploadID, run.ProcessorID, run.RunID)
+	objects, err := storage.List(ctx, run.WorkspaceID, prefix)
+	if err != nil {
+		return nil, err
+	}
+	artifacts := []models.Artifact{}
+	for _, obj := range objects {
+		if strings.HasSuffix(obj.Key, "/") {
+			continue
+		}
+		name := strings.TrimPrefix(obj.Key, prefix)
+		contentType := mime.TypeByExtension(path.Ext(name))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		artifacts = append(artifacts, models.Artifact{
+			Name:        name,
+			Key:         obj.Key,
+			Size:        obj.Size,
+			ContentType: contentType,
+			ActivityKey: outputActivityKey(name),
+		})
+	}
+	return artifacts, nil
+}
+
+// FindRunArtifact returns the artifact with the given name, looking into the
+// files of the zip archives too. Those have no key of their own and can only
+// be downloaded with their archive.
+func FindRunArtifact(artifacts []models.Artifact, name string) (*models.Artifact, bool) {
+	for i := range artifacts {
+		if artifacts[i].Name == name {
+			return &artifacts[i], true
+		}
+	}
+	for i := range artifacts {
+		if file, ok := FindRunArtifact(artifacts[i].Files, name); ok {
+			return file, true
+		}
+	}
+	return nil, false
+}
+
+// outputActivityKey returns the key of the activity that saved an output, the
+// activities save their outputs under processed/<processor>/<run>/<activity key>/.
+func outputActivityKey(name string) string {
+	key, _, _ := strings.Cut(name, "/")
+	return key
+}
+
+// zipOutputs streams the outputs into a zip archive in a temporary file and
+// describes the archive and, on the outputs, its files. The file is returned
+// rewound, the caller closes and removes it.
+func (f *ArtifactFinalizer) zipOutputs(ctx context.Context, bucket, key string, outputs []runOutput) (*os.File, models.Artifact, error) {
+	file, err := os.CreateTemp("", "artifacts-*.zip")
+	if err != nil {
+		return nil, models.Artifact{}, fmt.Errorf("failed to create the archive: %w", err)
+	}
+	archive := newDigest()
+	zw := zip.NewWriter(io.MultiWriter(file, archive))
+	for i, out := range outputs {
+		w, err := zw.Create(out.name)
+		if err != nil {
+			return file, models.Artifact{}, fmt.Errorf("failed to add %s to the archive: %w", out.name, err)
+		}
+		if outputs[i].artifact, err = f.streamOutput(ctx, bucket, out, w); err != nil {
+			return file, models.Artifact{}, fmt.Errorf("failed to add %s to the archive: %w", out.name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return file, models.Artifact{}, fmt.Errorf("failed to write the archive: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return file, models.Artifact{}, fmt.Errorf("failed to write the archive: %w", err)
+	}
+	return file, archive.artifact(path.Base(key)), nil
+}
+
+// describeOutput streams an output to describe it, without keeping it.
+func (f *ArtifactFinalizer) describeOutput(ctx context.Context, bucket string, out runOutput) (models.Artifact, error) {
+	return f.streamOutput(ctx, bucket, out, io.Discard)
+}
+
+// streamOutput copies an output to w and describes it with the activity that
+// saved it, computing its size and checksum on the way.
+func (f *ArtifactFinalizer) streamOutput(ctx context.Context, bucket string, out runOutput, w io.Writer) (models.Artifact, error) {
+	body, _, err := f.storage.Open(ctx, bucket, out.key)
+	if err != nil {
+		return models.Artifact{}, err
+	}
+	defer body.Close()
+	d := newDigest()
+	if _, err := io.Copy(io.MultiWriter(w, d), body); err != nil {
+		return models.Artifact{}, fmt.Errorf("failed to read %s: %w", out.key, err)
+	}
+	artifact := d.artifact(out.name)
+	artifact.ActivityKey = outputActivityKey(out.name)
+	return artifact, nil
+}
+
+// digest computes the size and checksum of a stream, and keeps its head to
+// detect the content type of files without a known extension.
+type digest struct {
+	hash hash.Hash
+	size int64
+	head []byte
+}
+
+func newDigest() *digest {
+	return &digest{hash: sha256.New()}
+}
+
+func (d *digest) Write(p []byte) (int, error) {
+	if n := sniffLen - len(d.head); n > 0 {
+		d.head = append(d.head, p[:min(n, len(p))]...)
+	}
+	d.size += int64(len(p))
+	return d.hash.Write(p)
+}
+
+func (d *digest) artifact(name string) models.Artifact {
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = http.DetectContentType(d.head)
+	}
+	return models.Artifact{
+		Name:        name,
+		Size:        d.size,
+		ContentType: contentType,
+		Checksum:    hex.EncodeToString(d.hash.Sum(nil)),
+	}
+}
@@ -0,0 +1,269 @@
+package workflow
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uploadpilot/core/internal/db/models"
+	"github.com/uploadpilot/core/internal/workflow/activities"
+	"github.com/uploadpilot/core/internal/workflow/dsl"
+)
+
+type memoryArtifactStorage struct {
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func newMemoryArtifactStorage(objects map[string]string) *memoryArtifactStorage {
+	s := &memoryArtifactStorage{objects: map[string][]byte{}, types: map[string]string{}}
+	for key, body := range objects {
+		s.objects[key] = []byte(body)
+	}
+	return s
+}
+
+func (s *memoryArtifactStorage) List(ctx context.Context, bucket, prefix string) ([]activities.Object, error) {
+	var objects []activities.Object
+	for key, body := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, activities.Object{Key: key, Size: int64(len(body))})
+		}
+	}
+	slices.SortFunc(objects, func(a, b activities.Object) int { return strings.Compare(a.Key, b.Key) })
+	return objects, nil
+}
+
+func (s *memoryArtifactStorage) Open(ctx context.Context, bucket, key string) (io.ReadCloser, int64, error) {
+	body, ok := s.objects[key]
+	if !ok {
+		return nil, 0, fmt.Errorf("%s not found", key)
+	}
+	return io.NopCloser(bytes.NewReader(body)), int64(len(body)), nil
+}
+
+func (s *memoryArtifactStorage) PutFile(ctx context.Context, bucket, key string, file io.ReadSeeker, contentType string) error {
+	body, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+	s.objects[key] = body
+	s.types[key] = contentType
+	return nil
+}
+
+func (s *memoryArtifactStorage) Copy(ctx context.Context, bucket, srcKey, dstKey string) error {
+	body, ok := s.objects[srcKey]
+	if !ok {
+		return fmt.Errorf("%s not found", srcKey)
+	}
+	s.objects[dstKey] = body
+	s.types[dstKey] = s.types[srcKey]
+	return nil
+}
+
+func (s *memoryArtifactStorage) Delete(ctx context.Context, bucket string, keys []string) error {
+	for _, key := range keys {
+		delete(s.objects, key)
+	}
+	return nil
+}
+
+// keys returns the keys of the objects, the raw objects of the derived uploads
+// are left out.
+func (s *memoryArtifactStorage) keys() []string {
+	var keys []string
+	for key := range s.objects {
+		if strings.HasPrefix(key, "up/") {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+type memoryDerivedUploads struct {
+	uploads map[string]models.Upload
+}
+
+func (u *memoryDerivedUploads) CreateDerived(ctx context.Context, uploads []models.Upload) error {
+	for _, upload := range uploads {
+		if _, ok := u.uploads[upload.ID]; !ok {
+			u.uploads[upload.ID] = upload
+		}
+	}
+	return nil
+}
+
+func newMemoryDerivedUploads() *memoryDerivedUploads {
+	return &memoryDerivedUploads{uploads: map[string]models.Upload{}}
+}
+
+func runOutputs() map[string]string {
+	return map[string]string{
+		"up/processed/proc/run/convert/photo.png": "png bytes",
+		"up/processed/proc/run/ocr/text.txt":      "hello",
+		"up/staging/proc/run/resize/small.png":    "intermediate",
+		"up/raw/photo.jpg":                        "original",
+	}
+}
+
+func finalizeRequest(mode models.ArtifactMode) dsl.FinalizeArtifactsRequest {
+	return dsl.FinalizeArtifactsRequest{WorkspaceID: "ws", UploadID: "up", ProcessorID: "proc", RunID: "run", Mode: mode}
+}
+
+func TestFinalizeArtifactsZip(t *testing.T) {
+	storage := newMemoryArtifactStorage(runOutputs())
+	manifest, err := NewArtifactFinalizer(storage, newMemoryDerivedUploads()).Finalize(context.Background(), finalizeRequest(models.ArtifactModeZip))
+	require.NoError(t, err)
+
+	assert.Equal(t, "up/artifacts/proc/run.zip", manifest.Key)
+	assert.Equal(t, []string{"up/artifacts/proc/run.zip", "up/raw/photo.jpg"}, storage.keys())
+	// no upload is derived unless the processor asks for it
+	assert.Len(t, storage.objects, 2)
+	assert.Equal(t, "application/zip", storage.types[manifest.Key])
+
+	require.Len(t, manifest.Artifacts, 1)
+	archive := manifest.Artifacts[0]
+	assert.Equal(t, "run.zip", archive.Name)
+	assert.Equal(t, int64(len(storage.objects[manifest.Key])), archive.Size)
+	require.Len(t, archive.Files, 2)
+	assert.Equal(t, "ocr/text.txt", archive.Files[1].Name)
+	assert.Equal(t, int64(5), archive.Files[1].Size)
+	assert.Equal(t, "text/plain; charset=utf-8", archive.Files[1].ContentType)
+	// sha256 of "hello"
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", archive.Files[1].Checksum)
+
+	zr, err := zip.NewReader(bytes.NewReader(storage.objects[manifest.Key]), int64(len(storage.objects[manifest.Key])))
+	require.NoError(t, err)
+	require.Len(t, zr.File, 2)
+	assert.Equal(t, "convert/photo.png", zr.File[0].Name)
+	f, err := zr.File[1].Open()
+	require.NoError(t, err)
+	content, err := io.ReadAll(f)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(content))
+}
+
+func TestFinalizeArtifactsIndividualAndCopy(t *testing.T) {
+	storage := newMemoryArtifactStorage(runOutputs())
+	manifest, err := NewArtifactFinalizer(storage, newMemoryDerivedUploads()).Finalize(context.Background(), finalizeRequest(models.ArtifactModeIndividual))
+	require.NoError(t, err)
+	assert.Equal(t, "up/artifacts/proc/run/", manifest.Key)
+	assert.Equal(t, []string{
+		"up/artifacts/proc/run/convert/photo.png",
+		"up/artifacts/proc/run/ocr/text.txt",
+		"up/raw/photo.jpg",
+	}, storage.keys())
+	require.Len(t, manifest.Artifacts, 2)
+	assert.Equal(t, "image/png", manifest.Artifacts[0].ContentType)
+	assert.Equal(t, int64(5), manifest.Artifacts[1].Size)
+	// sha256 of "hello"
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", manifest.Artifacts[1].Checksum)
+
+	storage = newMemoryArtifactStorage(runOutputs())
+	req := finalizeRequest(models.ArtifactModeCopy)
+	req.DestinationPrefix = "exports/customer/"
+	manifest, err = NewArtifactFinalizer(storage, newMemoryDerivedUploads()).Finalize(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "exports/customer/up/run/", manifest.Key)
+	assert.Equal(t, "exports/customer/up/run/ocr/text.txt", manifest.Artifacts[1].Key)
+	assert.Equal(t, "hello", string(storage.objects["exports/customer/up/run/ocr/text.txt"]))
+}
+
+func TestFinalizeArtifactsKeepsOutputsOnUnknownMode(t *testing.T) {
+	storage := newMemoryArtifactStorage(runOutputs())
+	_, err := NewArtifactFinalizer(storage, newMemoryDerivedUploads()).Finalize(context.Background(), finalizeRequest("tar"))
+	require.Error(t, err)
+	assert.Len(t, storage.objects, 4)
+}
+
+func TestRunArtifacts(t *testing.T) {
+	storage := newMemoryArtifactStorage(runOutputs())
+	run := &models.ProcessorRun{WorkspaceID: "ws", UploadID: "up", ProcessorID: "proc", RunID: "run"}
+
+	artifacts, err := RunArtifacts(context.Background(), storage, run)
+	require.NoError(t, err)
+	require.Len(t, artifacts, 2)
+	assert.Equal(t, models.Artifact{
+		Name:        "convert/photo.png",
+		Key:         "up/processed/proc/run/convert/photo.png",
+		Size:        9,
+		ContentType: "image/png",
+		ActivityKey: "convert",
+	}, artifacts[0])
+
+	run.Artifacts, err = NewArtifactFinalizer(storage, newMemoryDerivedUploads()).Finalize(context.Background(), finalizeRequest(models.ArtifactModeZip))
+	require.NoError(t, err)
+	artifacts, err = RunArtifacts(context.Background(), storage, run)
+	require.NoError(t, err)
+	require.Len(t, artifacts, 1)
+
+	archive, ok := FindRunArtifact(artifacts, "run.zip")
+	require.True(t, ok)
+	assert.Equal(t, "up/artifacts/proc/run.zip", archive.Key)
+	file, ok := FindRunArtifact(artifacts, "ocr/text.txt")
+	require.True(t, ok)
+	assert.Empty(t, file.Key)
+	_, ok = FindRunArtifact(artifacts, "resize/small.png")
+	assert.False(t, ok)
+}
+
+func TestFinalizeArtifactsSavesDerivedUploads(t *testing.T) {
+	storage := newMemoryArtifactStorage(runOutputs())
+	uploads := newMemoryDerivedUploads()
+	req := finalizeRequest(models.ArtifactModeZip)
+	req.DeriveUploads = true
+	manifest, err := NewArtifactFinalizer(storage, uploads).Finalize(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, uploads.uploads, 2)
+
+	file := manifest.Artifacts[0].Files[1]
+	assert.Equal(t, "ocr", file.ActivityKey)
+	upload, ok := uploads.uploads[file.UploadID]
+	require.True(t, ok)
+	assert.Equal(t, "up", *upload.ParentID)
+	assert.Equal(t, "proc", *upload.ProcessorID)
+	assert.Equal(t, "run", upload.RunID)
+	assert.Equal(t, "ocr", upload.ActivityKey)
+	assert.Equal(t, "text.txt", upload.FileName)
+	assert.Equal(t, models.UploadStatusFinished, upload.Status)
+	assert.Equal(t, "hello", string(storage.objects[file.UploadID+"/raw/text.txt"]))
+
+	// a retried attempt derives the same uploads
+	retried := NewArtifactFinalizer(newMemoryArtifactStorage(runOutputs()), newMemoryDerivedUploads())
+	req.Mode = models.ArtifactModeIndividual
+	manifest, err = retried.Finalize(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, file.UploadID, manifest.Artifacts[1].UploadID)
+}
+
+func TestFinalizeArtifactsSkipKeepsOutputs(t *testing.T) {
+	storage := newMemoryArtifactStorage(runOutputs())
+	uploads := newMemoryDerivedUploads()
+	req := finalizeRequest(models.ArtifactModeSkip)
+	req.DeriveUploads = true
+	manifest, err := NewArtifactFinalizer(storage, uploads).Finalize(context.Background(), req)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"up/processed/proc/run/convert/photo.png",
+		"up/processed/proc/run/ocr/text.txt",
+		"up/raw/photo.jpg",
+		"up/staging/proc/run/resize/small.png",
+	}, storage.keys())
+	assert.Equal(t, "up/processed/proc/run/", manifest.Key)
+	require.Len(t, manifest.Artifacts, 2)
+	assert.Equal(t, "up/processed/proc/run/ocr/text.txt", manifest.Artifacts[1].Key)
+	// the lineage is recorded in the skip mode too
+	upload, ok := uploads.uploads[manifest.Artifacts[1].UploadID]
+	require.True(t, ok)
+	assert.Equal(t, "ocr", upload.ActivityKey)
+}
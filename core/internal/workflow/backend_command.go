@@ -0,0 +1,44 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/phuslu/log"
+)
+
+// CommandBackend runs an activity as a local process, e.g. a script or
+// `docker run -i <image>`. The payload is written to stdin and the output is
+// read from stdout.
+type CommandBackend struct{}
+
+func NewCommandBackend() *CommandBackend {
+	return &CommandBackend{}
+}
+
+func (b *CommandBackend) Invoke(ctx context.Context, command string, payload []byte) ([]byte, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("command is empty")
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	log.Info().Str("command", command).Msg("running activity command")
+	if err := cmd.Run(); err != nil {
+		log.Error().Err(err).Str("stderr", stderr.String()).Msg("activity command failed")
+		return nil, fmt.Errorf("failed to run activity: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	if stderr.Len() > 0 {
+		log.Info().Str("logs", stderr.String()).Msg("activity command logs")
+	}
+
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,56 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/phuslu/log"
+)
+
+// maxHTTPBackendResponse bounds the size of an activity response read into memory.
+const maxHTTPBackendResponse = 10 << 20
+
+// HTTPBackend posts the payload to an activity server and reads the output
+// from the response body.
+type HTTPBackend struct {
+	client *http.Client
+}
+
+func NewHTTPBackend(client *http.Client) *HTTPBackend {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPBackend{
+		client: client,
+	}
+}
+
+func (b *HTTPBackend) Invoke(ctx context.Context, url string, payload []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	log.Info().Str("url", url).Msg("invoking activity endpoint")
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run activity: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPBackendResponse))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read activity response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		log.Error().Int("status", resp.StatusCode).Str("body", string(body)).Msg("activity endpoint failed")
+		return nil, fmt.Errorf("activity endpoint returned %d: %s", resp.StatusCode, string(body))
+	}
+
+	return body, nil
+}
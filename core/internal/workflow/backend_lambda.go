@@ -0,0 +1,50 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/phuslu/log"
+)
+
+// LambdaBackend invokes AWS Lambda functions synchronously.
+type LambdaBackend struct {
+	lambdaClient *lambda.Client
+}
+
+func NewLambdaBackend(lambdaClient *lambda.Client) *LambdaBackend {
+	return &LambdaBackend{
+		lambdaClient: lambdaClient,
+	}
+}
+
+func (b *LambdaBackend) Invoke(ctx context.Context, functionName string, payload []byte) ([]byte, error) {
+	input := &lambda.InvokeInput{
+		FunctionName: aws.String(functionName),
+		Payload:      payload,
+		LogType:      types.LogTypeTail,
+	}
+
+	// the payload holds the resolved secrets of the activity, so it is never logged
+	log.Info().Str("functionName", functionName).Msg("invoking lambda")
+	op, err := b.lambdaClient.Invoke(ctx, input)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to invoke lambda")
+		return nil, fmt.Errorf("failed to run activity: %w", err)
+	}
+
+	if op.FunctionError != nil {
+		log.Error().Str("error", *op.FunctionError).Msg("lambda error")
+		return nil, fmt.Errorf("error: %s", string(op.Payload))
+	}
+
+	if op.LogResult != nil {
+		logs, _ := DecodeBase64(*op.LogResult)
+		log.Info().Str("logs", logs).Msg("lambda logs")
+	}
+
+	return op.Payload, nil
+}
@@ -0,0 +1,40 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// NativeFunc is an activity implemented in Go. It receives the same payload as
+// the other backends and returns the JSON encoded output.
+type NativeFunc func(ctx context.Context, payload []byte) ([]byte, error)
+
+// NativeBackend runs activities in the worker process.
+type NativeBackend struct {
+	mu    sync.RWMutex
+	funcs map[string]NativeFunc
+}
+
+func NewNativeBackend() *NativeBackend {
+	return &NativeBackend{
+		funcs: make(map[string]NativeFunc),
+	}
+}
+
+// Register makes fn available under name, the target used by catalog entries.
+func (b *NativeBackend) Register(name string, fn NativeFunc) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.funcs[name] = fn
+}
+
+func (b *NativeBackend) Invoke(ctx context.Context, name string, payload []byte) ([]byte, error) {
+	b.mu.RLock()
+	fn, ok := b.funcs[name]
+	b.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("native activity %s is not registered", name)
+	}
+	return fn(ctx, payload)
+}
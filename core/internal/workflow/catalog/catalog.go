@@ -1,10 +1,47 @@
 package catalog
 
+import "encoding/json"
+
 type ActivityMetadata struct {
 	Name        string `json:"name"`
 	DisplayName string `json:"displayName"`
 	Description string `json:"description"`
 	Workflow    string `json:"workflow"`
+	// InputSchema is the JSON schema of the `with` arguments, OutputSchema the
+	// schema of the result the activity returns.
+	InputSchema  json.RawMessage `json:"inputSchema,omitempty"`
+	OutputSchema json.RawMessage `json:"outputSchema,omitempty"`
+	Executor     ExecutorSpec    `json:"executor"`
+}
+
+type ExecutorType string
+
+const (
+	ExecutorLambda  ExecutorType = "lambda"
+	ExecutorNative  ExecutorType = "native"
+	ExecutorCommand ExecutorType = "command"
+	ExecutorHTTP    ExecutorType = "http"
+)
+
+// ExecutorSpec tells the worker where an activity runs. Target is the lambda
+// function name, the registered native function, the command line or the URL,
+// depending on Type. Environment variables in Target are expanded by the worker.
+type ExecutorSpec struct {
+	Type   ExecutorType `json:"type"`
+	Target string       `json:"-"`
+}
+
+// GetExecutor returns the executor of the activity, an activity without one
+// runs as the lambda function named after it.
+func (a *ActivityMetadata) GetExecutor() ExecutorSpec {
+	spec := a.Executor
+	if spec.Type == "" {
+		spec.Type = ExecutorLambda
+	}
+	if spec.Target == "" {
+		spec.Target = a.Name
+	}
+	return spec
 }
 
 var ActivityCatalog = []*ActivityMetadata{
@@ -15,4 +52,16 @@ var ActivityCatalog = []*ActivityMetadata{
 	ImageAddWatermarkActivityV1_0,
 	ImageBlurActivityV1_0,
 	ImageResizeV1_0,
+	ImageConvertToJpegV1_0,
+	ImageConvertToBmpV1_0,
+}
+
+// GetActivity returns the catalog entry with the given name.
+func GetActivity(name string) (*ActivityMetadata, bool) {
+	for _, a := range ActivityCatalog {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return nil, false
 }
@@ -1,12 +1,17 @@
 package catalog
 
+import "encoding/json"
+
 var HTTP_V_01 = &ActivityMetadata{
 	Name:        "HTTP_V_01",
 	DisplayName: "Sends Http Request",
 	Description: `
 This Activity sends an HTTP request to a specified endpoint. 
 The request can include headers, parameters, and a body. 
-The response can be captured for further processing or analysis.
+The response status, headers and body are saved as status_code, response_headers
+and response_body for further processing or analysis. Requests can be retried on
+selected status codes and authenticated with basic, bearer or HMAC credentials
+stored as workspace secrets.
   `,
 	Workflow: `
 - activity:
@@ -17,6 +22,9 @@ The response can be captured for further processing or analysis.
       method: "POST"
       headers:
         Content-Type: "application/json"
+      timeout_seconds: 30
+      max_attempts: 3
+      retry_on_status: [429, 502, 503]
       body:
         name: "Apple MacBook Pro 16"
         data:
@@ -25,4 +33,53 @@ The response can be captured for further processing or analysis.
           CPU model: "Intel Core i9"
           Hard disk size: "1 TB"
 `,
+	InputSchema:  httpInputSchema,
+	OutputSchema: httpOutputSchema,
+	Executor:     ExecutorSpec{Type: ExecutorNative},
 }
+
+var httpInputSchema = json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "url": { "type": "string", "pattern": "^https?://" },
+    "method": { "type": "string", "enum": ["GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"] },
+    "headers": { "type": "object", "additionalProperties": { "type": "string" } },
+    "query": { "type": "object", "additionalProperties": { "type": ["string", "number", "boolean"] } },
+    "body": {},
+    "body_from_input": { "type": "boolean", "description": "Streams the uploaded file, or the output of the input activity, as the request body." },
+    "timeout_seconds": { "type": "number", "exclusiveMinimum": 0, "maximum": 300 },
+    "max_attempts": { "type": "integer", "minimum": 1, "maximum": 10 },
+    "retry_on_status": { "type": "array", "items": { "type": "integer", "minimum": 100, "maximum": 599 } },
+    "retry_backoff_seconds": { "type": "number", "exclusiveMinimum": 0, "maximum": 60 },
+    "auth": {
+      "type": "object",
+      "description": "Credentials are read from workspace secrets, the *_secret fields hold secret names.",
+      "properties": {
+        "type": { "type": "string", "enum": ["basic", "bearer", "hmac"] },
+        "username": { "type": "string" },
+        "password_secret": { "type": "string" },
+        "token_secret": { "type": "string" },
+        "secret": { "type": "string" },
+        "header": { "type": "string" },
+        "algorithm": { "type": "string", "enum": ["sha256", "sha512"] }
+      },
+      "required": ["type"],
+      "additionalProperties": false
+    }
+  },
+  "required": ["url"],
+  "additionalProperties": false
+}`)
+
+var httpOutputSchema = json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "status_code": { "type": "integer" },
+    "message": { "type": "string" },
+    "response_headers": { "type": "object" },
+    "response_body": {},
+    "attempts": { "type": "integer" },
+    "error": { "type": ["string", "null"] }
+  },
+  "required": ["status_code"]
+}`)
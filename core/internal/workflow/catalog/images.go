@@ -1,5 +1,7 @@
 package catalog
 
+import "encoding/json"
+
 var ImageResizeV1_0 = &ActivityMetadata{
 	Name:        "ImageResize@v1.0",
 	DisplayName: "Resize Image",
@@ -14,6 +16,9 @@ var ImageResizeV1_0 = &ActivityMetadata{
       width: 100
       height: 100
 `,
+	InputSchema:  imageResizeInputSchema,
+	OutputSchema: fileOutputSchema,
+	Executor:     ExecutorSpec{Type: ExecutorNative},
 }
 
 var ImageFormatConvertorV1 = &ActivityMetadata{
@@ -30,6 +35,8 @@ var ImageFormatConvertorV1 = &ActivityMetadata{
     with:
       format: "jpg"
 `,
+	InputSchema:  imageFormatConvertorInputSchema,
+	OutputSchema: fileOutputSchema,
 }
 
 var ImageConvertToJpegV1_0 = &ActivityMetadata{
@@ -43,6 +50,9 @@ var ImageConvertToJpegV1_0 = &ActivityMetadata{
     key: unique_alphanum_key
     uses: ImageConvertToJpeg@v1.0
 `,
+	InputSchema:  imageConvertToJpegInputSchema,
+	OutputSchema: fileOutputSchema,
+	Executor:     ExecutorSpec{Type: ExecutorNative},
 }
 
 var ImageConvertToBmpV1_0 = &ActivityMetadata{
@@ -56,6 +66,9 @@ var ImageConvertToBmpV1_0 = &ActivityMetadata{
     key: unique_alphanum_key
     uses: ImageConvertToBmp@v1.0
 `,
+	InputSchema:  noInputSchema,
+	OutputSchema: fileOutputSchema,
+	Executor:     ExecutorSpec{Type: ExecutorNative},
 }
 
 var ImageAddWatermarkActivityV1_0 = &ActivityMetadata{
@@ -73,6 +86,9 @@ var ImageAddWatermarkActivityV1_0 = &ActivityMetadata{
       text: "Watermark"
       opacity: 0.5
 `,
+	InputSchema:  imageAddWatermarkInputSchema,
+	OutputSchema: fileOutputSchema,
+	Executor:     ExecutorSpec{Type: ExecutorNative},
 }
 
 var ImageMetadataExtractionActivityV1_0 = &ActivityMetadata{
@@ -86,6 +102,8 @@ var ImageMetadataExtractionActivityV1_0 = &ActivityMetadata{
     key: unique_alphanum_key
     uses: ImageMetadataExtraction@v1.0
 `,
+	InputSchema:  noInputSchema,
+	OutputSchema: imageMetadataOutputSchema,
 }
 
 var ImageBlurActivityV1_0 = &ActivityMetadata{
@@ -101,4 +119,66 @@ var ImageBlurActivityV1_0 = &ActivityMetadata{
     with:
       radius: 2
 `,
+	InputSchema:  imageBlurInputSchema,
+	OutputSchema: fileOutputSchema,
+	Executor:     ExecutorSpec{Type: ExecutorNative},
 }
+
+var imageResizeInputSchema = json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "width": { "type": "integer", "minimum": 1, "maximum": 10000 },
+    "height": { "type": "integer", "minimum": 1, "maximum": 10000 },
+    "quality": { "type": "integer", "minimum": 1, "maximum": 100 }
+  },
+  "additionalProperties": false
+}`)
+
+var imageFormatConvertorInputSchema = json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "format": { "type": "string", "enum": ["png", "jpg", "jpeg", "webp", "bmp", "gif", "tiff"] }
+  },
+  "required": ["format"],
+  "additionalProperties": false
+}`)
+
+var imageConvertToJpegInputSchema = json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "quality": { "type": "integer", "minimum": 1, "maximum": 100 }
+  },
+  "additionalProperties": false
+}`)
+
+var imageAddWatermarkInputSchema = json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "text": { "type": "string", "minLength": 1 },
+    "size": { "type": "number", "exclusiveMinimum": 0, "maximum": 1000 },
+    "opacity": { "type": "number", "minimum": 0, "maximum": 1 },
+    "position": { "type": "string", "enum": ["top-left", "top-right", "bottom-left", "bottom-right", "center"] }
+  },
+  "required": ["text"],
+  "additionalProperties": false
+}`)
+
+var imageBlurInputSchema = json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "radius": { "type": "number", "exclusiveMinimum": 0, "maximum": 100 }
+  },
+  "additionalProperties": false
+}`)
+
+var imageMetadataOutputSchema = json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "status_code": { "type": "integer" },
+    "width": { "type": "integer" },
+    "height": { "type": "integer" },
+    "format": { "type": "string" },
+    "exif": { "type": "object" }
+  },
+  "required": ["status_code"]
+}`)
@@ -9,8 +9,10 @@ This activity is useful for extracting text from various document formats, such
 - activity:
     key: unique_alphanum_key
     uses: DetectDocumentTextV1
-    saveOutput: true
+    save_output: true
 `,
+	InputSchema:  noInputSchema,
+	OutputSchema: fileOutputSchema,
 }
 
 var ImageToTextV1 = &ActivityMetadata{
@@ -20,8 +22,10 @@ var ImageToTextV1 = &ActivityMetadata{
 This activity is useful for extracting text from various image formats, such as JPEG, PNG, and more.`,
 	Workflow: `
 - activity:
-	key: unique_alphanum_key
-	uses: ImageToTextV1
-	saveOutput: true
+    key: unique_alphanum_key
+    uses: ImageToTextV1
+    save_output: true
 `,
+	InputSchema:  noInputSchema,
+	OutputSchema: fileOutputSchema,
 }
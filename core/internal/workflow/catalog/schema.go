@@ -0,0 +1,108 @@
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// SchemaError is a single violation of an activity input or output schema.
+// Field is the dotted path inside the checked document, empty for the document itself.
+type SchemaError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+	// Property is set for missing required properties.
+	Property string `json:"-"`
+}
+
+func (e SchemaError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// fileOutputSchema describes the output of activities that write a single file.
+var fileOutputSchema = json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "status_code": { "type": "integer" },
+    "message": { "type": "string" },
+    "bucket": { "type": "string" },
+    "output_key": { "type": "string" },
+    "output_filename": { "type": "string" },
+    "output_content_type": { "type": "string" },
+    "error": { "type": ["string", "null"] }
+  },
+  "required": ["status_code"]
+}`)
+
+// noInputSchema is used by activities that do not take any arguments.
+var noInputSchema = json.RawMessage(`{
+  "type": "object",
+  "additionalProperties": false
+}`)
+
+var (
+	schemasMu sync.Mutex
+	schemas   = map[string]*gojsonschema.Schema{}
+)
+
+// CheckInput validates the `with` arguments of an invocation against the input schema.
+func (a *ActivityMetadata) CheckInput(args map[string]any) ([]SchemaError, error) {
+	if args == nil {
+		args = map[string]any{}
+	}
+	return check(a.Name+"#input", a.InputSchema, args)
+}
+
+// CheckOutput validates the result of an activity against the output schema.
+func (a *ActivityMetadata) CheckOutput(output map[string]any) ([]SchemaError, error) {
+	return check(a.Name+"#output", a.OutputSchema, output)
+}
+
+func check(cacheKey string, raw json.RawMessage, doc map[string]any) ([]SchemaError, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	schema, err := compile(cacheKey, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := schema.Validate(gojsonschema.NewGoLoader(doc))
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []SchemaError
+	for _, e := range result.Errors() {
+		se := SchemaError{Message: e.Description()}
+		if e.Field() != gojsonschema.STRING_ROOT_SCHEMA_PROPERTY {
+			se.Field = e.Field()
+		}
+		if e.Type() == "required" {
+			se.Property, _ = e.Details()["property"].(string)
+		}
+		errs = append(errs, se)
+	}
+	return errs, nil
+}
+
+func compile(cacheKey string, raw json.RawMessage) (*gojsonschema.Schema, error) {
+	schemasMu.Lock()
+	defer schemasMu.Unlock()
+
+	if s, ok := schemas[cacheKey]; ok {
+		return s, nil
+	}
+	s, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema for %s: %w", cacheKey, err)
+	}
+	schemas[cacheKey] = s
+	return s, nil
+}
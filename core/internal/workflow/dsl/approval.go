@@ -0,0 +1,141 @@
+package dsl
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+type ApprovalAction string
+
+const (
+	ApprovalApprove ApprovalAction = "approve"
+	ApprovalReject  ApprovalAction = "reject"
+)
+
+const (
+	// PendingApprovalsQuery returns the PendingApprovals of a run.
+	PendingApprovalsQuery = "pending_approvals"
+	approvalSignalPrefix  = "approval/"
+	approvalsCtxKey       = WorkflowCtxKey("approvals")
+
+	MaxApprovalTimeoutSeconds = MaxWaitSeconds
+	// TestRunApprover decides the approvals of test runs, they take the
+	// default action right away instead of waiting for a reviewer.
+	TestRunApprover = "test-run"
+)
+
+// ApprovalSignalName is the signal that decides the approval with the given key.
+func ApprovalSignalName(key string) string {
+	return approvalSignalPrefix + key
+}
+
+// ApprovalDecision is the payload of an approval signal.
+type ApprovalDecision struct {
+	Action     ApprovalAction `json:"action"`
+	ApproverID string         `json:"approverId"`
+	Approver   string         `json:"approver"`
+	Comment    string         `json:"comment,omitempty"`
+}
+
+// PendingApproval is an approval statement that is waiting for a decision.
+type PendingApproval struct {
+	Key           string         `json:"key"`
+	Message       string         `json:"message,omitempty"`
+	DefaultAction ApprovalAction `json:"defaultAction"`
+	RequestedAt   time.Time      `json:"requestedAt"`
+	ExpiresAt     time.Time      `json:"expiresAt"`
+}
+
+type pendingApprovals struct {
+	approvals []*PendingApproval
+}
+
+// withApprovals registers the query listing the pending approvals of the run.
+func withApprovals(ctx workflow.Context) (workflow.Context, error) {
+	pending := &pendingApprovals{}
+	err := workflow.SetQueryHandler(ctx, PendingApprovalsQuery, func() ([]*PendingApproval, error) {
+		return pending.approvals, nil
+	})
+	if err != nil {
+		return ctx, err
+	}
+	return workflow.WithValue(ctx, approvalsCtxKey, pending), nil
+}
+
+// execute waits for the approval signal or the timeout, whichever comes first,
+// and binds decision, approver, approver_id, comment, timed_out and decided_at
+// under the key of the statement. A rejection fails the workflow unless
+// continue_on_reject is set.
+func (a *Approval) execute(ctx workflow.Context, bindings map[string]any) error {
+	message, err := renderValue(a.Message, bindings)
+	if err != nil {
+		return fmt.Errorf("approval %s: message: %w", a.Key, err)
+	}
+	defaultAction := a.DefaultAction
+	if defaultAction == "" {
+		defaultAction = ApprovalReject
+	}
+
+	var decision ApprovalDecision
+	timedOut := false
+	if testRun, _ := bindings["test_run"].(bool); testRun {
+		decision = ApprovalDecision{Action: defaultAction, ApproverID: TestRunApprover, Approver: TestRunApprover}
+	} else {
+		pending := &PendingApproval{
+			Key:           a.Key,
+			Message:       stringify(message),
+			DefaultAction: defaultAction,
+			RequestedAt:   workflow.Now(ctx),
+		}
+		pending.ExpiresAt = pending.RequestedAt.Add(seconds(a.TimeoutSeconds))
+		registry, _ := ctx.Value(approvalsCtxKey).(*pendingApprovals)
+		if registry != nil {
+			registry.approvals = append(registry.approvals, pending)
+			defer func() {
+				registry.approvals = slices.DeleteFunc(registry.approvals, func(p *PendingApproval) bool { return p == pending })
+			}()
+		}
+
+		timerCtx, cancelTimer := workflow.WithCancel(ctx)
+		defer cancelTimer()
+		var timerErr error
+		selector := workflow.NewSelector(ctx)
+		selector.AddReceive(workflow.GetSignalChannel(ctx, ApprovalSignalName(a.Key)), func(c workflow.ReceiveChannel, more bool) {
+			c.Receive(ctx, &decision)
+		})
+		selector.AddFuture(workflow.NewTimer(timerCtx, seconds(a.TimeoutSeconds)), func(f workflow.Future) {
+			timerErr = f.Get(ctx, nil)
+			timedOut = true
+		})
+		selector.Select(ctx)
+		if timedOut {
+			// the timer also fires when the run is cancelled
+			if timerErr != nil {
+				return timerErr
+			}
+			decision = ApprovalDecision{Action: defaultAction}
+		}
+	}
+
+	result := "approved"
+	if decision.Action != ApprovalApprove {
+		result = "rejected"
+	}
+	bindings[fmt.Sprintf("%s.decision", a.Key)] = result
+	bindings[fmt.Sprintf("%s.approver", a.Key)] = decision.Approver
+	bindings[fmt.Sprintf("%s.approver_id", a.Key)] = decision.ApproverID
+	bindings[fmt.Sprintf("%s.comment", a.Key)] = decision.Comment
+	bindings[fmt.Sprintf("%s.timed_out", a.Key)] = timedOut
+	bindings[fmt.Sprintf("%s.decided_at", a.Key)] = workflow.Now(ctx).Format(time.RFC3339)
+
+	if result == "rejected" && !a.ContinueOnReject {
+		if timedOut {
+			return fmt.Errorf("approval %s timed out and was rejected", a.Key)
+		}
+		return fmt.Errorf("approval %s was rejected by %s", a.Key, decision.Approver)
+	}
+	return nil
+}
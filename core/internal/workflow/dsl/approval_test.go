@@ -0,0 +1,99 @@
+package dsl
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const moderationWorkflow = `
+variables: {}
+root:
+  sequence:
+    elements:
+      - approval:
+          key: review
+          message: Please review ${file_name}
+          timeout_seconds: 3600
+          default_action: %s
+      - activity:
+          key: publish
+          uses: HTTP_V_01
+          with:
+            url: https://example.com/publish
+            body: ${review.decision} by ${review.approver}
+`
+
+func TestApprovalWaitsForDecision(t *testing.T) {
+	wf := parseWorkflow(t, fmt.Sprintf(moderationWorkflow, "reject"))
+	wf.FileName = "cat.png"
+	require.NoError(t, wf.Validate())
+
+	exec := &fakeExecutor{}
+	env := newTestEnv(exec)
+	var pending []*PendingApproval
+	env.RegisterDelayedCallback(func() {
+		value, err := env.QueryWorkflow(PendingApprovalsQuery)
+		require.NoError(t, err)
+		require.NoError(t, value.Get(&pending))
+		env.SignalWorkflow(ApprovalSignalName("review"), ApprovalDecision{
+			Action: ApprovalApprove, ApproverID: "u1", Approver: "reviewer@example.com", Comment: "looks fine",
+		})
+	}, 10*time.Minute)
+
+	require.NoError(t, runInEnv(t, env, *wf))
+
+	require.Len(t, pending, 1)
+	assert.Equal(t, "review", pending[0].Key)
+	assert.Equal(t, "Please review cat.png", pending[0].Message)
+	assert.Equal(t, ApprovalReject, pending[0].DefaultAction)
+	assert.Equal(t, time.Hour, pending[0].ExpiresAt.Sub(pending[0].RequestedAt))
+
+	calls := exec.callsTo("HTTP_V_01")
+	require.Len(t, calls, 1)
+	assert.Equal(t, "approved by reviewer@example.com", calls[0]["publish.body"])
+	assert.Equal(t, "looks fine", calls[0]["review.comment"])
+	assert.Equal(t, false, calls[0]["review.timed_out"])
+}
+
+func TestApprovalTakesDefaultActionOnTimeout(t *testing.T) {
+	exec := &fakeExecutor{}
+	require.NoError(t, runDSLWorkflow(t, fmt.Sprintf(moderationWorkflow, "approve"), exec))
+
+	calls := exec.callsTo("HTTP_V_01")
+	require.Len(t, calls, 1)
+	assert.Equal(t, "approved", calls[0]["review.decision"])
+	assert.Equal(t, true, calls[0]["review.timed_out"])
+}
+
+func TestApprovalRejectionFailsWorkflow(t *testing.T) {
+	wf := parseWorkflow(t, fmt.Sprintf(moderationWorkflow, "approve"))
+	exec := &fakeExecutor{}
+	env := newTestEnv(exec)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(ApprovalSignalName("review"), ApprovalDecision{
+			Action: ApprovalReject, ApproverID: "u1", Approver: "reviewer@example.com",
+		})
+	}, time.Minute)
+
+	err := runInEnv(t, env, *wf)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "approval review was rejected by reviewer@example.com")
+	assert.Empty(t, exec.callsTo("HTTP_V_01"))
+}
+
+func TestValidateApproval(t *testing.T) {
+	var wf Workflow
+	wf.Root = Statement{Approval: &Approval{Key: "review", TimeoutSeconds: 7200, DefaultAction: "maybe"}}
+	wf.Settings = &ExecutionSettings{WorkflowRunTimeoutSeconds: 3600}
+
+	var errs ValidationErrors
+	require.ErrorAs(t, wf.Validate(), &errs)
+	assert.ElementsMatch(t, ValidationErrors{
+		{Path: "root.approval.timeout_seconds", Message: "must not be greater than the workflow run timeout of the processor (3600s)"},
+		{Path: "root.approval.default_action", Message: `must be "approve" or "reject"`},
+	}, errs)
+}
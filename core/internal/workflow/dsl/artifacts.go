@@ -0,0 +1,68 @@
+package dsl
+
+import (
+	"time"
+
+	"github.com/uploadpilot/core/internal/db/models"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// FinalizeArtifactsActivityName is the activity that turns the saved outputs of
+// a run into its artifacts once the workflow completed.
+const FinalizeArtifactsActivityName = "FinalizeArtifacts"
+
+// FinalizeArtifactsRequest is the input of the FinalizeArtifacts activity.
+type FinalizeArtifactsRequest struct {
+	WorkspaceID       string              `json:"workspaceId"`
+	UploadID          string              `json:"uploadId"`
+	ProcessorID       string              `json:"processorId"`
+	RunID             string              `json:"runId"`
+	Mode              models.ArtifactMode `json:"mode"`
+	DestinationPrefix string              `json:"destinationPrefix,omitempty"`
+	DeriveUploads     bool                `json:"deriveUploads,omitempty"`
+}
+
+// finalizeArtifacts runs the FinalizeArtifacts activity with the artifact
+// settings of the processor. It runs on a disconnected context so that the
+// outputs of cancelled runs are kept too. The manifest is nil when the
+// processor skips the finalization and derives no uploads.
+func (dslWorkflow Workflow) finalizeArtifacts(ctx workflow.Context) (*models.ArtifactManifest, error) {
+	req := FinalizeArtifactsRequest{
+		WorkspaceID: dslWorkflow.WorkspaceID,
+		UploadID:    dslWorkflow.UploadID,
+		ProcessorID: dslWorkflow.ProcessorID,
+		RunID:       scopeFromContext(ctx).RunID,
+		Mode:        models.ArtifactModeZip,
+	}
+	timeout := defaultActivityTimeout
+	if settings := dslWorkflow.Settings; settings != nil {
+		if settings.ArtifactMode != "" {
+			req.Mode = settings.ArtifactMode
+		}
+		req.DestinationPrefix = settings.ArtifactDestinationPrefix
+		req.DeriveUploads = settings.DeriveUploads
+		if settings.ActivityTimeoutSeconds > 0 {
+			timeout = seconds(settings.ActivityTimeoutSeconds)
+		}
+	}
+	if req.Mode == models.ArtifactModeSkip && !req.DeriveUploads {
+		return nil, nil
+	}
+
+	ctx, _ = workflow.NewDisconnectedContext(ctx)
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: timeout,
+		RetryPolicy: &temporal.RetryPolicy{
+			MaximumAttempts:    5,
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2,
+			MaximumInterval:    time.Minute,
+		},
+	})
+	var manifest models.ArtifactManifest
+	if err := workflow.ExecuteActivity(ctx, FinalizeArtifactsActivityName, req).Get(ctx, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
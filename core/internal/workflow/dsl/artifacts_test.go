@@ -0,0 +1,74 @@
+package dsl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uploadpilot/core/internal/db/models"
+)
+
+const convertWorkflow = `
+variables: {}
+root:
+  activity:
+    key: convert
+    uses: ImageFormatConvertorV1
+    save_output: true
+    with:
+      format: png
+`
+
+func TestRunFinalizesArtifacts(t *testing.T) {
+	wf := parseWorkflow(t, convertWorkflow)
+	wf.UploadID = "up"
+	wf.Settings = &ExecutionSettings{ArtifactMode: models.ArtifactModeCopy, ArtifactDestinationPrefix: "exports"}
+	exec := &fakeExecutor{}
+	require.NoError(t, runParsedWorkflow(t, *wf, exec))
+
+	require.Len(t, exec.finalized, 1)
+	assert.Equal(t, models.ArtifactModeCopy, exec.finalized[0].Mode)
+	assert.Equal(t, "exports", exec.finalized[0].DestinationPrefix)
+	require.Len(t, exec.runs, 2)
+	assert.Equal(t, exec.runs[1].ArtifactKey, exec.runs[1].Artifacts.Key)
+	assert.Equal(t, models.ArtifactModeCopy, exec.runs[1].Artifacts.Mode)
+}
+
+func TestRunSkipsArtifacts(t *testing.T) {
+	wf := parseWorkflow(t, convertWorkflow)
+	wf.Settings = &ExecutionSettings{ArtifactMode: models.ArtifactModeSkip}
+	exec := &fakeExecutor{}
+	require.NoError(t, runParsedWorkflow(t, *wf, exec))
+
+	assert.Empty(t, exec.finalized)
+	require.Len(t, exec.runs, 2)
+	assert.Empty(t, exec.runs[1].ArtifactKey)
+	assert.Nil(t, exec.runs[1].Artifacts)
+
+	// deriving uploads finalizes the run, which leaves the outputs in place
+	wf.Settings.DeriveUploads = true
+	exec = &fakeExecutor{}
+	require.NoError(t, runParsedWorkflow(t, *wf, exec))
+	require.Len(t, exec.finalized, 1)
+	assert.Equal(t, models.ArtifactModeSkip, exec.finalized[0].Mode)
+	assert.True(t, exec.finalized[0].DeriveUploads)
+}
+
+func TestValidateArtifactSettings(t *testing.T) {
+	settings := &ExecutionSettings{
+		MaxRetries: 1, RetryInitialIntervalSeconds: 1, RetryBackoffCoefficient: 2, RetryMaxIntervalSeconds: 10,
+		ActivityTimeoutSeconds: 60, WorkflowRunTimeoutSeconds: 60, WorkflowExecutionTimeoutSeconds: 60,
+		ArtifactMode: models.ArtifactModeCopy, ArtifactDestinationPrefix: "exports/../other",
+	}
+	var errs ValidationErrors
+	require.ErrorAs(t, settings.Validate(), &errs)
+	assert.Equal(t, ValidationErrors{
+		{Path: "artifactDestinationPrefix", Message: "must only contain letters, digits, '-', '_', '.' and '/'"},
+	}, errs)
+
+	settings.ArtifactDestinationPrefix = ""
+	require.ErrorAs(t, settings.Validate(), &errs)
+	assert.Equal(t, ValidationErrors{
+		{Path: "artifactDestinationPrefix", Message: `is required with the "copy" artifact mode`},
+	}, errs)
+}
@@ -0,0 +1,227 @@
+package dsl
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+const callsCtxKey = WorkflowCtxKey("calls")
+
+// CallTarget is the workflow a call statement runs, the workflow of a
+// processor or a snippet of the workspace.
+type CallTarget struct {
+	Processor string
+	Snippet   string
+}
+
+func (t CallTarget) String() string {
+	if t.Processor != "" {
+		return "processor:" + t.Processor
+	}
+	return "snippet:" + t.Snippet
+}
+
+// WorkflowLoader returns the workflow of a call target.
+type WorkflowLoader func(target CallTarget) (*Workflow, error)
+
+// CallRequest is the input of CallDSLWorkflow. Bindings are the identifiers of
+// the calling run and the rendered inputs of the call.
+type CallRequest struct {
+	Workflow Workflow       `json:"workflow"`
+	Bindings map[string]any `json:"bindings"`
+	Outputs  []string       `json:"outputs"`
+	Scope    ActivityScope  `json:"scope"`
+}
+
+// CallResult holds the declared outputs of a call, and the activities it ran
+// and the hooks it skipped when the call is part of a test run.
+type CallResult struct {
+	Outputs      map[string]any   `json:"outputs"`
+	Activities   []*ActivityTrace `json:"activities,omitempty"`
+	SkippedHooks []string         `json:"skippedHooks,omitempty"`
+}
+
+func (c *Call) Target() CallTarget {
+	return CallTarget{Processor: c.Processor, Snippet: c.Snippet}
+}
+
+// ResolveCalls loads the workflows called by w, and the workflows they call in
+// turn, into w.Calls so that a run does not depend on workflows saved after it
+// started. self is the target of w itself, a call leading back to a workflow
+// that is already being called is reported as a cycle.
+func (w *Workflow) ResolveCalls(self CallTarget, load WorkflowLoader) error {
+	calls := map[string]*Workflow{}
+	if err := w.resolveCalls(calls, []string{self.String()}, load); err != nil {
+		return err
+	}
+	w.Calls = nil
+	if len(calls) > 0 {
+		w.Calls = calls
+	}
+	return nil
+}
+
+func (w *Workflow) resolveCalls(calls map[string]*Workflow, stack []string, load WorkflowLoader) error {
+	var errs []error
+	w.walk(func(path string, stmt *Statement) {
+		if stmt.Call == nil {
+			return
+		}
+		target := stmt.Call.Target()
+		name := target.String()
+		if i := slices.Index(stack, name); i >= 0 {
+			cycle := append(slices.Clone(stack[i:]), name)
+			errs = append(errs, fmt.Errorf("%s.call: calls form a cycle: %s", path, strings.Join(cycle, " -> ")))
+			return
+		}
+
+		callee, ok := calls[name]
+		if !ok {
+			loaded, err := load(target)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s.call: %w", path, err))
+				return
+			}
+			callee = loaded
+			calls[name] = callee
+			if approval := callee.findApproval(); approval != "" {
+				errs = append(errs, fmt.Errorf("%s.call: %s waits for an approval at %s, approvals can only be used in the workflow of the run", path, name, approval))
+				return
+			}
+			if err := callee.resolveCalls(calls, append(slices.Clone(stack), name), load); err != nil {
+				errs = append(errs, fmt.Errorf("%s.call: %s: %w", path, name, err))
+				return
+			}
+		}
+
+		for _, arg := range slices.Sorted(maps.Keys(stmt.Call.With)) {
+			if _, ok := callee.Variables[arg]; !ok {
+				errs = append(errs, fmt.Errorf("%s.call.with.%s: %s has no variable %q", path, arg, name, arg))
+			}
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// findApproval returns the path of the first approval statement of w, approvals
+// are decided through the run and cannot be reached inside a child workflow.
+func (w *Workflow) findApproval() string {
+	found := ""
+	w.walk(func(path string, stmt *Statement) {
+		if stmt.Approval != nil && found == "" {
+			found = path
+		}
+	})
+	return found
+}
+
+// execute runs the called workflow as a child workflow. The child runs with
+// the identifiers of the calling run so that its outputs are stored and post
+// processed with the outputs of the caller, the run_id is suffixed with the
+// call key so the activity keys of both workflows cannot collide.
+func (c *Call) execute(ctx workflow.Context, bindings map[string]any) error {
+	calls, _ := ctx.Value(callsCtxKey).(map[string]*Workflow)
+	callee, ok := calls[c.Target().String()]
+	if !ok {
+		return fmt.Errorf("call %s: %s was not resolved before the run", c.Key, c.Target())
+	}
+
+	req := CallRequest{
+		Workflow: *callee,
+		Bindings: make(map[string]any, len(c.With)+len(workflowIdentifiers)),
+		Outputs:  c.Outputs,
+	}
+	req.Workflow.Calls = calls
+	req.Workflow.Settings = settingsFromContext(ctx)
+	req.Workflow.TestRun, _ = bindings["test_run"].(bool)
+
+	for argument, value := range c.With {
+		rendered, err := renderValue(value, bindings)
+		if err != nil {
+			return fmt.Errorf("call %s: argument %s: %w", c.Key, argument, err)
+		}
+		req.Bindings[argument] = rendered
+	}
+	for _, id := range workflowIdentifiers {
+		if value, ok := bindings[id]; ok && id != "current_activity_key" && id != "workflow_error" {
+			req.Bindings[id] = value
+		}
+	}
+	if req.Workflow.TestRun {
+		req.Bindings["test_run"] = true
+	}
+	req.Scope = scopeFromContext(ctx)
+	req.Scope.RunID = fmt.Sprintf("%s/%s", req.Scope.RunID, c.Key)
+	req.Bindings["run_id"] = req.Scope.RunID
+
+	var result CallResult
+	err := workflow.ExecuteChildWorkflow(ctx, CallDSLWorkflow, req).Get(ctx, &result)
+	if trace, ok := ctx.Value(traceCtxKey).(*TestRunResult); ok {
+		for _, a := range result.Activities {
+			a.Key = c.Key + "/" + a.Key
+			trace.Activities = append(trace.Activities, a)
+		}
+		for _, hook := range result.SkippedHooks {
+			trace.SkippedHooks = append(trace.SkippedHooks, c.Key+"/"+hook)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("call %s failed: %w", c.Key, err)
+	}
+
+	for name, value := range result.Outputs {
+		bindings[fmt.Sprintf("%s.%s", c.Key, name)] = value
+	}
+	return nil
+}
+
+// CallDSLWorkflow runs a called workflow and returns its declared outputs. It
+// is not recorded as a run and does not post process, the caller does both.
+func CallDSLWorkflow(ctx workflow.Context, req CallRequest) (*CallResult, error) {
+	callee := req.Workflow
+	ctx = withSettings(ctx, callee.Settings)
+	ctx = workflow.WithValue(ctx, callsCtxKey, callee.Calls)
+	ctx = withScope(ctx, req.Scope)
+
+	var trace *TestRunResult
+	if callee.TestRun {
+		trace = &TestRunResult{Activities: []*ActivityTrace{}}
+		ctx = workflow.WithValue(ctx, traceCtxKey, trace)
+	}
+
+	if err := callee.checkReferences(); err != nil {
+		return nil, err
+	}
+
+	bindings := make(map[string]any)
+	maps.Copy(bindings, callee.Variables)
+	maps.Copy(bindings, req.Bindings)
+
+	rootCtx, compensations := withCompensations(ctx)
+	err := callee.Root.execute(rootCtx, bindings)
+	if err != nil {
+		err = compensations.run(ctx, err)
+	}
+	if err = callee.complete(ctx, bindings, err); err != nil {
+		return nil, err
+	}
+
+	result := &CallResult{Outputs: make(map[string]any, len(req.Outputs))}
+	if trace != nil {
+		result.Activities = trace.Activities
+		result.SkippedHooks = trace.SkippedHooks
+	}
+	for _, name := range req.Outputs {
+		value, ok := bindings[name]
+		if !ok {
+			return nil, fmt.Errorf("output %q was not bound by the called workflow", name)
+		}
+		result.Outputs[name] = value
+	}
+	return result, nil
+}
@@ -0,0 +1,177 @@
+package dsl
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func parseWorkflow(t *testing.T, source string) *Workflow {
+	t.Helper()
+	var wf Workflow
+	require.NoError(t, yaml.Unmarshal([]byte(source), &wf))
+	return &wf
+}
+
+// mapLoader loads the call targets from YAML sources keyed by target name.
+func mapLoader(t *testing.T, sources map[string]string) WorkflowLoader {
+	return func(target CallTarget) (*Workflow, error) {
+		source, ok := sources[target.String()]
+		if !ok {
+			return nil, fmt.Errorf("%s not found", target)
+		}
+		return parseWorkflow(t, source), nil
+	}
+}
+
+const thumbnailSnippet = `
+variables:
+  url: https://example.com/default
+root:
+  activity:
+    key: thumb
+    uses: HTTP_V_01
+    with:
+      url: ${url}
+`
+
+func TestCallMergesDeclaredOutputs(t *testing.T) {
+	wf := parseWorkflow(t, `
+variables: {}
+root:
+  sequence:
+    elements:
+      - call:
+          key: tail
+          snippet: thumbnail
+          with:
+            url: https://example.com/${upload_id}
+          outputs: [thumb.status_code]
+      - activity:
+          key: notify
+          uses: HTTP_V_01
+          with:
+            url: https://example.com/notify
+            body: ${tail.thumb.status_code}
+`)
+	wf.UploadID = "u1"
+	require.NoError(t, wf.Validate())
+	require.NoError(t, wf.ResolveCalls(CallTarget{Processor: "p1"}, mapLoader(t, map[string]string{
+		"snippet:thumbnail": thumbnailSnippet,
+	})))
+
+	exec := &fakeExecutor{}
+	require.NoError(t, runParsedWorkflow(t, *wf, exec))
+
+	calls := exec.callsTo("HTTP_V_01")
+	require.Len(t, calls, 2)
+	assert.Equal(t, "thumb", calls[0]["current_activity_key"])
+	assert.Equal(t, "https://example.com/u1", calls[0]["thumb.url"])
+	assert.Equal(t, "u1", calls[0]["upload_id"])
+	assert.Regexp(t, `/tail$`, calls[0]["run_id"])
+	// the child runs in the scope of the caller
+	scope := calls[0]["__scope"].(ActivityScope)
+	assert.Equal(t, "u1", scope.UploadID)
+	assert.Equal(t, "thumb", scope.ActivityKey)
+	assert.Equal(t, calls[0]["run_id"], scope.RunID)
+	assert.Equal(t, float64(200), calls[1]["notify.body"])
+}
+
+func TestCallFailsWhenOutputIsNotBound(t *testing.T) {
+	wf := parseWorkflow(t, `
+variables: {}
+root:
+  call:
+    key: tail
+    snippet: thumbnail
+    outputs: [resize.output_key]
+`)
+	require.NoError(t, wf.ResolveCalls(CallTarget{Processor: "p1"}, mapLoader(t, map[string]string{
+		"snippet:thumbnail": thumbnailSnippet,
+	})))
+
+	err := runParsedWorkflow(t, *wf, &fakeExecutor{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `output "resize.output_key" was not bound`)
+}
+
+func TestResolveCallsDetectsCycles(t *testing.T) {
+	callSnippet := func(name string) string {
+		return fmt.Sprintf("variables: {}\nroot:\n  call:\n    key: next\n    snippet: %s\n", name)
+	}
+	loader := mapLoader(t, map[string]string{
+		"snippet:a":         callSnippet("b"),
+		"snippet:b":         callSnippet("a"),
+		"snippet:c":         callSnippet("thumbnail"),
+		"snippet:thumbnail": thumbnailSnippet,
+		"processor:p1":      "variables: {}\nroot:\n  call:\n    key: back\n    processor: p2\n",
+		"processor:p2":      "variables: {}\nroot:\n  call:\n    key: back\n    processor: p1\n",
+	})
+
+	err := parseWorkflow(t, callSnippet("b")).ResolveCalls(CallTarget{Snippet: "a"}, loader)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "snippet:a -> snippet:b -> snippet:a")
+
+	err = parseWorkflow(t, "variables: {}\nroot:\n  call:\n    key: tail\n    processor: p2\n").
+		ResolveCalls(CallTarget{Processor: "p1"}, loader)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "processor:p1 -> processor:p2 -> processor:p1")
+
+	// the same snippet called twice is not a cycle
+	wf := parseWorkflow(t, `
+variables: {}
+root:
+  sequence:
+    elements:
+      - call: {key: first, snippet: c}
+      - call: {key: second, snippet: thumbnail}
+`)
+	require.NoError(t, wf.ResolveCalls(CallTarget{Processor: "p1"}, loader))
+	assert.Len(t, wf.Calls, 2)
+}
+
+func TestResolveCallsRejectsUnknownInputs(t *testing.T) {
+	wf := parseWorkflow(t, `
+variables: {}
+root:
+  call:
+    key: tail
+    snippet: thumbnail
+    with:
+      size: 10
+`)
+	err := wf.ResolveCalls(CallTarget{Processor: "p1"}, mapLoader(t, map[string]string{
+		"snippet:thumbnail": thumbnailSnippet,
+	}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `root.call.with.size: snippet:thumbnail has no variable "size"`)
+}
+
+func TestValidateCall(t *testing.T) {
+	errs := validate(t, `
+variables: {}
+root:
+  sequence:
+    elements:
+      - call:
+          key: tail
+          processor: p2
+          snippet: thumbnail
+          with:
+            url: ${missing}
+          outputs: ["thumb..key"]
+      - activity:
+          key: notify
+          uses: HTTP_V_01
+          with:
+            url: ${tail.thumb.output_key}
+`)
+	assert.ElementsMatch(t, ValidationErrors{
+		{Path: "root.sequence.elements[0].call", Message: "call must define exactly one of processor or snippet"},
+		{Path: "root.sequence.elements[0].call.with.url", Message: `reference "missing" is not defined at this point of the workflow`},
+		{Path: "root.sequence.elements[0].call.outputs[0]", Message: `invalid binding name "thumb..key"`},
+	}, errs)
+}
@@ -0,0 +1,105 @@
+package dsl
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+const compensationsCtxKey = WorkflowCtxKey("compensations")
+
+// compensations is the stack of compensate statements registered by the
+// activities that completed in a scope, the root statement of a workflow or
+// the body of a try statement.
+type compensations struct {
+	steps []*compensation
+}
+
+type compensation struct {
+	key      string
+	stmt     *Statement
+	bindings map[string]any
+}
+
+// withCompensations opens a new compensation scope.
+func withCompensations(ctx workflow.Context) (workflow.Context, *compensations) {
+	scope := &compensations{}
+	return workflow.WithValue(ctx, compensationsCtxKey, scope), scope
+}
+
+// registerCompensation pushes the compensate statement of a completed activity.
+// It keeps the bindings the activity ran with, so that a compensation inside a
+// parallel branch or foreach item sees the outputs of that branch.
+func registerCompensation(ctx workflow.Context, a *ActivityInvocation, bindings map[string]any) {
+	if a.Compensate == nil {
+		return
+	}
+	if scope, _ := ctx.Value(compensationsCtxKey).(*compensations); scope != nil {
+		scope.steps = append(scope.steps, &compensation{key: a.Key, stmt: a.Compensate, bindings: bindings})
+	}
+}
+
+// handOver moves the compensations of a scope that succeeded to the enclosing
+// scope, so they still run when a later statement fails.
+func (c *compensations) handOver(ctx workflow.Context) {
+	if parent, _ := ctx.Value(compensationsCtxKey).(*compensations); parent != nil {
+		parent.steps = append(parent.steps, c.steps...)
+	}
+	c.steps = nil
+}
+
+// run executes the registered compensations in reverse order after cause made
+// the scope fail. A failing compensation does not stop the others, its error
+// is added to cause. The compensations also run when the run was cancelled.
+func (c *compensations) run(ctx workflow.Context, cause error) error {
+	if len(c.steps) == 0 {
+		return cause
+	}
+	if ctx.Err() != nil {
+		ctx, _ = workflow.NewDisconnectedContext(ctx)
+	}
+	// activities of a compensate statement cannot be compensated themselves
+	ctx, _ = withCompensations(ctx)
+
+	logger := workflow.GetLogger(ctx)
+	errs := []error{cause}
+	for _, step := range slices.Backward(c.steps) {
+		logger.Info("Compensating activity.", "Key", step.key)
+		if err := step.stmt.execute(ctx, step.bindings); err != nil {
+			errs = append(errs, fmt.Errorf("compensation of %s failed: %w", step.key, err))
+		}
+	}
+	c.steps = nil
+	return errors.Join(errs...)
+}
+
+const (
+	ErrorTypeActivity = "activity"
+	ErrorTypeTimeout  = "timeout"
+	ErrorTypeCanceled = "canceled"
+	ErrorTypeCall     = "call"
+	ErrorTypeWorkflow = "workflow"
+)
+
+// errorType classifies err for the catch block of a try statement.
+func errorType(err error) string {
+	var (
+		timeoutErr  *temporal.TimeoutError
+		activityErr *temporal.ActivityError
+		childErr    *temporal.ChildWorkflowExecutionError
+	)
+	switch {
+	case temporal.IsCanceledError(err):
+		return ErrorTypeCanceled
+	case errors.As(err, &timeoutErr):
+		return ErrorTypeTimeout
+	case errors.As(err, &activityErr):
+		return ErrorTypeActivity
+	case errors.As(err, &childErr):
+		return ErrorTypeCall
+	}
+	return ErrorTypeWorkflow
+}
@@ -0,0 +1,189 @@
+package dsl
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+type ConditionOperator string
+
+const (
+	OpEquals      ConditionOperator = "=="
+	OpNotEquals   ConditionOperator = "!="
+	OpLessThan    ConditionOperator = "<"
+	OpLessOrEq    ConditionOperator = "<="
+	OpGreaterThan ConditionOperator = ">"
+	OpGreaterOrEq ConditionOperator = ">="
+	OpIn          ConditionOperator = "in"
+	OpNotIn       ConditionOperator = "not_in"
+	OpContains    ConditionOperator = "contains"
+	OpExists      ConditionOperator = "exists"
+	OpNotExists   ConditionOperator = "not_exists"
+	OpMatches     ConditionOperator = "matches"
+)
+
+var ConditionOperators = []ConditionOperator{
+	OpEquals, OpNotEquals, OpLessThan, OpLessOrEq, OpGreaterThan, OpGreaterOrEq,
+	OpIn, OpNotIn, OpContains, OpExists, OpNotExists, OpMatches,
+}
+
+func (c *Condition) execute(ctx workflow.Context, bindings map[string]any) error {
+	ok, err := c.evaluate(bindings)
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		return c.Then.execute(ctx, bindings)
+	}
+	if c.Else != nil {
+		return c.Else.execute(ctx, bindings)
+	}
+	return nil
+}
+
+// evaluate checks the condition against the bindings. A missing variable is only
+// allowed for the exists/not_exists operators, every other operator fails so that
+// typos in the variable name do not silently take the else branch.
+func (c *Condition) evaluate(bindings map[string]any) (bool, error) {
+	op := c.Operator
+	if op == "" {
+		op = OpEquals
+	}
+
+	actual, found := lookupVariable(bindings, c.Variable)
+	found = found && actual != nil
+
+	switch op {
+	case OpExists:
+		return found, nil
+	case OpNotExists:
+		return !found, nil
+	}
+
+	if !found {
+		return false, fmt.Errorf("condition variable %q is not defined", c.Variable)
+	}
+
+	switch op {
+	case OpEquals:
+		return valuesEqual(actual, c.Value), nil
+	case OpNotEquals:
+		return !valuesEqual(actual, c.Value), nil
+	case OpLessThan, OpLessOrEq, OpGreaterThan, OpGreaterOrEq:
+		cmp := compareValues(actual, c.Value)
+		switch op {
+		case OpLessThan:
+			return cmp < 0, nil
+		case OpLessOrEq:
+			return cmp <= 0, nil
+		case OpGreaterThan:
+			return cmp > 0, nil
+		default:
+			return cmp >= 0, nil
+		}
+	case OpIn, OpNotIn:
+		list, ok := asList(c.Value)
+		if !ok {
+			return false, fmt.Errorf("condition on %q: value for operator %q must be a list", c.Variable, op)
+		}
+		in := false
+		for _, item := range list {
+			if valuesEqual(actual, item) {
+				in = true
+				break
+			}
+		}
+		return in == (op == OpIn), nil
+	case OpContains:
+		if list, ok := asList(actual); ok {
+			for _, item := range list {
+				if valuesEqual(item, c.Value) {
+					return true, nil
+				}
+			}
+			return false, nil
+		}
+		return strings.Contains(fmt.Sprint(actual), fmt.Sprint(c.Value)), nil
+	case OpMatches:
+		pattern, ok := c.Value.(string)
+		if !ok {
+			return false, fmt.Errorf("condition on %q: value for operator %q must be a regular expression", c.Variable, op)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return false, fmt.Errorf("condition on %q: invalid regular expression: %w", c.Variable, err)
+		}
+		return re.MatchString(fmt.Sprint(actual)), nil
+	}
+
+	return false, fmt.Errorf("condition on %q: unsupported operator %q", c.Variable, op)
+}
+
+// valuesEqual compares numerically when both sides look like numbers and falls
+// back to comparing the string representations otherwise.
+func valuesEqual(a, b any) bool {
+	af, aok := toFloat(a)
+	bf, bok := toFloat(b)
+	if aok && bok {
+		return af == bf
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+func compareValues(a, b any) int {
+	af, aok := toFloat(a)
+	bf, bok := toFloat(b)
+	if aok && bok {
+		switch {
+		case af < bf:
+			return -1
+		case af > bf:
+			return 1
+		default:
+			return 0
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func asList(v any) ([]any, bool) {
+	if list, ok := v.([]any); ok {
+		return list, true
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	list := make([]any, rv.Len())
+	for i := range list {
+		list[i] = rv.Index(i).Interface()
+	}
+	return list, true
+}
@@ -0,0 +1,59 @@
+package dsl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConditionEvaluate(t *testing.T) {
+	bindings := map[string]any{
+		"ocr.confidence":   0.82,
+		"file_size":        float64(2048),
+		"content_type":     "image/png",
+		"ocr.labels":       []any{"invoice", "receipt"},
+		"http.status_code": "200",
+		"ocr.result":       map[string]any{"confidence": 0.95, "pages": []any{map[string]any{"score": 3}}},
+	}
+
+	testCases := []struct {
+		name      string
+		condition Condition
+		expected  bool
+		expectErr bool
+	}{
+		{name: "default operator is equality", condition: Condition{Variable: "content_type", Value: "image/png"}, expected: true},
+		{name: "numeric equality across types", condition: Condition{Variable: "http.status_code", Operator: OpEquals, Value: 200}, expected: true},
+		{name: "not equals", condition: Condition{Variable: "content_type", Operator: OpNotEquals, Value: "image/jpeg"}, expected: true},
+		{name: "less than", condition: Condition{Variable: "ocr.confidence", Operator: OpLessThan, Value: 0.9}, expected: true},
+		{name: "greater or equal with string value", condition: Condition{Variable: "file_size", Operator: OpGreaterOrEq, Value: "2048"}, expected: true},
+		{name: "greater than false", condition: Condition{Variable: "file_size", Operator: OpGreaterThan, Value: 4096}, expected: false},
+		{name: "in list", condition: Condition{Variable: "content_type", Operator: OpIn, Value: []any{"image/png", "image/jpeg"}}, expected: true},
+		{name: "not in list", condition: Condition{Variable: "content_type", Operator: OpNotIn, Value: []any{"application/pdf"}}, expected: true},
+		{name: "in requires list", condition: Condition{Variable: "content_type", Operator: OpIn, Value: "image/png"}, expectErr: true},
+		{name: "contains list element", condition: Condition{Variable: "ocr.labels", Operator: OpContains, Value: "invoice"}, expected: true},
+		{name: "contains substring", condition: Condition{Variable: "content_type", Operator: OpContains, Value: "image/"}, expected: true},
+		{name: "exists", condition: Condition{Variable: "ocr.confidence", Operator: OpExists}, expected: true},
+		{name: "exists on missing variable", condition: Condition{Variable: "missing", Operator: OpExists}, expected: false},
+		{name: "not exists", condition: Condition{Variable: "missing", Operator: OpNotExists}, expected: true},
+		{name: "regex match", condition: Condition{Variable: "content_type", Operator: OpMatches, Value: "^image/(png|jpe?g)$"}, expected: true},
+		{name: "invalid regex", condition: Condition{Variable: "content_type", Operator: OpMatches, Value: "("}, expectErr: true},
+		{name: "missing variable", condition: Condition{Variable: "missing", Value: "x"}, expectErr: true},
+		{name: "nested output field", condition: Condition{Variable: "ocr.result.confidence", Operator: OpGreaterThan, Value: 0.9}, expected: true},
+		{name: "indexed output field", condition: Condition{Variable: "ocr.result.pages[0].score", Value: 3}, expected: true},
+		{name: "missing nested field", condition: Condition{Variable: "ocr.result.missing", Operator: OpExists}, expected: false},
+		{name: "unknown operator", condition: Condition{Variable: "content_type", Operator: "~=", Value: "x"}, expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := tc.condition.evaluate(bindings)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
@@ -3,34 +3,51 @@ package dsl
 import (
 	"encoding/json"
 	"fmt"
-	"time"
 
 	"maps"
 
 	"github.com/phuslu/log"
+	"github.com/uploadpilot/core/internal/db/models"
 	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
 )
 
+// ExecutorActivityName is the activity that runs every DSL activity. The worker
+// decides per activity which backend executes it.
+const ExecutorActivityName = "Executor"
+
 type (
 	WorkflowCtxKey string
 
 	Workflow struct {
-		WorkspaceID       string         `json:"workspaceId"`
-		UploadID          string         `json:"uploadId"`
-		ProcessorID       string         `json:"processorId"`
-		FileName          string         `json:"fileName"`
-		ContentType       string         `json:"contentType"`
-		Variables         map[string]any `json:"variables" yaml:"variables"`
-		Root              Statement      `json:"root" yaml:"root"`
-		OnWorkflowSuccess *Statement     `json:"on_workflow_success" yaml:"on_workflow_success"`
-		OnWorkflowFailure *Statement     `json:"on_workflow_failure" yaml:"on_workflow_failure"`
+		WorkspaceID       string               `json:"workspaceId"`
+		UploadID          string               `json:"uploadId"`
+		ProcessorID       string               `json:"processorId"`
+		FileName          string               `json:"fileName"`
+		ContentType       string               `json:"contentType"`
+		Attempt           int                  `json:"attempt"`
+		WorkflowVersion   int                  `json:"workflowVersion"`
+		Settings          *ExecutionSettings   `json:"settings,omitempty" yaml:"-"`
+		TestRun           bool                 `json:"testRun,omitempty" yaml:"-"`
+		Calls             map[string]*Workflow `json:"calls,omitempty" yaml:"-"`
+		Variables         map[string]any       `json:"variables" yaml:"variables"`
+		Root              Statement            `json:"root" yaml:"root"`
+		OnWorkflowSuccess *Statement           `json:"on_workflow_success" yaml:"on_workflow_success"`
+		OnWorkflowFailure *Statement           `json:"on_workflow_failure" yaml:"on_workflow_failure"`
 	}
 
 	Statement struct {
-		Activity *ActivityInvocation `json:"activity,omitempty" yaml:"activity,omitempty"`
-		Sequence *Sequence           `json:"sequence,omitempty" yaml:"sequence,omitempty"`
-		Parallel *Parallel           `json:"parallel,omitempty" yaml:"parallel,omitempty"`
+		Activity  *ActivityInvocation `json:"activity,omitempty" yaml:"activity,omitempty"`
+		Sequence  *Sequence           `json:"sequence,omitempty" yaml:"sequence,omitempty"`
+		Parallel  *Parallel           `json:"parallel,omitempty" yaml:"parallel,omitempty"`
+		Condition *Condition          `json:"condition,omitempty" yaml:"condition,omitempty"`
+		Loop      *Loop               `json:"loop,omitempty" yaml:"loop,omitempty"`
+		ForEach   *ForEach            `json:"foreach,omitempty" yaml:"foreach,omitempty"`
+		Call      *Call               `json:"call,omitempty" yaml:"call,omitempty"`
+		Approval  *Approval           `json:"approval,omitempty" yaml:"approval,omitempty"`
+		Sleep     *Sleep              `json:"sleep,omitempty" yaml:"sleep,omitempty"`
+		WaitUntil *WaitUntil          `json:"wait_until,omitempty" yaml:"wait_until,omitempty"`
+		Try       *Try                `json:"try,omitempty" yaml:"try,omitempty"`
 	}
 
 	Sequence struct {
@@ -38,7 +55,81 @@ type (
 	}
 
 	Parallel struct {
-		Branches []*Statement `json:"branches" yaml:"branches"`
+		Key      string         `json:"key,omitempty" yaml:"key,omitempty"`
+		Policy   ParallelPolicy `json:"policy,omitempty" yaml:"policy,omitempty"`
+		Quorum   int            `json:"quorum,omitempty" yaml:"quorum,omitempty"`
+		Branches []*Statement   `json:"branches" yaml:"branches"`
+	}
+
+	Condition struct {
+		Variable string            `json:"variable" yaml:"variable"`
+		Operator ConditionOperator `json:"operator,omitempty" yaml:"operator,omitempty"`
+		Value    any               `json:"value,omitempty" yaml:"value,omitempty"`
+		Then     *Statement        `json:"then" yaml:"then"`
+		Else     *Statement        `json:"else,omitempty" yaml:"else,omitempty"`
+	}
+
+	Loop struct {
+		Iterations      int               `json:"iterations" yaml:"iterations"`
+		Body            *Statement        `json:"body" yaml:"body"`
+		IndexVariable   string            `json:"indexVariable,omitempty" yaml:"indexVariable,omitempty"`
+		IntervalSeconds int64             `json:"intervalSeconds,omitempty" yaml:"intervalSeconds,omitempty"`
+		BreakVariable   string            `json:"breakVariable,omitempty" yaml:"breakVariable,omitempty"`
+		BreakOperator   ConditionOperator `json:"breakOperator,omitempty" yaml:"breakOperator,omitempty"`
+		BreakValue      any               `json:"breakValue,omitempty" yaml:"breakValue,omitempty"`
+	}
+
+	ForEach struct {
+		Key            string           `json:"key,omitempty" yaml:"key,omitempty"`
+		Items          string           `json:"items" yaml:"items"`
+		As             string           `json:"as,omitempty" yaml:"as,omitempty"`
+		IndexAs        string           `json:"index_as,omitempty" yaml:"index_as,omitempty"`
+		MaxConcurrency int              `json:"max_concurrency,omitempty" yaml:"max_concurrency,omitempty"`
+		ErrorMode      ForEachErrorMode `json:"error_mode,omitempty" yaml:"error_mode,omitempty"`
+		Body           *Statement       `json:"body" yaml:"body"`
+	}
+
+	// Call runs the workflow of another processor, or a snippet of the
+	// workspace, as a child workflow. With sets the variables of the called
+	// workflow, the bindings listed in Outputs are bound as <key>.<output>
+	// once it completed.
+	Call struct {
+		Key       string         `json:"key" yaml:"key"`
+		Processor string         `json:"processor,omitempty" yaml:"processor,omitempty"`
+		Snippet   string         `json:"snippet,omitempty" yaml:"snippet,omitempty"`
+		With      map[string]any `json:"with,omitempty" yaml:"with,omitempty"`
+		Outputs   []string       `json:"outputs,omitempty" yaml:"outputs,omitempty"`
+	}
+
+	// Approval pauses the workflow until a reviewer approves or rejects through
+	// the API, or the timeout expires and the default action is taken.
+	Approval struct {
+		Key              string         `json:"key" yaml:"key"`
+		Message          string         `json:"message,omitempty" yaml:"message,omitempty"`
+		TimeoutSeconds   int64          `json:"timeout_seconds" yaml:"timeout_seconds"`
+		DefaultAction    ApprovalAction `json:"default_action,omitempty" yaml:"default_action,omitempty"`
+		ContinueOnReject bool           `json:"continue_on_reject,omitempty" yaml:"continue_on_reject,omitempty"`
+	}
+
+	// Sleep pauses the workflow for a duration such as 10m or 1h30m, numbers
+	// are seconds.
+	Sleep struct {
+		Duration string `json:"duration" yaml:"duration"`
+	}
+
+	// WaitUntil pauses the workflow until an RFC 3339 timestamp, or the next
+	// occurrence of a time of day such as 02:00 in Timezone, UTC by default.
+	WaitUntil struct {
+		Time     string `json:"time" yaml:"time"`
+		Timezone string `json:"timezone,omitempty" yaml:"timezone,omitempty"`
+	}
+
+	// Try runs Body and hands its error to Catch, Finally runs in any case.
+	Try struct {
+		Key     string     `json:"key" yaml:"key"`
+		Body    *Statement `json:"body" yaml:"body"`
+		Catch   *Statement `json:"catch,omitempty" yaml:"catch,omitempty"`
+		Finally *Statement `json:"finally,omitempty" yaml:"finally,omitempty"`
 	}
 
 	ActivityInvocation struct {
@@ -57,6 +148,9 @@ type (
 		IgnoreErrors                  *bool          `json:"ignore_errors,omitempty" yaml:"ignore_errors,omitempty"`
 		OnSuccess                     *Statement     `json:"on_success,omitempty" yaml:"on_success,omitempty"`
 		OnError                       *Statement     `json:"on_error,omitempty" yaml:"on_error,omitempty"`
+		// Compensate undoes the work of the activity when a later statement of
+		// the enclosing try body, or of the workflow, fails.
+		Compensate *Statement `json:"compensate,omitempty" yaml:"compensate,omitempty"`
 	}
 
 	executable interface {
@@ -66,47 +160,116 @@ type (
 
 func SimpleDSLWorkflow(ctx workflow.Context, dslWorkflow Workflow) ([]byte, error) {
 	logger := workflow.GetLogger(ctx)
+	ctx = withSettings(ctx, dslWorkflow.Settings)
+	if dslWorkflow.TestRun {
+		return dslWorkflow.testRun(ctx)
+	}
+
+	run := newRunEvent(ctx, dslWorkflow)
+	recordRun(ctx, run)
+
+	artifacts, workflowErr := dslWorkflow.run(ctx)
+
+	// the run is recorded on a disconnected context so that cancelled runs are recorded too
+	run.Status = models.RunStatusCompleted
+	if artifacts != nil {
+		run.ArtifactKey = artifacts.Key
+		run.Artifacts = artifacts
+	}
+	if workflowErr != nil {
+		run.Status = models.RunStatusFailed
+		if temporal.IsCanceledError(workflowErr) {
+			run.Status = models.RunStatusCanceled
+		}
+		run.Error = workflowErr.Error()
+	}
+	run.FinishedAt = workflow.Now(ctx)
+	disconnectedCtx, _ := workflow.NewDisconnectedContext(ctx)
+	recordRun(disconnectedCtx, run)
+
+	if workflowErr != nil {
+		return nil, workflowErr
+	}
+	logger.Info("DSL Workflow completed.")
+	return nil, nil
+}
+
+// run executes the statements of the workflow and finalizes the artifacts. It
+// returns the manifest of the artifacts if the finalization succeeded.
+func (dslWorkflow Workflow) run(ctx workflow.Context) (*models.ArtifactManifest, error) {
+	logger := workflow.GetLogger(ctx)
+	if err := dslWorkflow.checkReferences(); err != nil {
+		logger.Error("DSL Workflow has invalid references: ", err)
+		return nil, err
+	}
+
+	ctx = workflow.WithValue(ctx, callsCtxKey, dslWorkflow.Calls)
+	ctx, err := withApprovals(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	bindings := make(map[string]any)
 	maps.Copy(bindings, dslWorkflow.Variables)
 
 	// adds workspace_id, upload_id, run_id etc
 	addWorkflowIdentifiersToBindings(ctx, bindings, dslWorkflow)
+	ctx = withScope(ctx, ActivityScope{
+		WorkspaceID: dslWorkflow.WorkspaceID,
+		UploadID:    dslWorkflow.UploadID,
+		ProcessorID: dslWorkflow.ProcessorID,
+		RunID:       workflow.GetInfo(ctx).WorkflowExecution.RunID,
+	})
 
-	workflowErr := dslWorkflow.Root.execute(ctx, bindings)
+	rootCtx, compensations := withCompensations(ctx)
+	workflowErr := dslWorkflow.Root.execute(rootCtx, bindings)
+	if workflowErr != nil {
+		workflowErr = compensations.run(ctx, workflowErr)
+	}
 
-	// runs the post processing activity in any case
-	// TODO: handle what if it fails
-	if e := runPostProcessingActivity(ctx, bindings); e != nil {
-		logger.Error("Error in post processing: ", e)
-		if workflowErr != nil {
-			workflowErr = fmt.Errorf("failed to run post processing. original error: %w", workflowErr)
-		} else {
-			workflowErr = fmt.Errorf("failed to run post processing")
+	// finalizes the artifacts in any case, test runs excepted as they must not
+	// produce artifacts
+	var artifacts *models.ArtifactManifest
+	if !dslWorkflow.TestRun {
+		artifacts, err = dslWorkflow.finalizeArtifacts(ctx)
+		if err != nil {
+			logger.Error("Failed to finalize artifacts: ", err)
+			if workflowErr != nil {
+				workflowErr = fmt.Errorf("failed to finalize artifacts: %w. original error: %w", err, workflowErr)
+			} else {
+				workflowErr = fmt.Errorf("failed to finalize artifacts: %w", err)
+			}
 		}
 	}
 
+	return artifacts, dslWorkflow.complete(ctx, bindings, workflowErr)
+}
+
+// complete runs the on_workflow_failure or on_workflow_success statement
+// depending on the outcome of the root statement. Test runs skip both.
+func (dslWorkflow Workflow) complete(ctx workflow.Context, bindings map[string]any, workflowErr error) error {
+	logger := workflow.GetLogger(ctx)
 	if workflowErr != nil {
 		logger.Error("DSL Workflow failed: ", workflowErr)
 		bindings["workflow_error"] = workflowErr
 
-		if dslWorkflow.OnWorkflowFailure != nil {
+		if dslWorkflow.OnWorkflowFailure != nil && !skipHook(ctx, "on_workflow_failure") {
 			onFailureErr := dslWorkflow.OnWorkflowFailure.execute(ctx, bindings)
 			if onFailureErr != nil {
 				workflowErr = fmt.Errorf("failed to run on_workflow_failure: %w. original error: %w", onFailureErr, workflowErr)
 			}
 		}
-		return nil, workflowErr
+		return workflowErr
 	}
 
-	if dslWorkflow.OnWorkflowSuccess != nil {
+	if dslWorkflow.OnWorkflowSuccess != nil && !skipHook(ctx, "on_workflow_success") {
 		onSuccessErr := dslWorkflow.OnWorkflowSuccess.execute(ctx, bindings)
 		if onSuccessErr != nil {
-			return nil, fmt.Errorf("failed to run on_workflow_success: %w", onSuccessErr)
+			return fmt.Errorf("failed to run on_workflow_success: %w", onSuccessErr)
 		}
 	}
 
-	logger.Info("DSL Workflow completed.")
-	return nil, nil
+	return nil
 }
 
 func (b *Statement) execute(ctx workflow.Context, bindings map[string]any) error {
@@ -119,52 +282,38 @@ func (b *Statement) execute(ctx workflow.Context, bindings map[string]any) error
 	if b.Activity != nil {
 		return b.Activity.execute(ctx, bindings)
 	}
-	return nil
-}
-
-func (a *ActivityInvocation) execute(ctx workflow.Context, bindings map[string]any) error {
-	log.Debug().Interface("activity", a).Msg("invoking activity")
-	ao := workflow.ActivityOptions{}
-	if a.StartToCloseTimeoutSeconds != nil && *a.StartToCloseTimeoutSeconds != 0 {
-		ao.StartToCloseTimeout = time.Duration(*a.StartToCloseTimeoutSeconds) * time.Second
-	} else {
-		ao.StartToCloseTimeout = 24 * time.Hour
+	if b.Condition != nil {
+		return b.Condition.execute(ctx, bindings)
 	}
-
-	if a.ScheduleToCloseTimeoutSeconds != nil {
-		ao.ScheduleToCloseTimeout = time.Duration(*a.ScheduleToCloseTimeoutSeconds) * time.Second
-	} else {
-		ao.ScheduleToCloseTimeout = 24 * time.Hour
+	if b.Loop != nil {
+		return b.Loop.execute(ctx, bindings)
 	}
-
-	if a.ScheduleToStartTimeoutSeconds != nil {
-		ao.ScheduleToStartTimeout = time.Duration(*a.ScheduleToStartTimeoutSeconds) * time.Second
-	} else {
-		ao.ScheduleToStartTimeout = 24 * time.Hour
+	if b.ForEach != nil {
+		return b.ForEach.execute(ctx, bindings)
 	}
-
-	ao.RetryPolicy = &temporal.RetryPolicy{
-		MaximumAttempts:    1,
-		InitialInterval:    0,
-		BackoffCoefficient: 2,
-		MaximumInterval:    1 * time.Minute,
+	if b.Call != nil {
+		return b.Call.execute(ctx, bindings)
 	}
-
-	if a.MaxRetries != nil {
-		ao.RetryPolicy.MaximumAttempts = *a.MaxRetries
+	if b.Approval != nil {
+		return b.Approval.execute(ctx, bindings)
 	}
-	if a.RetryInitialIntervalSeconds != nil {
-		ao.RetryPolicy.InitialInterval = time.Duration(*a.RetryInitialIntervalSeconds) * time.Second
+	if b.Sleep != nil {
+		return b.Sleep.execute(ctx, bindings)
 	}
-	if a.RetryBackoffCoefficient != nil {
-		ao.RetryPolicy.BackoffCoefficient = *a.RetryBackoffCoefficient
+	if b.WaitUntil != nil {
+		return b.WaitUntil.execute(ctx, bindings)
 	}
-	if a.RetryMaxIntervalSeconds != nil {
-		ao.RetryPolicy.MaximumInterval = time.Duration(*a.RetryMaxIntervalSeconds) * time.Second
+	if b.Try != nil {
+		return b.Try.execute(ctx, bindings)
 	}
-	ctx = workflow.WithActivityOptions(ctx, ao)
+	return nil
+}
 
-	args, err := makeInput(a.With, bindings, a.Key, a.SaveOutput, a.Input)
+func (a *ActivityInvocation) execute(ctx workflow.Context, bindings map[string]any) error {
+	log.Debug().Interface("activity", a).Msg("invoking activity")
+	ctx = workflow.WithActivityOptions(ctx, a.activityOptions(settingsFromContext(ctx)))
+
+	args, err := makeInput(a.Uses, a.With, bindings, a.Key, a.SaveOutput, a.Input)
 	if err != nil {
 		logger := workflow.GetLogger(ctx)
 		logger.Error("Failed to make input.", "Error", err)
@@ -181,8 +330,13 @@ func (a *ActivityInvocation) execute(ctx workflow.Context, bindings map[string]a
 		return err
 	}
 
+	scope := scopeFromContext(ctx)
+	scope.ActivityKey = a.Key
+
+	trace := startTrace(ctx, a, bindings)
 	var result []byte
-	err = workflow.ExecuteActivity(ctx, "Executor", a.Uses, args).Get(ctx, &result)
+	err = workflow.ExecuteActivity(ctx, ExecutorActivityName, a.Uses, args, scope).Get(ctx, &result)
+	trace.finish(ctx, result, err)
 	if err != nil && (a.IgnoreErrors == nil || !*a.IgnoreErrors) {
 		return handleError(err)
 	}
@@ -193,6 +347,9 @@ func (a *ActivityInvocation) execute(ctx workflow.Context, bindings map[string]a
 	}
 
 	saveOutput(output, bindings, a.Key)
+	if err == nil {
+		registerCompensation(ctx, a, bindings)
+	}
 	if a.OnSuccess != nil {
 		return a.OnSuccess.execute(ctx, bindings)
 	}
@@ -209,30 +366,6 @@ func (s *Sequence) execute(ctx workflow.Context, bindings map[string]any) error
 	return nil
 }
 
-func (p *Parallel) execute(ctx workflow.Context, bindings map[string]any) error {
-	childCtx, cancelHandler := workflow.WithCancel(ctx)
-	selector := workflow.NewSelector(ctx)
-	var activityErr error
-
-	for _, stmt := range p.Branches {
-		f := executeAsync(stmt, childCtx, bindings)
-		selector.AddFuture(f, func(f workflow.Future) {
-			if err := f.Get(ctx, nil); err != nil {
-				cancelHandler()
-				activityErr = err
-			}
-		})
-	}
-
-	for i := 0; i < len(p.Branches); i++ {
-		selector.Select(ctx)
-		if activityErr != nil {
-			return activityErr
-		}
-	}
-	return nil
-}
-
 func executeAsync(exe executable, ctx workflow.Context, bindings map[string]any) workflow.Future {
 	future, settable := workflow.NewFuture(ctx)
 	workflow.Go(ctx, func(ctx workflow.Context) {
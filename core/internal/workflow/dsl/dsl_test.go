@@ -0,0 +1,420 @@
+package dsl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uploadpilot/core/internal/db/models"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/testsuite"
+	"gopkg.in/yaml.v3"
+)
+
+// fakeExecutor stands in for the "Executor", "RecordRun" and
+// "FinalizeArtifacts" activities and records every call.
+type fakeExecutor struct {
+	mu        sync.Mutex
+	calls     []map[string]any
+	runs      []RunEvent
+	finalized []FinalizeArtifactsRequest
+	respond   func(uses string, payload map[string]any) (map[string]any, error)
+}
+
+func (f *fakeExecutor) recordRun(ctx context.Context, run RunEvent) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.runs = append(f.runs, run)
+	return nil
+}
+
+func (f *fakeExecutor) finalize(ctx context.Context, req FinalizeArtifactsRequest) (*models.ArtifactManifest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.finalized = append(f.finalized, req)
+	return &models.ArtifactManifest{Mode: req.Mode, Key: req.UploadID + "/artifacts/" + req.RunID + ".zip"}, nil
+}
+
+func (f *fakeExecutor) execute(ctx context.Context, uses, payload string, scope ActivityScope) ([]byte, error) {
+	var in map[string]any
+	if err := json.Unmarshal([]byte(payload), &in); err != nil {
+		return nil, err
+	}
+	in["__uses"] = uses
+	in["__scope"] = scope
+
+	f.mu.Lock()
+	f.calls = append(f.calls, in)
+	f.mu.Unlock()
+
+	out := map[string]any{"status_code": 200}
+	if f.respond != nil {
+		r, err := f.respond(uses, in)
+		if err != nil {
+			return nil, err
+		}
+		out = r
+	}
+	return json.Marshal(out)
+}
+
+func (f *fakeExecutor) callsTo(uses string) []map[string]any {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var calls []map[string]any
+	for _, c := range f.calls {
+		if c["__uses"] == uses {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+func runDSLWorkflow(t *testing.T, source string, exec *fakeExecutor) error {
+	t.Helper()
+
+	var wf Workflow
+	if err := yaml.Unmarshal([]byte(source), &wf); err != nil {
+		t.Fatalf("failed to parse workflow: %v", err)
+	}
+	return runParsedWorkflow(t, wf, exec)
+}
+
+func runParsedWorkflow(t *testing.T, wf Workflow, exec *fakeExecutor) error {
+	t.Helper()
+	return runInEnv(t, newTestEnv(exec), wf)
+}
+
+func newTestEnv(exec *fakeExecutor) *testsuite.TestWorkflowEnvironment {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(SimpleDSLWorkflow)
+	env.RegisterWorkflow(CallDSLWorkflow)
+	env.RegisterActivityWithOptions(exec.execute, activity.RegisterOptions{Name: "Executor"})
+	env.RegisterActivityWithOptions(exec.recordRun, activity.RegisterOptions{Name: RecordRunActivityName})
+	env.RegisterActivityWithOptions(exec.finalize, activity.RegisterOptions{Name: FinalizeArtifactsActivityName})
+	return env
+}
+
+func runInEnv(t *testing.T, env *testsuite.TestWorkflowEnvironment, wf Workflow) error {
+	t.Helper()
+
+	env.ExecuteWorkflow(SimpleDSLWorkflow, wf)
+	if !env.IsWorkflowCompleted() {
+		t.Fatalf("workflow did not complete")
+	}
+	return env.GetWorkflowError()
+}
+
+func TestLoopBreaksOnCondition(t *testing.T) {
+	exec := &fakeExecutor{
+		respond: func(uses string, in map[string]any) (map[string]any, error) {
+			status := "pending"
+			if in["loop_index"] == float64(2) {
+				status = "done"
+			}
+			return map[string]any{"status_code": 200, "state": status}, nil
+		},
+	}
+
+	err := runDSLWorkflow(t, `
+variables: {}
+root:
+  loop:
+    iterations: 10
+    intervalSeconds: 30
+    breakVariable: poll.state
+    breakValue: done
+    body:
+      activity:
+        key: poll
+        uses: HTTP_V_01
+        with:
+          url: https://example.com
+`, exec)
+
+	assert.NoError(t, err)
+	assert.Len(t, exec.callsTo("HTTP_V_01"), 3)
+}
+
+func TestLoopRunsAllIterationsWithoutBreak(t *testing.T) {
+	exec := &fakeExecutor{}
+
+	err := runDSLWorkflow(t, `
+variables: {}
+root:
+  loop:
+    iterations: 4
+    indexVariable: page
+    body:
+      activity:
+        key: fetch
+        uses: HTTP_V_01
+        with:
+          url: https://example.com
+`, exec)
+
+	assert.NoError(t, err)
+	calls := exec.callsTo("HTTP_V_01")
+	assert.Len(t, calls, 4)
+	for i, call := range calls {
+		assert.Equal(t, float64(i), call["page"])
+	}
+}
+
+func TestLoopRejectsIterationsAboveCap(t *testing.T) {
+	exec := &fakeExecutor{}
+
+	err := runDSLWorkflow(t, `
+variables: {}
+root:
+  loop:
+    iterations: 1000
+    body:
+      activity:
+        key: fetch
+        uses: HTTP_V_01
+        with:
+          url: https://example.com
+`, exec)
+
+	assert.Error(t, err)
+	assert.Empty(t, exec.callsTo("HTTP_V_01"))
+}
+
+func TestForEachRunsBodyPerItem(t *testing.T) {
+	exec := &fakeExecutor{
+		respond: func(uses string, in map[string]any) (map[string]any, error) {
+			if uses == "ListPages" {
+				return map[string]any{"status_code": 200, "pages": []any{"p1", "p2", "p3"}}, nil
+			}
+			return map[string]any{"status_code": 200}, nil
+		},
+	}
+
+	err := runDSLWorkflow(t, `
+variables: {}
+root:
+  sequence:
+    elements:
+      - activity:
+          key: split
+          uses: ListPages
+      - foreach:
+          key: pages
+          items: split.pages
+          as: page
+          max_concurrency: 2
+          body:
+            activity:
+              key: ocr
+              uses: DetectDocumentTextV1
+`, exec)
+
+	assert.NoError(t, err)
+	calls := exec.callsTo("DetectDocumentTextV1")
+	assert.Len(t, calls, 3)
+	var pages []any
+	for _, call := range calls {
+		pages = append(pages, call["page"])
+	}
+	assert.ElementsMatch(t, []any{"p1", "p2", "p3"}, pages)
+}
+
+func TestNestedVariablesResolveIntoOutputs(t *testing.T) {
+	exec := &fakeExecutor{
+		respond: func(uses string, in map[string]any) (map[string]any, error) {
+			if uses == "ListPages" {
+				return map[string]any{"status_code": 200, "result": map[string]any{"pages": []any{"p1", "p2"}}}, nil
+			}
+			return map[string]any{"status_code": 200}, nil
+		},
+	}
+
+	err := runDSLWorkflow(t, `
+variables: {}
+root:
+  sequence:
+    elements:
+      - activity:
+          key: split
+          uses: ListPages
+      - foreach:
+          key: pages
+          items: split.result.pages
+          body:
+            condition:
+              variable: split.result.pages[0]
+              value: p1
+              then:
+                activity:
+                  key: ocr
+                  uses: DetectDocumentTextV1
+`, exec)
+
+	assert.NoError(t, err)
+	assert.Len(t, exec.callsTo("DetectDocumentTextV1"), 2)
+}
+
+func TestForEachErrorModes(t *testing.T) {
+	failOnSecond := func(uses string, in map[string]any) (map[string]any, error) {
+		if in["item_index"] == float64(1) {
+			return nil, assert.AnError
+		}
+		return map[string]any{"status_code": 200}, nil
+	}
+
+	source := func(mode string) string {
+		return `
+variables:
+  files: [a, b, c, d]
+root:
+  foreach:
+    items: files
+    error_mode: ` + mode + `
+    body:
+      activity:
+        key: convert
+        uses: ImageFormatConvertorV1
+        with:
+          format: png
+`
+	}
+
+	t.Run("fail fast stops scheduling new items", func(t *testing.T) {
+		exec := &fakeExecutor{respond: failOnSecond}
+		err := runDSLWorkflow(t, source("fail_fast"), exec)
+		assert.Error(t, err)
+		assert.Len(t, exec.callsTo("ImageFormatConvertorV1"), 2)
+	})
+
+	t.Run("collect all runs every item", func(t *testing.T) {
+		exec := &fakeExecutor{respond: failOnSecond}
+		err := runDSLWorkflow(t, source("collect_all"), exec)
+		assert.Error(t, err)
+		assert.Len(t, exec.callsTo("ImageFormatConvertorV1"), 4)
+	})
+}
+
+func TestActivityRejectsRenderedArgumentsOutsideSchema(t *testing.T) {
+	exec := &fakeExecutor{}
+
+	err := runDSLWorkflow(t, `
+variables:
+  target: heic
+root:
+  activity:
+    key: convert
+    uses: ImageFormatConvertorV1
+    with:
+      format: ${target}
+`, exec)
+
+	assert.ErrorContains(t, err, "invalid arguments for ImageFormatConvertorV1")
+	assert.Empty(t, exec.callsTo("ImageFormatConvertorV1"))
+}
+
+func TestRunIsRecordedOnStartAndCompletion(t *testing.T) {
+	source := `
+variables: {}
+root:
+  activity:
+    key: convert
+    uses: ImageFormatConvertorV1
+    with:
+      format: png
+`
+	exec := &fakeExecutor{}
+	err := runDSLWorkflow(t, source, exec)
+	assert.NoError(t, err)
+	if assert.Len(t, exec.runs, 2) {
+		assert.Equal(t, models.RunStatusRunning, exec.runs[0].Status)
+		assert.Equal(t, 1, exec.runs[0].Attempt)
+		assert.Equal(t, models.RunStatusCompleted, exec.runs[1].Status)
+		assert.Equal(t, exec.runs[0].RunID, exec.runs[1].RunID)
+		assert.Contains(t, exec.runs[1].ArtifactKey, "/artifacts/")
+	}
+
+	exec = &fakeExecutor{respond: func(uses string, payload map[string]any) (map[string]any, error) {
+		if uses == "ImageFormatConvertorV1" {
+			return map[string]any{"status_code": 500}, errors.New("conversion failed")
+		}
+		return map[string]any{"status_code": 200}, nil
+	}}
+	err = runDSLWorkflow(t, source, exec)
+	assert.Error(t, err)
+	if assert.Len(t, exec.runs, 2) {
+		assert.Equal(t, models.RunStatusFailed, exec.runs[1].Status)
+		assert.Contains(t, exec.runs[1].Error, "conversion failed")
+	}
+}
+
+func TestTestRunReturnsActivityTraces(t *testing.T) {
+	var wf Workflow
+	assert.NoError(t, yaml.Unmarshal([]byte(`
+variables: {}
+root:
+  sequence:
+    elements:
+      - activity:
+          key: convert
+          uses: ImageFormatConvertorV1
+          with:
+            format: png
+      - activity:
+          key: resize
+          uses: ImageResize@v1.0
+          input: convert
+          with:
+            width: 10
+on_workflow_failure:
+  activity:
+    key: notify
+    uses: HTTP_V_01
+    with:
+      url: https://example.com/failed
+`), &wf))
+	wf.TestRun = true
+
+	exec := &fakeExecutor{respond: func(uses string, payload map[string]any) (map[string]any, error) {
+		if uses == "ImageResize@v1.0" {
+			return nil, errors.New("resize failed")
+		}
+		return map[string]any{"status_code": 200, "output_key": "converted.png"}, nil
+	}}
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(SimpleDSLWorkflow)
+	env.RegisterActivityWithOptions(exec.execute, activity.RegisterOptions{Name: "Executor"})
+	env.RegisterActivityWithOptions(exec.recordRun, activity.RegisterOptions{Name: RecordRunActivityName})
+	env.RegisterActivityWithOptions(exec.finalize, activity.RegisterOptions{Name: FinalizeArtifactsActivityName})
+	env.ExecuteWorkflow(SimpleDSLWorkflow, wf)
+
+	// a failed test run completes, the error is part of the result
+	assert.True(t, env.IsWorkflowCompleted())
+	assert.NoError(t, env.GetWorkflowError())
+	var raw []byte
+	assert.NoError(t, env.GetWorkflowResult(&raw))
+	var result TestRunResult
+	assert.NoError(t, json.Unmarshal(raw, &result))
+
+	assert.Equal(t, models.RunStatusFailed, result.Status)
+	assert.Contains(t, result.Error, "resize failed")
+	if assert.Len(t, result.Activities, 2) {
+		assert.Equal(t, "convert", result.Activities[0].Key)
+		assert.Equal(t, map[string]any{"format": "png"}, result.Activities[0].Input)
+		assert.Equal(t, "converted.png", result.Activities[0].Output["output_key"])
+		assert.Equal(t, map[string]any{"width": float64(10), "input": "convert"}, result.Activities[1].Input)
+		assert.Contains(t, result.Activities[1].Error, "resize failed")
+	}
+
+	// test runs are not recorded, produce no artifacts and skip the hooks
+	assert.Empty(t, exec.runs)
+	assert.Empty(t, exec.finalized)
+	assert.Empty(t, exec.callsTo("HTTP_V_01"))
+	assert.Equal(t, []string{"on_workflow_failure"}, result.SkippedHooks)
+	assert.Equal(t, true, exec.callsTo("ImageFormatConvertorV1")[0]["test_run"])
+}
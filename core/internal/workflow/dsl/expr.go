@@ -0,0 +1,668 @@
+package dsl
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Activity `with` arguments support templates:
+//
+//	url: "https://hooks/x/${upload_id}?name=${lower(file_name)}"
+//	text: "${ocr.pages[0].text}"
+//	token: "${default(variables_token, 'anonymous') | base64}"
+//	source: $file_name
+//	token: "Bearer ${secrets.API_TOKEN}"
+//
+// A string that consists of a single ${...} (or the legacy $name form) keeps the
+// type of the referenced value, otherwise the results are interpolated as text.
+// Paths are resolved against the flat bindings map, so `ocr.pages[0].text`
+// looks up the `ocr.pages` binding and then walks into the saved output.
+//
+// Workspace secrets are never rendered by the workflow, ${secrets.NAME} is kept
+// as is and replaced by the executor right before the activity runs, so the
+// plaintext does not end up in the workflow history.
+
+var legacyReference = regexp.MustCompile(`^\$[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*|\[[0-9]+\])*$`)
+
+type template struct {
+	raw   string
+	parts []templatePart
+}
+
+type templatePart struct {
+	text string
+	expr exprNode
+}
+
+type exprNode interface {
+	eval(bindings map[string]any) (any, error)
+}
+
+type literalNode struct {
+	value any
+}
+
+type pathNode struct {
+	raw      string
+	segments []pathSegment
+}
+
+type pathSegment struct {
+	name    string
+	index   int
+	isIndex bool
+}
+
+// secretNode is a ${secrets.NAME} reference, it renders to itself.
+type secretNode struct {
+	name string
+}
+
+const secretsRoot = "secrets"
+
+type callNode struct {
+	name string
+	args []exprNode
+}
+
+// reference is a path used by a template. References inside the first argument
+// of default() are optional because the template handles them being missing.
+type reference struct {
+	path     *pathNode
+	optional bool
+}
+
+type exprFunc func(args []any) (any, error)
+
+var exprFuncs = map[string]exprFunc{
+	"lower":  stringFunc(strings.ToLower),
+	"upper":  stringFunc(strings.ToUpper),
+	"trim":   stringFunc(strings.TrimSpace),
+	"string": stringFunc(func(s string) string { return s }),
+	"base64": stringFunc(func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }),
+	"json": func(args []any) (any, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("json expects 1 argument, got %d", len(args))
+		}
+		b, err := json.Marshal(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	},
+	// default is evaluated lazily in callNode.eval, the entry only registers the name
+	"default": nil,
+}
+
+func stringFunc(fn func(string) string) exprFunc {
+	return func(args []any) (any, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("expected 1 argument, got %d", len(args))
+		}
+		return fn(stringify(args[0])), nil
+	}
+}
+
+// parseTemplate parses a `with` string value. Strings without expressions return
+// a template with a single text part.
+func parseTemplate(s string) (*template, error) {
+	t := &template{raw: s}
+
+	if legacyReference.MatchString(s) {
+		p, err := newExprParser(s[1:]).parsePathOnly()
+		if err != nil {
+			return nil, err
+		}
+		node, err := secretReference(p)
+		if err != nil {
+			return nil, err
+		}
+		t.parts = []templatePart{{expr: node}}
+		return t, nil
+	}
+
+	var text strings.Builder
+	for i := 0; i < len(s); {
+		if strings.HasPrefix(s[i:], "$${") {
+			text.WriteString("${")
+			i += 3
+			continue
+		}
+		if !strings.HasPrefix(s[i:], "${") {
+			text.WriteByte(s[i])
+			i++
+			continue
+		}
+
+		end, err := findExprEnd(s, i+2)
+		if err != nil {
+			return nil, err
+		}
+		node, err := newExprParser(s[i+2 : end]).parse()
+		if err == nil {
+			node, err = secretReference(node)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid expression %q: %w", s[i:end+1], err)
+		}
+		if text.Len() > 0 {
+			t.parts = append(t.parts, templatePart{text: text.String()})
+			text.Reset()
+		}
+		t.parts = append(t.parts, templatePart{expr: node})
+		i = end + 1
+	}
+	if text.Len() > 0 || len(t.parts) == 0 {
+		t.parts = append(t.parts, templatePart{text: text.String()})
+	}
+
+	return t, nil
+}
+
+// findExprEnd returns the index of the closing brace, skipping braces inside
+// quoted strings.
+func findExprEnd(s string, from int) (int, error) {
+	var quote byte
+	for i := from; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '}':
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated expression in %q", s)
+}
+
+// secretReference turns a secrets.NAME path into a secretNode. Secrets cannot be
+// passed to functions because they are not known while the workflow runs.
+func secretReference(node exprNode) (exprNode, error) {
+	if p, ok := node.(*pathNode); ok && p.segments[0].name == secretsRoot {
+		if len(p.segments) != 2 || p.segments[1].isIndex {
+			return nil, fmt.Errorf("secrets must be referenced as secrets.NAME")
+		}
+		return &secretNode{name: p.segments[1].name}, nil
+	}
+	for _, ref := range collectReferences(node, false, nil) {
+		if ref.path.segments[0].name == secretsRoot {
+			return nil, fmt.Errorf("secrets can only be used as ${secrets.NAME}")
+		}
+	}
+	return node, nil
+}
+
+func (t *template) hasExpressions() bool {
+	for _, p := range t.parts {
+		if p.expr != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *template) render(bindings map[string]any) (any, error) {
+	if len(t.parts) == 1 && t.parts[0].expr != nil {
+		return t.parts[0].expr.eval(bindings)
+	}
+
+	var out strings.Builder
+	for _, p := range t.parts {
+		if p.expr == nil {
+			out.WriteString(p.text)
+			continue
+		}
+		v, err := p.expr.eval(bindings)
+		if err != nil {
+			return nil, err
+		}
+		out.WriteString(stringify(v))
+	}
+	return out.String(), nil
+}
+
+func (t *template) references() []reference {
+	var refs []reference
+	for _, p := range t.parts {
+		if p.expr != nil {
+			refs = collectReferences(p.expr, false, refs)
+		}
+	}
+	return refs
+}
+
+func collectReferences(node exprNode, optional bool, refs []reference) []reference {
+	switch n := node.(type) {
+	case *pathNode:
+		refs = append(refs, reference{path: n, optional: optional})
+	case *callNode:
+		for i, arg := range n.args {
+			refs = collectReferences(arg, optional || (n.name == "default" && i == 0), refs)
+		}
+	}
+	return refs
+}
+
+// renderValue renders templates in strings, maps and lists recursively.
+func renderValue(value any, bindings map[string]any) (any, error) {
+	switch v := value.(type) {
+	case string:
+		t, err := parseTemplate(v)
+		if err != nil {
+			return nil, err
+		}
+		return t.render(bindings)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			r, err := renderValue(item, bindings)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			out[key] = r
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			r, err := renderValue(item, bindings)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			out[i] = r
+		}
+		return out, nil
+	}
+	return value, nil
+}
+
+// valueTemplates parses every string found in value and calls fn with the
+// parsed template and the location of the string relative to value.
+func valueTemplates(value any, path string, fn func(path string, t *template, err error)) {
+	switch v := value.(type) {
+	case string:
+		t, err := parseTemplate(v)
+		fn(path, t, err)
+	case map[string]any:
+		for key, item := range v {
+			valueTemplates(item, path+"."+key, fn)
+		}
+	case []any:
+		for i, item := range v {
+			valueTemplates(item, fmt.Sprintf("%s[%d]", path, i), fn)
+		}
+	}
+}
+
+func (n *secretNode) eval(map[string]any) (any, error) {
+	return "${" + secretsRoot + "." + n.name + "}", nil
+}
+
+func (l *literalNode) eval(map[string]any) (any, error) {
+	return l.value, nil
+}
+
+func (c *callNode) eval(bindings map[string]any) (any, error) {
+	if c.name == "default" {
+		if len(c.args) != 2 {
+			return nil, fmt.Errorf("default expects 2 arguments, got %d", len(c.args))
+		}
+		v, err := c.args[0].eval(bindings)
+		if err == nil && v != nil && v != "" {
+			return v, nil
+		}
+		return c.args[1].eval(bindings)
+	}
+
+	fn, ok := exprFuncs[c.name]
+	if !ok || fn == nil {
+		return nil, fmt.Errorf("unknown function %q", c.name)
+	}
+	args := make([]any, len(c.args))
+	for i, a := range c.args {
+		v, err := a.eval(bindings)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	v, err := fn(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", c.name, err)
+	}
+	return v, nil
+}
+
+// leadingNames returns the dotted names before the first index segment, these
+// are the candidates for flat binding keys.
+func (p *pathNode) leadingNames() []string {
+	var names []string
+	for _, s := range p.segments {
+		if s.isIndex {
+			break
+		}
+		names = append(names, s.name)
+	}
+	return names
+}
+
+func (p *pathNode) eval(bindings map[string]any) (any, error) {
+	names := p.leadingNames()
+	for j := len(names); j >= 1; j-- {
+		value, ok := bindings[strings.Join(names[:j], ".")]
+		if !ok {
+			continue
+		}
+		for _, s := range p.segments[j:] {
+			value, ok = walk(value, s)
+			if !ok {
+				return nil, fmt.Errorf("cannot resolve %q", p.raw)
+			}
+		}
+		return value, nil
+	}
+	return nil, fmt.Errorf("cannot resolve %q", p.raw)
+}
+
+// lookupVariable resolves the variable of a condition, loop or foreach. Flat
+// binding names are looked up as is, paths like `ocr.result.confidence` or
+// `pages[0].score` walk into the saved outputs like template references do.
+func lookupVariable(bindings map[string]any, name string) (any, bool) {
+	if value, ok := bindings[name]; ok {
+		return value, true
+	}
+	p, err := newExprParser(name).parsePathOnly()
+	if err != nil {
+		return nil, false
+	}
+	value, err := p.eval(bindings)
+	return value, err == nil
+}
+
+func walk(value any, s pathSegment) (any, bool) {
+	if s.isIndex {
+		list, ok := asList(value)
+		if !ok || s.index < 0 || s.index >= len(list) {
+			return nil, false
+		}
+		return list[s.index], true
+	}
+	m, ok := value.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	v, ok := m[s.name]
+	return v, ok
+}
+
+func stringify(v any) string {
+	switch s := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return s
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64)
+	case bool, int, int32, int64, uint64:
+		return fmt.Sprint(s)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// exprParser is a small recursive descent parser for:
+//
+//	expr    := primary ('|' ident)*
+//	primary := string | number | true | false | null | call | path
+//	call    := ident '(' [expr (',' expr)*] ')'
+//	path    := ['$.'] ident ('.' ident | '[' (number | string) ']')*
+type exprParser struct {
+	src string
+	pos int
+}
+
+func newExprParser(src string) *exprParser {
+	return &exprParser{src: src}
+}
+
+func (p *exprParser) parse() (exprNode, error) {
+	node, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos != len(p.src) {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.src[p.pos:], p.pos)
+	}
+	return node, nil
+}
+
+func (p *exprParser) parsePathOnly() (*pathNode, error) {
+	node, err := p.parsePath()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.src) {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.src[p.pos:], p.pos)
+	}
+	return node, nil
+}
+
+func (p *exprParser) parseExpr() (exprNode, error) {
+	node, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpace()
+		if !p.consume('|') {
+			return node, nil
+		}
+		p.skipSpace()
+		name := p.ident()
+		if name == "" {
+			return nil, fmt.Errorf("expected function name after '|' at position %d", p.pos)
+		}
+		if _, ok := exprFuncs[name]; !ok || name == "default" {
+			return nil, fmt.Errorf("unknown function %q", name)
+		}
+		node = &callNode{name: name, args: []exprNode{node}}
+	}
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+
+	c := p.src[p.pos]
+	switch {
+	case c == '\'' || c == '"':
+		s, err := p.stringLiteral()
+		if err != nil {
+			return nil, err
+		}
+		return &literalNode{value: s}, nil
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.numberLiteral()
+	case c == '$':
+		return p.parsePath()
+	}
+
+	start := p.pos
+	name := p.ident()
+	if name == "" {
+		return nil, fmt.Errorf("unexpected %q at position %d", string(c), p.pos)
+	}
+	switch name {
+	case "true":
+		return &literalNode{value: true}, nil
+	case "false":
+		return &literalNode{value: false}, nil
+	case "null":
+		return &literalNode{value: nil}, nil
+	}
+
+	p.skipSpace()
+	if !p.consume('(') {
+		p.pos = start
+		return p.parsePath()
+	}
+
+	if _, ok := exprFuncs[name]; !ok {
+		return nil, fmt.Errorf("unknown function %q", name)
+	}
+	call := &callNode{name: name}
+	p.skipSpace()
+	if p.consume(')') {
+		return call, nil
+	}
+	for {
+		arg, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		call.args = append(call.args, arg)
+		p.skipSpace()
+		if p.consume(')') {
+			return call, nil
+		}
+		if !p.consume(',') {
+			return nil, fmt.Errorf("expected ',' or ')' at position %d", p.pos)
+		}
+	}
+}
+
+func (p *exprParser) parsePath() (*pathNode, error) {
+	start := p.pos
+	if strings.HasPrefix(p.src[p.pos:], "$.") {
+		p.pos += 2
+	}
+
+	node := &pathNode{}
+	name := p.ident()
+	if name == "" {
+		return nil, fmt.Errorf("expected variable name at position %d", p.pos)
+	}
+	node.segments = append(node.segments, pathSegment{name: name})
+
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case '.':
+			p.pos++
+			name := p.ident()
+			if name == "" {
+				return nil, fmt.Errorf("expected field name at position %d", p.pos)
+			}
+			node.segments = append(node.segments, pathSegment{name: name})
+		case '[':
+			p.pos++
+			p.skipSpace()
+			if p.pos < len(p.src) && (p.src[p.pos] == '\'' || p.src[p.pos] == '"') {
+				s, err := p.stringLiteral()
+				if err != nil {
+					return nil, err
+				}
+				node.segments = append(node.segments, pathSegment{name: s})
+			} else {
+				digits := p.span(func(r rune) bool { return r >= '0' && r <= '9' })
+				idx, err := strconv.Atoi(digits)
+				if err != nil {
+					return nil, fmt.Errorf("expected index at position %d", p.pos)
+				}
+				node.segments = append(node.segments, pathSegment{index: idx, isIndex: true})
+			}
+			p.skipSpace()
+			if !p.consume(']') {
+				return nil, fmt.Errorf("expected ']' at position %d", p.pos)
+			}
+		default:
+			node.raw = strings.TrimPrefix(p.src[start:p.pos], "$.")
+			return node, nil
+		}
+	}
+
+	node.raw = strings.TrimPrefix(p.src[start:p.pos], "$.")
+	return node, nil
+}
+
+func (p *exprParser) stringLiteral() (string, error) {
+	quote := p.src[p.pos]
+	p.pos++
+	var out strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		p.pos++
+		if c == '\\' && p.pos < len(p.src) {
+			out.WriteByte(p.src[p.pos])
+			p.pos++
+			continue
+		}
+		if c == quote {
+			return out.String(), nil
+		}
+		out.WriteByte(c)
+	}
+	return "", fmt.Errorf("unterminated string literal")
+}
+
+func (p *exprParser) numberLiteral() (exprNode, error) {
+	start := p.pos
+	if p.src[p.pos] == '-' {
+		p.pos++
+	}
+	p.span(func(r rune) bool { return (r >= '0' && r <= '9') || r == '.' })
+	f, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number %q", p.src[start:p.pos])
+	}
+	return &literalNode{value: f}, nil
+}
+
+func (p *exprParser) ident() string {
+	if p.pos >= len(p.src) {
+		return ""
+	}
+	c := rune(p.src[p.pos])
+	if c != '_' && !unicode.IsLetter(c) {
+		return ""
+	}
+	return p.span(func(r rune) bool { return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) })
+}
+
+func (p *exprParser) span(accept func(rune) bool) string {
+	start := p.pos
+	for p.pos < len(p.src) && accept(rune(p.src[p.pos])) {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *exprParser) consume(c byte) bool {
+	if p.pos < len(p.src) && p.src[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package dsl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderValue(t *testing.T) {
+	bindings := map[string]any{
+		"upload_id":  "u-1",
+		"file_name":  "Invoice.PDF",
+		"threshold":  0.8,
+		"empty":      "",
+		"ocr.pages":  []any{map[string]any{"text": "hello", "confidence": 0.91}},
+		"ocr.status": "done",
+		"http.body":  map[string]any{"id": float64(42), "tags": []any{"a", "b"}},
+	}
+
+	testCases := []struct {
+		name      string
+		value     any
+		expected  any
+		expectErr bool
+	}{
+		{name: "plain string", value: "https://example.com", expected: "https://example.com"},
+		{name: "empty string", value: "", expected: ""},
+		{name: "legacy reference keeps type", value: "$threshold", expected: 0.8},
+		{name: "dollar amount is not a reference", value: "$5 fee", expected: "$5 fee"},
+		{name: "interpolation", value: "https://hooks/x/${upload_id}?name=${file_name}", expected: "https://hooks/x/u-1?name=Invoice.PDF"},
+		{name: "single expression keeps type", value: "${http.body}", expected: map[string]any{"id": float64(42), "tags": []any{"a", "b"}}},
+		{name: "nested output path", value: "${ocr.pages[0].text}", expected: "hello"},
+		{name: "jsonpath prefix", value: "${$.http.body.tags[1]}", expected: "b"},
+		{name: "bracket field access", value: "${http.body['id']}", expected: float64(42)},
+		{name: "number interpolation", value: "id-${http.body.id}", expected: "id-42"},
+		{name: "lower function", value: "${lower(file_name)}", expected: "invoice.pdf"},
+		{name: "pipe function", value: "${file_name | upper}", expected: "INVOICE.PDF"},
+		{name: "default for missing", value: "${default(missing, 'none')}", expected: "none"},
+		{name: "default for empty", value: "${default(empty, \"fallback\")}", expected: "fallback"},
+		{name: "default keeps existing", value: "${default(ocr.status, 'none')}", expected: "done"},
+		{name: "json function", value: "${json(http.body.tags)}", expected: `["a","b"]`},
+		{name: "base64 function", value: "${upload_id | base64}", expected: "dS0x"},
+		{name: "escaped expression", value: "$${upload_id}", expected: "${upload_id}"},
+		{name: "brace inside string literal", value: "${default(missing, '}')}", expected: "}"},
+		{name: "nested map and list", value: map[string]any{"h": []any{"${upload_id}"}}, expected: map[string]any{"h": []any{"u-1"}}},
+		{name: "secret is kept for the executor", value: "Bearer ${secrets.API_TOKEN}", expected: "Bearer ${secrets.API_TOKEN}"},
+		{name: "legacy secret reference", value: "$secrets.API_TOKEN", expected: "${secrets.API_TOKEN}"},
+		{name: "secret inside function", value: "${secrets.API_TOKEN | base64}", expectErr: true},
+		{name: "secret without name", value: "${secrets}", expectErr: true},
+		{name: "missing reference", value: "${missing}", expectErr: true},
+		{name: "index out of range", value: "${ocr.pages[3].text}", expectErr: true},
+		{name: "unknown function", value: "${shout(upload_id)}", expectErr: true},
+		{name: "unterminated expression", value: "${upload_id", expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := renderValue(tc.value, bindings)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func TestCheckReferences(t *testing.T) {
+	wf := Workflow{
+		Variables: map[string]any{"hook": map[string]any{"url": "https://example.com"}},
+		Root: Statement{Sequence: &Sequence{Elements: []*Statement{
+			{Activity: &ActivityInvocation{Key: "ocr", Uses: "DetectDocumentTextV1"}},
+			{Activity: &ActivityInvocation{Key: "notify", Uses: "HTTP_V_01", With: map[string]any{
+				"url":  "${hook.url}?upload=${upload_id}",
+				"body": map[string]any{"text": "${ocr.text}", "name": "${default(nickname, file_name)}"},
+			}}},
+		}}},
+	}
+	assert.NoError(t, wf.checkReferences())
+
+	wf.Root.Sequence.Elements[1].Activity.With["extra"] = "${hok.url}"
+	wf.Root.Sequence.Elements[1].Activity.With["broken"] = "${lower(}"
+	err := wf.checkReferences()
+	assert.ErrorContains(t, err, `root.sequence.elements[1].activity.with.extra: unresolved reference "hok.url"`)
+	assert.ErrorContains(t, err, "root.sequence.elements[1].activity.with.broken")
+}
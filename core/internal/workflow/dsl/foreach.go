@@ -0,0 +1,156 @@
+package dsl
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+type ForEachErrorMode string
+
+const (
+	// ForEachFailFast cancels the remaining items as soon as one of them fails.
+	ForEachFailFast ForEachErrorMode = "fail_fast"
+	// ForEachCollectAll lets every item finish and reports all failures at the end.
+	ForEachCollectAll ForEachErrorMode = "collect_all"
+)
+
+const (
+	// MaxForEachItems bounds the fan out of a single foreach statement.
+	MaxForEachItems       = 1000
+	MaxForEachConcurrency = 50
+
+	DefaultForEachItemVariable  = "item"
+	DefaultForEachIndexVariable = "item_index"
+)
+
+func (f *ForEach) execute(ctx workflow.Context, bindings map[string]any) error {
+	raw, ok := lookupVariable(bindings, f.Items)
+	if !ok {
+		return fmt.Errorf("foreach items variable %q is not defined", f.Items)
+	}
+	items, ok := asList(raw)
+	if !ok {
+		return fmt.Errorf("foreach items variable %q is not a list", f.Items)
+	}
+	if len(items) > MaxForEachItems {
+		return fmt.Errorf("foreach over %q has %d items, the maximum is %d", f.Items, len(items), MaxForEachItems)
+	}
+
+	itemVariable, indexVariable := f.As, f.IndexAs
+	if itemVariable == "" {
+		itemVariable = DefaultForEachItemVariable
+	}
+	if indexVariable == "" {
+		indexVariable = DefaultForEachIndexVariable
+	}
+
+	concurrency := f.MaxConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if concurrency > MaxForEachConcurrency {
+		concurrency = MaxForEachConcurrency
+	}
+	failFast := f.ErrorMode != ForEachCollectAll
+
+	childCtx, cancelHandler := workflow.WithCancel(ctx)
+	defer cancelHandler()
+	selector := workflow.NewSelector(ctx)
+
+	results := make([]any, len(items))
+	itemErrs := make([]error, len(items))
+	var firstErr error
+	next, pending := 0, 0
+
+	start := func(i int) {
+		itemBindings := maps.Clone(bindings)
+		itemBindings[itemVariable] = items[i]
+		itemBindings[indexVariable] = i
+
+		pending++
+		selector.AddFuture(executeAsync(f.Body, childCtx, itemBindings), func(fut workflow.Future) {
+			pending--
+			itemErrs[i] = fut.Get(ctx, nil)
+			results[i] = changedBindings(bindings, itemBindings, itemVariable, indexVariable)
+			if itemErrs[i] != nil && firstErr == nil {
+				firstErr = fmt.Errorf("foreach item %d failed: %w", i, itemErrs[i])
+			}
+		})
+	}
+
+	for ; next < len(items) && pending < concurrency; next++ {
+		start(next)
+	}
+	for pending > 0 {
+		selector.Select(ctx)
+		if failFast && firstErr != nil {
+			cancelHandler()
+			f.record(bindings, results, itemErrs)
+			return firstErr
+		}
+		for ; next < len(items) && pending < concurrency; next++ {
+			start(next)
+		}
+	}
+
+	f.record(bindings, results, itemErrs)
+
+	var errs []error
+	for i, err := range itemErrs {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("foreach item %d failed: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// record stores the per item outputs and errors under the statement key so that
+// later statements can inspect them.
+func (f *ForEach) record(bindings map[string]any, results []any, itemErrs []error) {
+	if f.Key == "" {
+		return
+	}
+
+	var errList []any
+	failed := 0
+	for i, err := range itemErrs {
+		if err != nil {
+			failed++
+			errList = append(errList, map[string]any{"index": i, "error": err.Error()})
+		}
+	}
+
+	bindings[fmt.Sprintf("%s.results", f.Key)] = results
+	bindings[fmt.Sprintf("%s.errors", f.Key)] = errList
+	bindings[fmt.Sprintf("%s.count", f.Key)] = len(results)
+	bindings[fmt.Sprintf("%s.failed", f.Key)] = failed
+}
+
+// changedBindings returns the bindings that were added or modified by a branch
+// compared to the bindings it was cloned from.
+func changedBindings(base, branch map[string]any, ignore ...string) map[string]any {
+	changed := make(map[string]any)
+	for key, value := range branch {
+		if isIgnored(key, ignore) {
+			continue
+		}
+		if old, ok := base[key]; ok && reflect.DeepEqual(old, value) {
+			continue
+		}
+		changed[key] = value
+	}
+	return changed
+}
+
+func isIgnored(key string, ignore []string) bool {
+	for _, i := range ignore {
+		if key == i {
+			return true
+		}
+	}
+	return false
+}
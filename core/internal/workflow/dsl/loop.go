@@ -0,0 +1,76 @@
+package dsl
+
+import (
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+const (
+	// MaxLoopIterations caps every loop so that a bad break condition cannot
+	// grow the workflow history without bounds.
+	MaxLoopIterations = 100
+
+	DefaultLoopIndexVariable = "loop_index"
+)
+
+func (l *Loop) execute(ctx workflow.Context, bindings map[string]any) error {
+	if l.Iterations < 1 || l.Iterations > MaxLoopIterations {
+		return fmt.Errorf("loop iterations must be between 1 and %d, got %d", MaxLoopIterations, l.Iterations)
+	}
+
+	indexVariable := l.IndexVariable
+	if indexVariable == "" {
+		indexVariable = DefaultLoopIndexVariable
+	}
+
+	// restore the outer value of the index variable so that nested loops
+	// using the default name do not clobber each other
+	previous, hadPrevious := bindings[indexVariable]
+	defer func() {
+		if hadPrevious {
+			bindings[indexVariable] = previous
+		} else {
+			delete(bindings, indexVariable)
+		}
+	}()
+
+	for i := 0; i < l.Iterations; i++ {
+		bindings[indexVariable] = i
+		if err := l.Body.execute(ctx, bindings); err != nil {
+			return err
+		}
+
+		done, err := l.shouldBreak(bindings)
+		if err != nil {
+			return err
+		}
+		if done {
+			workflow.GetLogger(ctx).Info("Loop break condition met.", "Iteration", i)
+			return nil
+		}
+
+		if l.IntervalSeconds > 0 && i < l.Iterations-1 {
+			if err := workflow.Sleep(ctx, time.Duration(l.IntervalSeconds)*time.Second); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (l *Loop) shouldBreak(bindings map[string]any) (bool, error) {
+	if l.BreakVariable == "" {
+		return false, nil
+	}
+
+	cond := &Condition{Variable: l.BreakVariable, Operator: l.BreakOperator, Value: l.BreakValue}
+	if _, ok := lookupVariable(bindings, l.BreakVariable); !ok && cond.Operator != OpExists && cond.Operator != OpNotExists {
+		// the variable is usually the output of an activity in the body that
+		// has not produced it yet, so keep iterating
+		return false, nil
+	}
+	return cond.evaluate(bindings)
+}
@@ -0,0 +1,161 @@
+package dsl
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+type ParallelPolicy string
+
+const (
+	// ParallelFailFast cancels the remaining branches as soon as one of them fails.
+	ParallelFailFast ParallelPolicy = "fail_fast"
+	// ParallelAllSettled lets every branch finish and never fails, the branch
+	// errors are recorded under the statement key.
+	ParallelAllSettled ParallelPolicy = "all_settled"
+	// ParallelQuorum completes once quorum branches succeeded and fails as soon
+	// as the quorum can no longer be reached.
+	ParallelQuorum ParallelPolicy = "quorum"
+	// ParallelFirstSuccess completes with the first branch that succeeds.
+	ParallelFirstSuccess ParallelPolicy = "first_success"
+)
+
+var ParallelPolicies = []ParallelPolicy{ParallelFailFast, ParallelAllSettled, ParallelQuorum, ParallelFirstSuccess}
+
+const (
+	branchSucceeded = "succeeded"
+	branchFailed    = "failed"
+	branchCanceled  = "canceled"
+)
+
+// execute runs every branch on its own copy of the bindings. Once the policy
+// is settled the unfinished branches are cancelled and the bindings of the
+// successful branches are merged back in branch order, so a later branch wins
+// when two of them bind the same name regardless of which finished first.
+func (p *Parallel) execute(ctx workflow.Context, bindings map[string]any) error {
+	policy := p.Policy
+	if policy == "" {
+		policy = ParallelFailFast
+	}
+	required := len(p.Branches)
+	switch policy {
+	case ParallelQuorum:
+		required = p.Quorum
+	case ParallelFirstSuccess:
+		required = 1
+	}
+
+	childCtx, cancelHandler := workflow.WithCancel(ctx)
+	defer cancelHandler()
+	selector := workflow.NewSelector(ctx)
+
+	branchBindings := make([]map[string]any, len(p.Branches))
+	branchErrs := make([]error, len(p.Branches))
+	done := make([]bool, len(p.Branches))
+	succeeded, failed, winner := 0, 0, -1
+	var firstErr error
+
+	for i, stmt := range p.Branches {
+		branchBindings[i] = maps.Clone(bindings)
+		selector.AddFuture(executeAsync(stmt, childCtx, branchBindings[i]), func(f workflow.Future) {
+			done[i] = true
+			branchErrs[i] = f.Get(ctx, nil)
+			if branchErrs[i] != nil {
+				failed++
+				if firstErr == nil {
+					firstErr = branchErrs[i]
+				}
+				return
+			}
+			succeeded++
+			if winner < 0 {
+				winner = i
+			}
+		})
+	}
+
+	var err error
+	for pending := len(p.Branches); pending > 0; pending-- {
+		selector.Select(ctx)
+
+		if policy == ParallelFailFast && firstErr != nil {
+			err = firstErr
+			break
+		}
+		if policy == ParallelAllSettled {
+			continue
+		}
+		if succeeded >= required {
+			break
+		}
+		if failed > len(p.Branches)-required {
+			err = p.quorumError(succeeded, required, branchErrs)
+			break
+		}
+	}
+	cancelHandler()
+
+	changes := make([]map[string]any, len(p.Branches))
+	for i := range p.Branches {
+		if done[i] {
+			changes[i] = changedBindings(bindings, branchBindings[i])
+		}
+	}
+	for i, changed := range changes {
+		if done[i] && branchErrs[i] == nil {
+			maps.Copy(bindings, changed)
+		}
+	}
+	p.record(bindings, changes, done, branchErrs, winner)
+
+	return err
+}
+
+func (p *Parallel) quorumError(succeeded, required int, branchErrs []error) error {
+	errs := []error{fmt.Errorf("parallel: %d of %d branches succeeded, %d required", succeeded, len(p.Branches), required)}
+	for i, err := range branchErrs {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("parallel branch %d failed: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// record stores the per branch outputs, statuses and errors under the statement
+// key so that later statements can inspect them. Branches that were cancelled
+// have no results.
+func (p *Parallel) record(bindings map[string]any, changes []map[string]any, done []bool, branchErrs []error, winner int) {
+	if p.Key == "" {
+		return
+	}
+
+	results := make([]any, len(changes))
+	statuses := make([]any, len(changes))
+	var errList []any
+	succeeded, failed := 0, 0
+	for i, changed := range changes {
+		switch {
+		case !done[i]:
+			statuses[i] = branchCanceled
+		case branchErrs[i] != nil:
+			failed++
+			statuses[i] = branchFailed
+			results[i] = changed
+			errList = append(errList, map[string]any{"index": i, "error": branchErrs[i].Error()})
+		default:
+			succeeded++
+			statuses[i] = branchSucceeded
+			results[i] = changed
+		}
+	}
+
+	bindings[fmt.Sprintf("%s.results", p.Key)] = results
+	bindings[fmt.Sprintf("%s.statuses", p.Key)] = statuses
+	bindings[fmt.Sprintf("%s.errors", p.Key)] = errList
+	bindings[fmt.Sprintf("%s.succeeded", p.Key)] = succeeded
+	bindings[fmt.Sprintf("%s.failed", p.Key)] = failed
+	bindings[fmt.Sprintf("%s.winner", p.Key)] = winner
+}
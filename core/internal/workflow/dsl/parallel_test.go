@@ -0,0 +1,175 @@
+package dsl
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failProvider fails the activities whose provider argument is listed.
+func failProvider(providers ...string) func(string, map[string]any) (map[string]any, error) {
+	return func(uses string, in map[string]any) (map[string]any, error) {
+		for _, p := range providers {
+			for key, value := range in {
+				if value == p && key != "__uses" {
+					return nil, fmt.Errorf("%s is unavailable", p)
+				}
+			}
+		}
+		return map[string]any{"status_code": 200, "text": "hello"}, nil
+	}
+}
+
+const ocrRaceWorkflow = `
+variables: {}
+root:
+  sequence:
+    elements:
+      - parallel:
+          key: ocr
+          policy: %s
+          quorum: %d
+          branches:
+            - sequence:
+                elements:
+                  - sleep:
+                      duration: 1m
+                  - activity:
+                      key: slow_ocr
+                      uses: ExtractText
+                      with:
+                        provider: slow
+            - activity:
+                key: fast_ocr
+                uses: ExtractText
+                with:
+                  provider: fast
+            - activity:
+                key: backup_ocr
+                uses: ExtractText
+                with:
+                  provider: backup
+      - activity:
+          key: store
+          uses: HTTP_V_01
+          with:
+            url: https://example.com
+`
+
+func ocrRace(policy ParallelPolicy, quorum int) string {
+	return fmt.Sprintf(ocrRaceWorkflow, policy, quorum)
+}
+
+func TestParallelFirstSuccessKeepsFastestBranch(t *testing.T) {
+	exec := &fakeExecutor{respond: failProvider("fast")}
+	require.NoError(t, runDSLWorkflow(t, ocrRace(ParallelFirstSuccess, 0), exec))
+
+	// the slow branch is cancelled while it sleeps
+	assert.Len(t, exec.callsTo("ExtractText"), 2)
+	calls := exec.callsTo("HTTP_V_01")
+	require.Len(t, calls, 1)
+	assert.Equal(t, float64(2), calls[0]["ocr.winner"])
+	assert.Equal(t, "hello", calls[0]["backup_ocr.text"])
+	assert.NotContains(t, calls[0], "fast_ocr.text")
+	assert.Equal(t, []any{branchCanceled, branchFailed, branchSucceeded}, calls[0]["ocr.statuses"])
+	errs, ok := calls[0]["ocr.errors"].([]any)
+	require.True(t, ok)
+	require.Len(t, errs, 1)
+	assert.Equal(t, float64(1), errs[0].(map[string]any)["index"])
+	assert.Contains(t, errs[0].(map[string]any)["error"], "fast is unavailable")
+}
+
+func TestParallelQuorum(t *testing.T) {
+	t.Run("completes once enough branches succeeded", func(t *testing.T) {
+		exec := &fakeExecutor{respond: failProvider("fast")}
+		require.NoError(t, runDSLWorkflow(t, ocrRace(ParallelQuorum, 2), exec))
+
+		calls := exec.callsTo("HTTP_V_01")
+		require.Len(t, calls, 1)
+		assert.Equal(t, float64(2), calls[0]["ocr.succeeded"])
+		assert.Equal(t, float64(1), calls[0]["ocr.failed"])
+		assert.Equal(t, "hello", calls[0]["slow_ocr.text"])
+		assert.Equal(t, "hello", calls[0]["backup_ocr.text"])
+	})
+
+	t.Run("fails once the quorum cannot be reached", func(t *testing.T) {
+		exec := &fakeExecutor{respond: failProvider("fast", "backup")}
+		err := runDSLWorkflow(t, ocrRace(ParallelQuorum, 2), exec)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "parallel: 0 of 3 branches succeeded, 2 required")
+		assert.Contains(t, err.Error(), "parallel branch 2 failed")
+		assert.Empty(t, exec.callsTo("HTTP_V_01"))
+	})
+}
+
+func TestParallelAllSettledRecordsBranchErrors(t *testing.T) {
+	exec := &fakeExecutor{respond: failProvider("fast")}
+	require.NoError(t, runDSLWorkflow(t, ocrRace(ParallelAllSettled, 0), exec))
+
+	assert.Len(t, exec.callsTo("ExtractText"), 3)
+	calls := exec.callsTo("HTTP_V_01")
+	require.Len(t, calls, 1)
+	assert.Equal(t, []any{branchSucceeded, branchFailed, branchSucceeded}, calls[0]["ocr.statuses"])
+	assert.Equal(t, float64(1), calls[0]["ocr.failed"])
+	assert.Equal(t, "hello", calls[0]["slow_ocr.text"])
+	assert.Equal(t, "hello", calls[0]["backup_ocr.text"])
+
+	results, ok := calls[0]["ocr.results"].([]any)
+	require.True(t, ok)
+	require.Len(t, results, 3)
+	assert.Equal(t, "hello", results[0].(map[string]any)["slow_ocr.text"])
+	assert.NotContains(t, results[0], "backup_ocr.text")
+}
+
+func TestParallelFailFastCancelsOtherBranches(t *testing.T) {
+	exec := &fakeExecutor{respond: failProvider("fast")}
+	err := runDSLWorkflow(t, ocrRace(ParallelFailFast, 0), exec)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "fast is unavailable")
+	assert.Empty(t, exec.callsTo("HTTP_V_01"))
+	for _, call := range exec.callsTo("ExtractText") {
+		assert.NotEqual(t, "slow", call["slow_ocr.provider"])
+	}
+}
+
+func TestValidateParallel(t *testing.T) {
+	errs := validate(t, `
+variables: {}
+root:
+  sequence:
+    elements:
+      - parallel:
+          policy: fastest
+          branches:
+            - sleep:
+                duration: 1m
+      - parallel:
+          policy: quorum
+          quorum: 3
+          branches:
+            - sleep:
+                duration: 1m
+            - sleep:
+                duration: 1m
+      - parallel:
+          quorum: 1
+          branches:
+            - sleep:
+                duration: 1m
+      - parallel:
+          policy: all_settled
+          branches:
+            - sleep:
+                duration: 1m
+`)
+	assert.ElementsMatch(t, ValidationErrors{
+		{Path: "root.sequence.elements[0].parallel.policy", Message: `unknown policy "fastest"`},
+		{Path: "root.sequence.elements[1].parallel.quorum", Message: "must be between 1 and the number of branches (2)"},
+		{Path: "root.sequence.elements[2].parallel.quorum", Message: `is only allowed with the "quorum" policy`},
+		{Path: "root.sequence.elements[3].parallel.key", Message: `is required with the "all_settled" policy, branch errors are only recorded under the key`},
+	}, errs)
+}
@@ -0,0 +1,176 @@
+package dsl
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// workflowIdentifiers are the bindings added to every run by addWorkflowIdentifiersToBindings.
+var workflowIdentifiers = []string{
+	"workspace_id",
+	"upload_id",
+	"processor_id",
+	"file_name",
+	"content_type",
+	"workflow_id",
+	"run_id",
+	"current_activity_key",
+	"workflow_error",
+}
+
+// walkStatement calls fn for stmt and every statement nested in it. The path
+// passed to fn is the YAML location of the statement, e.g. root.sequence.elements[1].
+func walkStatement(path string, stmt *Statement, fn func(path string, stmt *Statement)) {
+	if stmt == nil {
+		return
+	}
+	fn(path, stmt)
+
+	if stmt.Activity != nil {
+		walkStatement(path+".activity.on_success", stmt.Activity.OnSuccess, fn)
+		walkStatement(path+".activity.on_error", stmt.Activity.OnError, fn)
+		walkStatement(path+".activity.compensate", stmt.Activity.Compensate, fn)
+	}
+	if stmt.Sequence != nil {
+		for i, el := range stmt.Sequence.Elements {
+			walkStatement(fmt.Sprintf("%s.sequence.elements[%d]", path, i), el, fn)
+		}
+	}
+	if stmt.Parallel != nil {
+		for i, br := range stmt.Parallel.Branches {
+			walkStatement(fmt.Sprintf("%s.parallel.branches[%d]", path, i), br, fn)
+		}
+	}
+	if stmt.Condition != nil {
+		walkStatement(path+".condition.then", stmt.Condition.Then, fn)
+		walkStatement(path+".condition.else", stmt.Condition.Else, fn)
+	}
+	if stmt.Loop != nil {
+		walkStatement(path+".loop.body", stmt.Loop.Body, fn)
+	}
+	if stmt.ForEach != nil {
+		walkStatement(path+".foreach.body", stmt.ForEach.Body, fn)
+	}
+	if stmt.Try != nil {
+		walkStatement(path+".try.body", stmt.Try.Body, fn)
+		walkStatement(path+".try.catch", stmt.Try.Catch, fn)
+		walkStatement(path+".try.finally", stmt.Try.Finally, fn)
+	}
+}
+
+// walk visits every statement of the workflow including the success and failure handlers.
+func (w *Workflow) walk(fn func(path string, stmt *Statement)) {
+	walkStatement("root", &w.Root, fn)
+	walkStatement("on_workflow_success", w.OnWorkflowSuccess, fn)
+	walkStatement("on_workflow_failure", w.OnWorkflowFailure, fn)
+}
+
+// bindingScope describes which names a template may reference.
+type bindingScope struct {
+	names   map[string]bool
+	outputs map[string]bool
+}
+
+func newBindingScope() *bindingScope {
+	s := &bindingScope{names: map[string]bool{}, outputs: map[string]bool{}}
+	for _, id := range workflowIdentifiers {
+		s.names[id] = true
+	}
+	return s
+}
+
+func (s *bindingScope) resolves(p *pathNode) bool {
+	names := p.leadingNames()
+	for j := len(names); j >= 1; j-- {
+		if s.names[strings.Join(names[:j], ".")] {
+			return true
+		}
+	}
+	return len(p.segments) > 1 && s.outputs[names[0]]
+}
+
+// staticScope collects every name the workflow can bind, regardless of the
+// order in which statements run.
+func (w *Workflow) staticScope() *bindingScope {
+	scope := newBindingScope()
+	for name := range w.Variables {
+		scope.names[name] = true
+	}
+
+	w.walk(func(_ string, stmt *Statement) {
+		switch {
+		case stmt.Activity != nil:
+			scope.outputs[stmt.Activity.Key] = true
+		case stmt.Loop != nil:
+			scope.names[orDefault(stmt.Loop.IndexVariable, DefaultLoopIndexVariable)] = true
+		case stmt.ForEach != nil:
+			scope.names[orDefault(stmt.ForEach.As, DefaultForEachItemVariable)] = true
+			scope.names[orDefault(stmt.ForEach.IndexAs, DefaultForEachIndexVariable)] = true
+			if stmt.ForEach.Key != "" {
+				scope.outputs[stmt.ForEach.Key] = true
+			}
+		case stmt.Parallel != nil:
+			if stmt.Parallel.Key != "" {
+				scope.outputs[stmt.Parallel.Key] = true
+			}
+		case stmt.Call != nil:
+			scope.outputs[stmt.Call.Key] = true
+		case stmt.Approval != nil:
+			scope.outputs[stmt.Approval.Key] = true
+		case stmt.Try != nil:
+			scope.outputs[stmt.Try.Key] = true
+		}
+	})
+
+	return scope
+}
+
+// checkReferences parses every activity and call argument and every templated
+// field of the other statements, and makes sure that all referenced names can be bound, so that a
+// typo fails the run before the first activity instead of halfway through it.
+func (w *Workflow) checkReferences() error {
+	scope := w.staticScope()
+
+	var errs []error
+	check := func(path string, value any) {
+		valueTemplates(value, path, func(argPath string, t *template, err error) {
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", argPath, err))
+				return
+			}
+			for _, ref := range t.references() {
+				if !ref.optional && !scope.resolves(ref.path) {
+					errs = append(errs, fmt.Errorf("%s: unresolved reference %q", argPath, ref.path.raw))
+				}
+			}
+		})
+	}
+	w.walk(func(path string, stmt *Statement) {
+		switch {
+		case stmt.Activity != nil:
+			for arg, value := range stmt.Activity.With {
+				check(fmt.Sprintf("%s.activity.with.%s", path, arg), value)
+			}
+		case stmt.Call != nil:
+			for arg, value := range stmt.Call.With {
+				check(fmt.Sprintf("%s.call.with.%s", path, arg), value)
+			}
+		case stmt.Approval != nil:
+			check(path+".approval.message", stmt.Approval.Message)
+		case stmt.Sleep != nil:
+			check(path+".sleep.duration", stmt.Sleep.Duration)
+		case stmt.WaitUntil != nil:
+			check(path+".wait_until.time", stmt.WaitUntil.Time)
+		}
+	})
+
+	return errors.Join(errs...)
+}
+
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
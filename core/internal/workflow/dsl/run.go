@@ -0,0 +1,70 @@
+package dsl
+
+import (
+	"time"
+
+	"github.com/uploadpilot/core/internal/db/models"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// RecordRunActivityName is the activity that saves the state of a run. The
+// workflow calls it when it starts and when it completes.
+const RecordRunActivityName = "RecordRun"
+
+// RunEvent is the state of a run sent to the RecordRun activity.
+type RunEvent struct {
+	WorkspaceID     string                   `json:"workspaceId"`
+	UploadID        string                   `json:"uploadId"`
+	ProcessorID     string                   `json:"processorId"`
+	WorkflowID      string                   `json:"workflowId"`
+	RunID           string                   `json:"runId"`
+	Attempt         int                      `json:"attempt"`
+	WorkflowVersion int                      `json:"workflowVersion"`
+	Status          models.RunStatus         `json:"status"`
+	Error           string                   `json:"error,omitempty"`
+	ArtifactKey     string                   `json:"artifactKey,omitempty"`
+	Artifacts       *models.ArtifactManifest `json:"artifacts,omitempty"`
+	StartedAt       time.Time                `json:"startedAt"`
+	FinishedAt      time.Time                `json:"finishedAt,omitempty"`
+}
+
+func newRunEvent(ctx workflow.Context, dslWorkflow Workflow) RunEvent {
+	info := workflow.GetInfo(ctx)
+	attempt := dslWorkflow.Attempt
+	if attempt == 0 {
+		attempt = 1
+	}
+	version := dslWorkflow.WorkflowVersion
+	if version == 0 {
+		version = 1
+	}
+	return RunEvent{
+		WorkspaceID:     dslWorkflow.WorkspaceID,
+		UploadID:        dslWorkflow.UploadID,
+		ProcessorID:     dslWorkflow.ProcessorID,
+		WorkflowID:      info.WorkflowExecution.ID,
+		RunID:           info.WorkflowExecution.RunID,
+		Attempt:         attempt,
+		WorkflowVersion: version,
+		Status:          models.RunStatusRunning,
+		StartedAt:       workflow.Now(ctx),
+	}
+}
+
+// recordRun saves the state of the run. Failing to save it does not fail the
+// run, the processing result matters more than its bookkeeping.
+func recordRun(ctx workflow.Context, run RunEvent) {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 30 * time.Second,
+		RetryPolicy: &temporal.RetryPolicy{
+			MaximumAttempts:    5,
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2,
+			MaximumInterval:    30 * time.Second,
+		},
+	})
+	if err := workflow.ExecuteActivity(ctx, RecordRunActivityName, run).Get(ctx, nil); err != nil {
+		workflow.GetLogger(ctx).Error("Failed to record run.", "Status", run.Status, "Error", err)
+	}
+}
@@ -17,20 +17,32 @@ const DSLSchema = `{
   "definitions": {
     "Statement": {
       "type": "object",
-      "description": "A building block of the workflow, which can be an activity, sequence, parallel execution, condition, or loop.",
+      "description": "A building block of the workflow, which can be an activity, sequence, parallel execution, condition, loop, foreach, call, approval, sleep, wait_until, or try.",
       "properties": {
         "activity": { "$ref": "#/definitions/ActivityInvocation" },
         "sequence": { "$ref": "#/definitions/Sequence" },
         "parallel": { "$ref": "#/definitions/Parallel" },
         "condition": { "$ref": "#/definitions/Condition" },
-        "loop": { "$ref": "#/definitions/Loop" }
+        "loop": { "$ref": "#/definitions/Loop" },
+        "foreach": { "$ref": "#/definitions/ForEach" },
+        "call": { "$ref": "#/definitions/Call" },
+        "approval": { "$ref": "#/definitions/Approval" },
+        "sleep": { "$ref": "#/definitions/Sleep" },
+        "wait_until": { "$ref": "#/definitions/WaitUntil" },
+        "try": { "$ref": "#/definitions/Try" }
       },
       "oneOf": [
         { "required": ["activity"] },
         { "required": ["sequence"] },
         { "required": ["parallel"] },
         { "required": ["condition"] },
-        { "required": ["loop"] }
+        { "required": ["loop"] },
+        { "required": ["foreach"] },
+        { "required": ["call"] },
+        { "required": ["approval"] },
+        { "required": ["sleep"] },
+        { "required": ["wait_until"] },
+        { "required": ["try"] }
       ]
     },
     "Sequence": {
@@ -48,6 +60,20 @@ const DSLSchema = `{
       "type": "object",
       "description": "A collection of statements that run in parallel.",
       "properties": {
+        "key": {
+          "type": "string",
+          "description": "Optional key under which per branch results, statuses and errors are saved."
+        },
+        "policy": {
+          "type": "string",
+          "enum": ["fail_fast", "all_settled", "quorum", "first_success"],
+          "description": "When the statement completes. fail_fast (default) fails on the first branch error, all_settled waits for every branch, quorum completes once quorum branches succeeded, first_success completes with the first successful branch."
+        },
+        "quorum": {
+          "type": "integer",
+          "minimum": 1,
+          "description": "Number of branches that must succeed with the quorum policy."
+        },
         "branches": {
           "type": "array",
           "items": { "$ref": "#/definitions/Statement" }
@@ -63,14 +89,18 @@ const DSLSchema = `{
           "type": "string",
           "description": "The variable to check."
         },
-        "value": {
+        "operator": {
           "type": "string",
-          "description": "The expected value to satisfy the condition."
+          "enum": ["==", "!=", "<", "<=", ">", ">=", "in", "not_in", "contains", "exists", "not_exists", "matches"],
+          "description": "The comparison to apply. Defaults to ==."
+        },
+        "value": {
+          "description": "The value to compare against. A list for in/not_in, a regular expression for matches."
         },
         "then": { "$ref": "#/definitions/Statement" },
         "else": { "$ref": "#/definitions/Statement" }
       },
-      "required": ["variable", "value", "then"]
+      "required": ["variable", "then"]
     },
     "Loop": {
       "type": "object",
@@ -79,20 +109,166 @@ const DSLSchema = `{
         "iterations": {
           "type": "integer",
           "minimum": 1,
+          "maximum": 100,
           "description": "Number of iterations to run."
         },
         "body": { "$ref": "#/definitions/Statement" },
+        "indexVariable": {
+          "type": "string",
+          "description": "The variable holding the zero based iteration index. Defaults to loop_index."
+        },
+        "intervalSeconds": {
+          "type": "integer",
+          "minimum": 0,
+          "description": "Time to wait between iterations."
+        },
         "breakVariable": {
           "type": "string",
           "description": "The variable to check for breaking the loop."
         },
-        "breakValue": {
+        "breakOperator": {
           "type": "string",
+          "enum": ["==", "!=", "<", "<=", ">", ">=", "in", "not_in", "contains", "exists", "not_exists", "matches"],
+          "description": "The comparison used for the break condition. Defaults to ==."
+        },
+        "breakValue": {
           "description": "The expected value to break the loop."
         }
       },
       "required": ["iterations", "body"]
     },
+    "ForEach": {
+      "type": "object",
+      "description": "Runs the body once for every element of a list.",
+      "properties": {
+        "key": {
+          "type": "string",
+          "description": "Optional key under which per item results and errors are saved."
+        },
+        "items": {
+          "type": "string",
+          "description": "The variable holding the list to iterate over."
+        },
+        "as": {
+          "type": "string",
+          "description": "The variable holding the current element. Defaults to item."
+        },
+        "index_as": {
+          "type": "string",
+          "description": "The variable holding the current index. Defaults to item_index."
+        },
+        "max_concurrency": {
+          "type": "integer",
+          "minimum": 1,
+          "maximum": 50,
+          "description": "Maximum number of items processed at the same time. Defaults to 1."
+        },
+        "error_mode": {
+          "type": "string",
+          "enum": ["fail_fast", "collect_all"],
+          "description": "fail_fast cancels remaining items on the first failure, collect_all runs every item and reports all failures."
+        },
+        "body": { "$ref": "#/definitions/Statement" }
+      },
+      "required": ["items", "body"]
+    },
+    "Call": {
+      "type": "object",
+      "description": "Runs the workflow of another processor or a snippet of the workspace as a child workflow.",
+      "properties": {
+        "key": {
+          "type": "string",
+          "description": "A unique key under which the outputs of the call are saved."
+        },
+        "processor": {
+          "type": "string",
+          "description": "The ID of the processor whose workflow is called."
+        },
+        "snippet": {
+          "type": "string",
+          "description": "The name of the workspace snippet that is called."
+        },
+        "with": {
+          "type": "object",
+          "description": "Values of the variables of the called workflow. String values may reference bindings with ${...} expressions."
+        },
+        "outputs": {
+          "type": "array",
+          "items": { "type": "string" },
+          "description": "Bindings of the called workflow saved as <key>.<output> once it completed."
+        }
+      },
+      "required": ["key"]
+    },
+    "Approval": {
+      "type": "object",
+      "description": "Pauses the workflow until a reviewer approves or rejects it through the API.",
+      "properties": {
+        "key": {
+          "type": "string",
+          "description": "A unique key under which the decision is saved."
+        },
+        "message": {
+          "type": "string",
+          "description": "Shown to the reviewer. May reference bindings with ${...} expressions."
+        },
+        "timeout_seconds": {
+          "type": "integer",
+          "minimum": 1,
+          "description": "Time to wait for a decision before the default action is taken."
+        },
+        "default_action": {
+          "type": "string",
+          "enum": ["approve", "reject"],
+          "description": "The action taken when no decision is made in time. Defaults to reject."
+        },
+        "continue_on_reject": {
+          "type": "boolean",
+          "description": "Whether the workflow continues after a rejection instead of failing."
+        }
+      },
+      "required": ["key", "timeout_seconds"]
+    },
+    "Sleep": {
+      "type": "object",
+      "description": "Pauses the workflow for a duration.",
+      "properties": {
+        "duration": {
+          "type": ["string", "number"],
+          "description": "A duration such as 10m or 1h30m, or a number of seconds. May reference bindings with ${...} expressions."
+        }
+      },
+      "required": ["duration"]
+    },
+    "WaitUntil": {
+      "type": "object",
+      "description": "Pauses the workflow until a point in time.",
+      "properties": {
+        "time": {
+          "type": "string",
+          "description": "An RFC 3339 timestamp or a time of day such as 02:00. May reference bindings with ${...} expressions."
+        },
+        "timezone": {
+          "type": "string",
+          "description": "The IANA timezone of a time of day. Defaults to UTC."
+        }
+      },
+      "required": ["time"]
+    },
+    "Try": {
+      "type": "object",
+      "description": "Runs a statement and handles its failure.",
+      "properties": {
+        "key": {
+          "type": "string",
+          "description": "A unique key under which failed, error.type and error.message are saved."
+        },
+        "body": { "$ref": "#/definitions/Statement" },
+        "catch": { "$ref": "#/definitions/Statement" },
+        "finally": { "$ref": "#/definitions/Statement" }
+      },
+      "required": ["key", "body"]
+    },
     "ActivityInvocation": {
       "type": "object",
       "description": "Defines an activity invocation with arguments and execution properties.",
@@ -107,19 +283,27 @@ const DSLSchema = `{
         },
         "with": {
           "type": "object",
-          "description": "Key-value pairs for activity parameters."
+          "description": "Key-value pairs for activity parameters. String values may reference bindings with ${...} expressions."
         },
         "input": {
           "type": "string",
           "description": "Optional input string for the activity."
         },
-        "scheduleToCloseTimeoutSeconds": { "type": "integer", "minimum": 1 },
-        "scheduleToStartTimeoutSeconds": { "type": "integer", "minimum": 1 },
-        "startToCloseTimeoutSeconds": { "type": "integer", "minimum": 1 },
-        "maxRetries": { "type": "integer", "minimum": 0 },
-        "retryBackoffCoefficient": { "type": "number", "minimum": 0 },
-        "retryMaxIntervalSeconds": { "type": "integer", "minimum": 1 },
-        "retryInitialIntervalSeconds": { "type": "integer", "minimum": 1 }
+        "save_output": {
+          "type": "boolean",
+          "description": "Whether the output of the activity is kept after the workflow finishes."
+        },
+        "schedule_to_close_timeout_seconds": { "type": "integer", "minimum": 1 },
+        "schedule_to_start_timeout_seconds": { "type": "integer", "minimum": 1 },
+        "start_to_close_timeout_seconds": { "type": "integer", "minimum": 1 },
+        "max_retries": { "type": "integer", "minimum": 0 },
+        "retry_backoff_coefficient": { "type": "number", "minimum": 1 },
+        "retry_max_interval_seconds": { "type": "integer", "minimum": 1 },
+        "retry_initial_interval_seconds": { "type": "integer", "minimum": 1 },
+        "compensate": {
+          "$ref": "#/definitions/Statement",
+          "description": "Undoes the work of the activity when a later statement fails."
+        }
       },
       "required": ["key", "uses"]
     }
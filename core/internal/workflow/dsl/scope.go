@@ -0,0 +1,26 @@
+package dsl
+
+import "go.temporal.io/sdk/workflow"
+
+const scopeCtxKey = WorkflowCtxKey("scope")
+
+// ActivityScope identifies the run an activity belongs to. It is passed to the
+// Executor activity next to the payload and is set from the workflow itself,
+// so that the workspace used for secrets and storage cannot be changed through
+// the bindings.
+type ActivityScope struct {
+	WorkspaceID string `json:"workspaceId"`
+	UploadID    string `json:"uploadId"`
+	ProcessorID string `json:"processorId"`
+	RunID       string `json:"runId"`
+	ActivityKey string `json:"activityKey,omitempty"`
+}
+
+func withScope(ctx workflow.Context, scope ActivityScope) workflow.Context {
+	return workflow.WithValue(ctx, scopeCtxKey, scope)
+}
+
+func scopeFromContext(ctx workflow.Context) ActivityScope {
+	scope, _ := ctx.Value(scopeCtxKey).(ActivityScope)
+	return scope
+}
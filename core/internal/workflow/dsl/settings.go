@@ -0,0 +1,173 @@
+package dsl
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/uploadpilot/core/internal/db/models"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+var artifactPrefixRegex = regexp.MustCompile(`^[A-Za-z0-9_.\-/]+$`)
+
+const (
+	defaultActivityTimeout = 24 * time.Hour
+	settingsCtxKey         = WorkflowCtxKey("settings")
+)
+
+// ExecutionSettings are the retry, timeout and artifact settings of a
+// processor. The retry and activity timeout settings are the defaults of every
+// activity, the values set on an activity in the workflow take precedence.
+// MaxRetries counts the retries after the first attempt, so the default of 3
+// gives every activity 4 attempts.
+type ExecutionSettings struct {
+	MaxRetries                      int32   `json:"maxRetries"`
+	RetryInitialIntervalSeconds     int64   `json:"retryInitialIntervalSeconds"`
+	RetryBackoffCoefficient         float64 `json:"retryBackoffCoefficient"`
+	RetryMaxIntervalSeconds         int64   `json:"retryMaxIntervalSeconds"`
+	ActivityTimeoutSeconds          int64   `json:"activityTimeoutSeconds"`
+	WorkflowRunTimeoutSeconds       int64   `json:"workflowRunTimeoutSeconds"`
+	WorkflowExecutionTimeoutSeconds int64   `json:"workflowExecutionTimeoutSeconds"`
+
+	ArtifactMode              models.ArtifactMode `json:"artifactMode,omitempty"`
+	ArtifactDestinationPrefix string              `json:"artifactDestinationPrefix,omitempty"`
+	DeriveUploads             bool                `json:"deriveUploads,omitempty"`
+}
+
+// Validate rejects settings with which no run can ever succeed. The paths are
+// the names of the settings in the processor API.
+func (s *ExecutionSettings) Validate() error {
+	v := &validator{}
+	if s.MaxRetries < 0 || s.MaxRetries > MaxActivityRetries {
+		v.errorf("maxRetries", "must be between 0 and %d", MaxActivityRetries)
+	}
+	if s.RetryBackoffCoefficient < 1 || s.RetryBackoffCoefficient > MaxRetryBackoff {
+		v.errorf("retryBackoffCoefficient", "must be between 1 and %d", MaxRetryBackoff)
+	}
+	v.timeout("retryInitialIntervalS", &s.RetryInitialIntervalSeconds)
+	v.timeout("retryMaxIntervalS", &s.RetryMaxIntervalSeconds)
+	if s.RetryMaxIntervalSeconds < s.RetryInitialIntervalSeconds {
+		v.errorf("retryMaxIntervalS", "must not be less than retryInitialIntervalS")
+	}
+	v.timeout("taskRunTimeoutS", &s.ActivityTimeoutSeconds)
+	v.timeout("workflowRunTimeoutS", &s.WorkflowRunTimeoutSeconds)
+	v.timeout("workflowExecutionTimeoutS", &s.WorkflowExecutionTimeoutSeconds)
+	if s.WorkflowRunTimeoutSeconds > s.WorkflowExecutionTimeoutSeconds {
+		v.errorf("workflowRunTimeoutS", "must not be greater than workflowExecutionTimeoutS")
+	}
+	if s.ActivityTimeoutSeconds > s.WorkflowRunTimeoutSeconds {
+		v.errorf("taskRunTimeoutS", "must not be greater than workflowRunTimeoutS, the activity could never finish")
+	}
+	if s.ArtifactMode != "" && !slices.Contains(models.ArtifactModes, s.ArtifactMode) {
+		v.errorf("artifactMode", "unknown artifact mode %q", s.ArtifactMode)
+	}
+	if s.ArtifactMode == models.ArtifactModeCopy {
+		if prefix := strings.Trim(s.ArtifactDestinationPrefix, "/"); prefix == "" {
+			v.errorf("artifactDestinationPrefix", "is required with the %q artifact mode", models.ArtifactModeCopy)
+		} else if !artifactPrefixRegex.MatchString(prefix) || strings.Contains(prefix, "..") {
+			v.errorf("artifactDestinationPrefix", "must only contain letters, digits, '-', '_', '.' and '/'")
+		}
+	}
+
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+// activityOptions returns the options of an activity: its own settings, else the
+// processor settings, else the defaults.
+func (a *ActivityInvocation) activityOptions(settings *ExecutionSettings) workflow.ActivityOptions {
+	ao := workflow.ActivityOptions{
+		StartToCloseTimeout:    defaultActivityTimeout,
+		ScheduleToCloseTimeout: defaultActivityTimeout,
+		ScheduleToStartTimeout: defaultActivityTimeout,
+		RetryPolicy: &temporal.RetryPolicy{
+			MaximumAttempts:    1,
+			InitialInterval:    0,
+			BackoffCoefficient: 2,
+			MaximumInterval:    1 * time.Minute,
+		},
+	}
+
+	if settings != nil {
+		if settings.ActivityTimeoutSeconds > 0 {
+			ao.StartToCloseTimeout = seconds(settings.ActivityTimeoutSeconds)
+		}
+		ao.RetryPolicy.MaximumAttempts = settings.MaxRetries + 1
+		if settings.RetryInitialIntervalSeconds > 0 {
+			ao.RetryPolicy.InitialInterval = seconds(settings.RetryInitialIntervalSeconds)
+		}
+		if settings.RetryBackoffCoefficient >= 1 {
+			ao.RetryPolicy.BackoffCoefficient = settings.RetryBackoffCoefficient
+		}
+		if settings.RetryMaxIntervalSeconds > 0 {
+			ao.RetryPolicy.MaximumInterval = seconds(settings.RetryMaxIntervalSeconds)
+		}
+	}
+
+	if a.StartToCloseTimeoutSeconds != nil && *a.StartToCloseTimeoutSeconds != 0 {
+		ao.StartToCloseTimeout = seconds(*a.StartToCloseTimeoutSeconds)
+	}
+	if a.ScheduleToCloseTimeoutSeconds != nil {
+		ao.ScheduleToCloseTimeout = seconds(*a.ScheduleToCloseTimeoutSeconds)
+	}
+	if a.ScheduleToStartTimeoutSeconds != nil {
+		ao.ScheduleToStartTimeout = seconds(*a.ScheduleToStartTimeoutSeconds)
+	}
+	// unlike the processor setting, max_retries of an activity has always been
+	// the maximum number of attempts, and is kept so for existing workflows
+	if a.MaxRetries != nil {
+		ao.RetryPolicy.MaximumAttempts = *a.MaxRetries
+	}
+	if a.RetryInitialIntervalSeconds != nil {
+		ao.RetryPolicy.InitialInterval = seconds(*a.RetryInitialIntervalSeconds)
+	}
+	if a.RetryBackoffCoefficient != nil {
+		ao.RetryPolicy.BackoffCoefficient = *a.RetryBackoffCoefficient
+	}
+	if a.RetryMaxIntervalSeconds != nil {
+		ao.RetryPolicy.MaximumInterval = seconds(*a.RetryMaxIntervalSeconds)
+	}
+	return ao
+}
+
+// checkSettings reports activity settings that cannot work together with the
+// processor settings.
+func (v *validator) checkSettings(path string, a *ActivityInvocation, settings *ExecutionSettings) {
+	if settings == nil {
+		return
+	}
+	ao := a.activityOptions(settings)
+	if a.StartToCloseTimeoutSeconds != nil && settings.WorkflowRunTimeoutSeconds > 0 &&
+		ao.StartToCloseTimeout > seconds(settings.WorkflowRunTimeoutSeconds) {
+		v.errorf(path+".start_to_close_timeout_seconds", "must not be greater than the workflow run timeout of the processor (%d seconds)",
+			settings.WorkflowRunTimeoutSeconds)
+	}
+	if a.RetryInitialIntervalSeconds != nil || a.RetryMaxIntervalSeconds != nil {
+		if ao.RetryPolicy.MaximumInterval < ao.RetryPolicy.InitialInterval {
+			v.errorf(path+".retry_max_interval_seconds", "must not be less than the retry initial interval (%s)",
+				ao.RetryPolicy.InitialInterval)
+		}
+	}
+}
+
+func withSettings(ctx workflow.Context, settings *ExecutionSettings) workflow.Context {
+	if settings == nil {
+		return ctx
+	}
+	return workflow.WithValue(ctx, settingsCtxKey, settings)
+}
+
+func settingsFromContext(ctx workflow.Context) *ExecutionSettings {
+	settings, _ := ctx.Value(settingsCtxKey).(*ExecutionSettings)
+	return settings
+}
+
+func seconds(s int64) time.Duration {
+	return time.Duration(s) * time.Second
+}
@@ -0,0 +1,93 @@
+package dsl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func testSettings() *ExecutionSettings {
+	return &ExecutionSettings{
+		MaxRetries:                      3,
+		RetryInitialIntervalSeconds:     1,
+		RetryBackoffCoefficient:         2,
+		RetryMaxIntervalSeconds:         60,
+		ActivityTimeoutSeconds:          600,
+		WorkflowRunTimeoutSeconds:       3600,
+		WorkflowExecutionTimeoutSeconds: 3600,
+	}
+}
+
+func TestExecutionSettingsValidate(t *testing.T) {
+	assert.NoError(t, testSettings().Validate())
+
+	settings := testSettings()
+	settings.MaxRetries = -1
+	settings.RetryMaxIntervalSeconds = 0
+	settings.ActivityTimeoutSeconds = 7200
+	settings.WorkflowExecutionTimeoutSeconds = 60
+	assert.ElementsMatch(t, ValidationErrors{
+		{Path: "maxRetries", Message: "must be between 0 and 100"},
+		{Path: "retryMaxIntervalS", Message: "must be between 1 and 604800 seconds"},
+		{Path: "retryMaxIntervalS", Message: "must not be less than retryInitialIntervalS"},
+		{Path: "workflowRunTimeoutS", Message: "must not be greater than workflowExecutionTimeoutS"},
+		{Path: "taskRunTimeoutS", Message: "must not be greater than workflowRunTimeoutS, the activity could never finish"},
+	}, settings.Validate())
+}
+
+func TestActivityOptionsPrecedence(t *testing.T) {
+	a := &ActivityInvocation{Key: "convert", Uses: "ImageFormatConvertorV1"}
+
+	ao := a.activityOptions(nil)
+	assert.Equal(t, 24*time.Hour, ao.StartToCloseTimeout)
+	assert.Equal(t, int32(1), ao.RetryPolicy.MaximumAttempts)
+
+	ao = a.activityOptions(testSettings())
+	assert.Equal(t, 10*time.Minute, ao.StartToCloseTimeout)
+	assert.Equal(t, int32(4), ao.RetryPolicy.MaximumAttempts)
+	assert.Equal(t, time.Second, ao.RetryPolicy.InitialInterval)
+	assert.Equal(t, time.Minute, ao.RetryPolicy.MaximumInterval)
+
+	timeout, retries, backoff := int64(30), int32(2), 1.5
+	a.StartToCloseTimeoutSeconds = &timeout
+	a.MaxRetries = &retries
+	a.RetryBackoffCoefficient = &backoff
+	ao = a.activityOptions(testSettings())
+	assert.Equal(t, 30*time.Second, ao.StartToCloseTimeout)
+	// max_retries of an activity is the number of attempts
+	assert.Equal(t, int32(2), ao.RetryPolicy.MaximumAttempts)
+	assert.Equal(t, 1.5, ao.RetryPolicy.BackoffCoefficient)
+	assert.Equal(t, time.Minute, ao.RetryPolicy.MaximumInterval)
+}
+
+func TestValidateActivitiesAgainstSettings(t *testing.T) {
+	var wf Workflow
+	require.NoError(t, yaml.Unmarshal([]byte(`
+variables: {}
+root:
+  sequence:
+    elements:
+      - activity:
+          key: convert
+          uses: ImageFormatConvertorV1
+          with:
+            format: png
+          start_to_close_timeout_seconds: 7200
+      - activity:
+          key: resize
+          uses: ImageResize@v1.0
+          with:
+            width: 10
+          retry_initial_interval_seconds: 120
+`), &wf))
+	require.NoError(t, wf.Validate())
+
+	wf.Settings = testSettings()
+	assert.ElementsMatch(t, ValidationErrors{
+		{Path: "root.sequence.elements[0].activity.start_to_close_timeout_seconds", Message: "must not be greater than the workflow run timeout of the processor (3600 seconds)"},
+		{Path: "root.sequence.elements[1].activity.retry_max_interval_seconds", Message: "must not be less than the retry initial interval (2m0s)"},
+	}, wf.Validate())
+}
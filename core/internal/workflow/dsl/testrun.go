@@ -0,0 +1,108 @@
+package dsl
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/uploadpilot/core/internal/db/models"
+	"go.temporal.io/sdk/workflow"
+)
+
+const traceCtxKey = WorkflowCtxKey("trace")
+
+// TestRunResult is the result of a test run. Test runs are not recorded as
+// runs, they return what every activity received and produced instead.
+// SkippedHooks lists the on_workflow_success and on_workflow_failure hooks that
+// the run would have started.
+type TestRunResult struct {
+	Status         models.RunStatus `json:"status"`
+	Error          string           `json:"error,omitempty"`
+	StartedAt      time.Time        `json:"startedAt"`
+	FinishedAt     time.Time        `json:"finishedAt"`
+	DurationMillis int64            `json:"durationMillis"`
+	Activities     []*ActivityTrace `json:"activities"`
+	SkippedHooks   []string         `json:"skippedHooks,omitempty"`
+}
+
+// ActivityTrace is one activity execution of a test run. Input holds the
+// rendered arguments, secrets are left as references.
+type ActivityTrace struct {
+	Key            string         `json:"key"`
+	Uses           string         `json:"uses"`
+	Input          map[string]any `json:"input"`
+	Output         map[string]any `json:"output,omitempty"`
+	Error          string         `json:"error,omitempty"`
+	StartedAt      time.Time      `json:"startedAt"`
+	FinishedAt     time.Time      `json:"finishedAt"`
+	DurationMillis int64          `json:"durationMillis"`
+}
+
+// testRun runs the workflow without recording the run or post processing its
+// outputs. A failed test run completes the workflow, the error is part of the
+// result.
+func (dslWorkflow Workflow) testRun(ctx workflow.Context) ([]byte, error) {
+	result := &TestRunResult{
+		Status:     models.RunStatusCompleted,
+		StartedAt:  workflow.Now(ctx),
+		Activities: []*ActivityTrace{},
+	}
+	ctx = workflow.WithValue(ctx, traceCtxKey, result)
+
+	if _, err := dslWorkflow.run(ctx); err != nil {
+		result.Status = models.RunStatusFailed
+		result.Error = err.Error()
+	}
+	result.FinishedAt = workflow.Now(ctx)
+	result.DurationMillis = result.FinishedAt.Sub(result.StartedAt).Milliseconds()
+	return json.Marshal(result)
+}
+
+// startTrace records the start of an activity when the workflow is a test run,
+// it returns nil otherwise.
+func startTrace(ctx workflow.Context, a *ActivityInvocation, bindings map[string]any) *ActivityTrace {
+	result, ok := ctx.Value(traceCtxKey).(*TestRunResult)
+	if !ok {
+		return nil
+	}
+	trace := &ActivityTrace{
+		Key:       a.Key,
+		Uses:      a.Uses,
+		Input:     make(map[string]any, len(a.With)),
+		StartedAt: workflow.Now(ctx),
+	}
+	for argument := range a.With {
+		trace.Input[argument] = bindings[fmt.Sprintf("%s.%s", a.Key, argument)]
+	}
+	if a.Input != nil {
+		trace.Input["input"] = *a.Input
+	}
+	result.Activities = append(result.Activities, trace)
+	return trace
+}
+
+// skipHook reports whether a workflow hook is skipped, which it is in test
+// runs as the hooks act outside of the run, e.g. notify or clean up. The
+// skipped hook is recorded in the result of the test run.
+func skipHook(ctx workflow.Context, name string) bool {
+	result, ok := ctx.Value(traceCtxKey).(*TestRunResult)
+	if !ok {
+		return false
+	}
+	result.SkippedHooks = append(result.SkippedHooks, name)
+	return true
+}
+
+func (t *ActivityTrace) finish(ctx workflow.Context, output []byte, err error) {
+	if t == nil {
+		return
+	}
+	t.FinishedAt = workflow.Now(ctx)
+	t.DurationMillis = t.FinishedAt.Sub(t.StartedAt).Milliseconds()
+	if err != nil {
+		t.Error = err.Error()
+	}
+	if len(output) > 0 {
+		_ = json.Unmarshal(output, &t.Output)
+	}
+}
@@ -0,0 +1,101 @@
+package dsl
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// MaxWaitSeconds is the longest a sleep, wait_until or approval may pause a run.
+const MaxWaitSeconds = 30 * 24 * 60 * 60
+
+var clockLayouts = []string{"15:04", "15:04:05"}
+
+func (s *Sleep) execute(ctx workflow.Context, bindings map[string]any) error {
+	rendered, err := renderValue(s.Duration, bindings)
+	if err != nil {
+		return fmt.Errorf("sleep: duration: %w", err)
+	}
+	d, err := parseDuration(rendered)
+	if err != nil {
+		return fmt.Errorf("sleep: %w", err)
+	}
+	return pause(ctx, bindings, d)
+}
+
+func (w *WaitUntil) execute(ctx workflow.Context, bindings map[string]any) error {
+	rendered, err := renderValue(w.Time, bindings)
+	if err != nil {
+		return fmt.Errorf("wait_until: time: %w", err)
+	}
+	at, err := parseWaitTime(stringify(rendered), w.Timezone, workflow.Now(ctx))
+	if err != nil {
+		return fmt.Errorf("wait_until: %w", err)
+	}
+	return pause(ctx, bindings, at.Sub(workflow.Now(ctx)))
+}
+
+// pause sleeps for d. The sleep ends with a canceled error when the run is
+// cancelled, which fails the statement like a cancelled activity does. Test
+// runs do not wait.
+func pause(ctx workflow.Context, bindings map[string]any, d time.Duration) error {
+	if d > time.Duration(MaxWaitSeconds)*time.Second {
+		return fmt.Errorf("cannot wait for %s, the maximum is %s", d, time.Duration(MaxWaitSeconds)*time.Second)
+	}
+	if testRun, _ := bindings["test_run"].(bool); testRun || d <= 0 {
+		return nil
+	}
+	return workflow.Sleep(ctx, d)
+}
+
+// parseDuration reads a Go duration such as 10m or 1h30m, numbers are seconds.
+func parseDuration(value any) (time.Duration, error) {
+	switch v := value.(type) {
+	case float64:
+		return time.Duration(v * float64(time.Second)), nil
+	case int:
+		return time.Duration(v) * time.Second, nil
+	case string:
+		if seconds, err := strconv.ParseFloat(v, 64); err == nil {
+			return time.Duration(seconds * float64(time.Second)), nil
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", v)
+		}
+		return d, nil
+	}
+	return 0, fmt.Errorf("invalid duration %v", value)
+}
+
+// parseWaitTime reads an RFC 3339 timestamp, or a time of day such as 02:00 in
+// the given timezone that resolves to its next occurrence after now.
+func parseWaitTime(value, timezone string, now time.Time) (time.Time, error) {
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return at, nil
+	}
+
+	location := time.UTC
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("unknown timezone %q", timezone)
+		}
+		location = loc
+	}
+	for _, layout := range clockLayouts {
+		clock, err := time.Parse(layout, value)
+		if err != nil {
+			continue
+		}
+		local := now.In(location)
+		at := time.Date(local.Year(), local.Month(), local.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, location)
+		if !at.After(local) {
+			at = at.AddDate(0, 0, 1)
+		}
+		return at, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, expected an RFC 3339 timestamp or a time of day like 02:00", value)
+}
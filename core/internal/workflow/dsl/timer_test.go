@@ -0,0 +1,106 @@
+package dsl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uploadpilot/core/internal/db/models"
+	"go.temporal.io/sdk/temporal"
+)
+
+const sleepWorkflow = `
+variables:
+  delay: 10m
+root:
+  sequence:
+    elements:
+      - sleep:
+          duration: ${delay}
+      - activity:
+          key: recheck
+          uses: HTTP_V_01
+          with:
+            url: https://example.com
+`
+
+func TestSleepPausesWorkflow(t *testing.T) {
+	exec := &fakeExecutor{}
+	env := newTestEnv(exec)
+	start := env.Now()
+
+	require.NoError(t, runInEnv(t, env, *parseWorkflow(t, sleepWorkflow)))
+
+	assert.Len(t, exec.callsTo("HTTP_V_01"), 1)
+	assert.GreaterOrEqual(t, env.Now().Sub(start), 10*time.Minute)
+}
+
+func TestSleepIsCancelledWithTheRun(t *testing.T) {
+	exec := &fakeExecutor{}
+	env := newTestEnv(exec)
+	env.RegisterDelayedCallback(env.CancelWorkflow, 5*time.Minute)
+
+	err := runInEnv(t, env, *parseWorkflow(t, sleepWorkflow))
+
+	var canceled *temporal.CanceledError
+	require.ErrorAs(t, err, &canceled)
+	assert.Empty(t, exec.callsTo("HTTP_V_01"))
+	require.Len(t, exec.runs, 2)
+	assert.Equal(t, models.RunStatusCanceled, exec.runs[1].Status)
+}
+
+func TestParseWaitTime(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 30, 0, 0, time.UTC)
+
+	cases := []struct {
+		value    string
+		timezone string
+		want     time.Time
+	}{
+		{"2026-03-11T08:00:00Z", "", time.Date(2026, 3, 11, 8, 0, 0, 0, time.UTC)},
+		{"14:00", "", time.Date(2026, 3, 10, 14, 0, 0, 0, time.UTC)},
+		{"02:00", "", time.Date(2026, 3, 11, 2, 0, 0, 0, time.UTC)},
+		{"12:30", "", time.Date(2026, 3, 11, 12, 30, 0, 0, time.UTC)},
+		// Berlin is one hour ahead of UTC in early March
+		{"13:45:10", "Europe/Berlin", time.Date(2026, 3, 10, 12, 45, 10, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		got, err := parseWaitTime(c.value, c.timezone, now)
+		require.NoError(t, err, c.value)
+		assert.True(t, c.want.Equal(got), "%s: want %s, got %s", c.value, c.want, got)
+	}
+
+	_, err := parseWaitTime("tomorrow", "", now)
+	assert.Error(t, err)
+}
+
+func TestValidateTimers(t *testing.T) {
+	errs := validate(t, `
+variables: {}
+root:
+  sequence:
+    elements:
+      - sleep:
+          duration: soon
+      - sleep:
+          duration: 0
+      - sleep:
+          duration: ${missing}
+      - wait_until:
+          time: 25:00
+      - wait_until:
+          time: "02:00"
+          timezone: Mars/Olympus
+      - wait_until:
+          time: "02:00"
+          timezone: Europe/Berlin
+`)
+	assert.ElementsMatch(t, ValidationErrors{
+		{Path: "root.sequence.elements[0].sleep.duration", Message: `invalid duration "soon"`},
+		{Path: "root.sequence.elements[1].sleep.duration", Message: "must be between 1 and 2592000 seconds"},
+		{Path: "root.sequence.elements[2].sleep.duration", Message: `reference "missing" is not defined at this point of the workflow`},
+		{Path: "root.sequence.elements[3].wait_until.time", Message: `invalid time "25:00", expected an RFC 3339 timestamp or a time of day like 02:00`},
+		{Path: "root.sequence.elements[4].wait_until.timezone", Message: `unknown timezone "Mars/Olympus"`},
+	}, errs)
+}
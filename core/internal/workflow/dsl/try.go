@@ -0,0 +1,54 @@
+package dsl
+
+import (
+	"fmt"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// execute runs the body and, when it fails, first the compensations of the
+// activities that completed in the body and then the catch statement. A catch
+// that succeeds handles the error. The finally statement runs in any case,
+// also when the run was cancelled, which is never caught.
+//
+// failed, error.type and error.message are bound under the key of the
+// statement before catch and finally run.
+func (t *Try) execute(ctx workflow.Context, bindings map[string]any) error {
+	bodyCtx, scope := withCompensations(ctx)
+	err := t.Body.execute(bodyCtx, bindings)
+	if err != nil {
+		err = scope.run(ctx, err)
+	} else {
+		scope.handOver(ctx)
+	}
+
+	bindings[fmt.Sprintf("%s.failed", t.Key)] = err != nil
+	bindings[fmt.Sprintf("%s.error.type", t.Key)] = ""
+	bindings[fmt.Sprintf("%s.error.message", t.Key)] = ""
+	if err != nil {
+		bindings[fmt.Sprintf("%s.error.type", t.Key)] = errorType(err)
+		bindings[fmt.Sprintf("%s.error.message", t.Key)] = err.Error()
+	}
+
+	if err != nil && t.Catch != nil && ctx.Err() == nil {
+		if catchErr := t.Catch.execute(ctx, bindings); catchErr != nil {
+			err = fmt.Errorf("try %s: catch failed: %w. original error: %w", t.Key, catchErr, err)
+		} else {
+			err = nil
+		}
+	}
+
+	if t.Finally != nil {
+		finallyCtx := ctx
+		if ctx.Err() != nil {
+			finallyCtx, _ = workflow.NewDisconnectedContext(ctx)
+		}
+		if finallyErr := t.Finally.execute(finallyCtx, bindings); finallyErr != nil {
+			if err != nil {
+				return fmt.Errorf("try %s: finally failed: %w. original error: %w", t.Key, finallyErr, err)
+			}
+			return fmt.Errorf("try %s: finally failed: %w", t.Key, finallyErr)
+		}
+	}
+	return err
+}
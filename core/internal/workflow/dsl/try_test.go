@@ -0,0 +1,188 @@
+package dsl
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// order returns the keys of the activities in the order they ran.
+func (f *fakeExecutor) order() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var keys []string
+	for _, c := range f.calls {
+		keys = append(keys, c["current_activity_key"].(string))
+	}
+	return keys
+}
+
+// failUses fails every call of the listed activities.
+func failUses(names ...string) func(string, map[string]any) (map[string]any, error) {
+	return func(uses string, in map[string]any) (map[string]any, error) {
+		for _, name := range names {
+			if uses == name {
+				return nil, errors.New(uses + " failed")
+			}
+		}
+		return map[string]any{"status_code": 200, "object": in["current_activity_key"]}, nil
+	}
+}
+
+const copyToBucketsWorkflow = `
+variables: {}
+root:
+  sequence:
+    elements:
+      - activity:
+          key: copy_a
+          uses: CopyObject
+          compensate:
+            activity:
+              key: delete_a
+              uses: DeleteObject
+              with:
+                object: ${copy_a.object}
+      - activity:
+          key: copy_b
+          uses: CopyObject
+          compensate:
+            activity:
+              key: delete_b
+              uses: DeleteObject
+              with:
+                object: ${copy_b.object}
+      - activity:
+          key: notify
+          uses: Notify
+`
+
+func TestCompensationsRunInReverseOrder(t *testing.T) {
+	exec := &fakeExecutor{respond: failUses("Notify")}
+	err := runDSLWorkflow(t, copyToBucketsWorkflow, exec)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Notify failed")
+	assert.Equal(t, []string{"copy_a", "copy_b", "notify", "delete_b", "delete_a"}, exec.order())
+	deletes := exec.callsTo("DeleteObject")
+	require.Len(t, deletes, 2)
+	assert.Equal(t, "copy_b", deletes[0]["delete_b.object"])
+	assert.Equal(t, "copy_a", deletes[1]["delete_a.object"])
+}
+
+func TestCompensationFailureIsReported(t *testing.T) {
+	exec := &fakeExecutor{respond: failUses("Notify", "DeleteObject")}
+	err := runDSLWorkflow(t, copyToBucketsWorkflow, exec)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Notify failed")
+	assert.Contains(t, err.Error(), "compensation of copy_b failed")
+	assert.Contains(t, err.Error(), "compensation of copy_a failed")
+}
+
+const tryWorkflow = `
+variables: {}
+root:
+  sequence:
+    elements:
+      - activity:
+          key: copy_before
+          uses: CopyObject
+          compensate:
+            activity:
+              key: delete_before
+              uses: DeleteObject
+      - try:
+          key: publish
+          body:
+            sequence:
+              elements:
+                - activity:
+                    key: copy_inside
+                    uses: CopyObject
+                    compensate:
+                      activity:
+                        key: delete_inside
+                        uses: DeleteObject
+                - activity:
+                    key: notify
+                    uses: Notify
+          catch:
+            activity:
+              key: report
+              uses: Report
+              with:
+                type: ${publish.error.type}
+                message: ${publish.error.message}
+          finally:
+            activity:
+              key: cleanup
+              uses: Cleanup
+              with:
+                failed: ${publish.failed}
+`
+
+func TestTryCatchFinally(t *testing.T) {
+	t.Run("catch handles the error after compensating the body", func(t *testing.T) {
+		exec := &fakeExecutor{respond: failUses("Notify")}
+		require.NoError(t, runDSLWorkflow(t, tryWorkflow, exec))
+
+		assert.Equal(t, []string{"copy_before", "copy_inside", "notify", "delete_inside", "report", "cleanup"}, exec.order())
+		report := exec.callsTo("Report")
+		require.Len(t, report, 1)
+		assert.Equal(t, ErrorTypeActivity, report[0]["report.type"])
+		assert.Contains(t, report[0]["report.message"], "Notify failed")
+		assert.Equal(t, true, exec.callsTo("Cleanup")[0]["cleanup.failed"])
+	})
+
+	t.Run("finally runs when the body succeeds", func(t *testing.T) {
+		exec := &fakeExecutor{}
+		require.NoError(t, runDSLWorkflow(t, tryWorkflow, exec))
+
+		assert.Equal(t, []string{"copy_before", "copy_inside", "notify", "cleanup"}, exec.order())
+		assert.Equal(t, false, exec.callsTo("Cleanup")[0]["cleanup.failed"])
+	})
+
+	t.Run("a failing catch fails the workflow and compensates the rest", func(t *testing.T) {
+		exec := &fakeExecutor{respond: failUses("Notify", "Report")}
+		err := runDSLWorkflow(t, tryWorkflow, exec)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "try publish: catch failed")
+		assert.Equal(t, []string{"copy_before", "copy_inside", "notify", "delete_inside", "report", "cleanup", "delete_before"}, exec.order())
+	})
+}
+
+func TestValidateTry(t *testing.T) {
+	errs := validate(t, `
+variables: {}
+root:
+  sequence:
+    elements:
+      - try:
+          key: attempt
+          body:
+            activity:
+              key: copy
+              uses: HTTP_V_01
+              with:
+                url: https://example.com
+              compensate:
+                activity:
+                  key: undo
+                  uses: HTTP_V_01
+                  with:
+                    url: ${later.body}
+      - activity:
+          key: later
+          uses: HTTP_V_01
+          with:
+            url: https://example.com/${attempt.error.message}
+`)
+	assert.ElementsMatch(t, ValidationErrors{
+		{Path: "root.sequence.elements[0].try", Message: "must define catch, finally or both"},
+		{Path: "root.sequence.elements[0].try.body.activity.compensate.activity.with.url", Message: `reference "later.body" is not defined at this point of the workflow`},
+	}, errs)
+}
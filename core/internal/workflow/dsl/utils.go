@@ -3,9 +3,9 @@ package dsl
 import (
 	"encoding/json"
 	"fmt"
-	"time"
+	"strings"
 
-	"go.temporal.io/sdk/temporal"
+	"github.com/uploadpilot/core/internal/workflow/catalog"
 	"go.temporal.io/sdk/workflow"
 )
 
@@ -17,17 +17,27 @@ func addWorkflowIdentifiersToBindings(ctx workflow.Context, bindings map[string]
 	bindings["content_type"] = dslWorkflow.ContentType
 	bindings["workflow_id"] = workflow.GetInfo(ctx).WorkflowExecution.ID
 	bindings["run_id"] = workflow.GetInfo(ctx).WorkflowExecution.RunID
+	if dslWorkflow.TestRun {
+		bindings["test_run"] = true
+	}
 }
 
-func makeInput(argMap map[string]any, bindings map[string]any, activityKey string, saveOutput *bool, inputActivityKey *string) (string, error) {
+func makeInput(uses string, argMap map[string]any, bindings map[string]any, activityKey string, saveOutput *bool, inputActivityKey *string) (string, error) {
+	// render every argument against the same bindings before saving any of them,
+	// so the result does not depend on map iteration order
+	args := make(map[string]any, len(argMap))
 	for argument, value := range argMap {
-		val, ok := value.(string)
-		if ok && val[0] == '$' {
-			varName := val[1:]
-			bindings[fmt.Sprintf("%s.%s", activityKey, argument)] = bindings[varName]
-		} else {
-			bindings[fmt.Sprintf("%s.%s", activityKey, argument)] = value
+		rendered, err := renderValue(value, bindings)
+		if err != nil {
+			return "", fmt.Errorf("activity %s: argument %s: %w", activityKey, argument, err)
 		}
+		args[argument] = rendered
+	}
+	if err := checkArguments(uses, activityKey, args); err != nil {
+		return "", err
+	}
+	for argument, value := range args {
+		bindings[fmt.Sprintf("%s.%s", activityKey, argument)] = value
 	}
 
 	bindings["current_activity_key"] = activityKey
@@ -50,35 +60,29 @@ func makeInput(argMap map[string]any, bindings map[string]any, activityKey strin
 	return string(argsbytes), nil
 }
 
-func saveOutput(result map[string]any, bindings map[string]any, activityKey string) {
-	for key, value := range result {
-		bindings[fmt.Sprintf("%s.%s", activityKey, key)] = value
+// checkArguments validates the rendered arguments against the input schema of
+// the activity so that bad values fail the run before the activity is scheduled.
+func checkArguments(uses, activityKey string, args map[string]any) error {
+	meta, ok := catalog.GetActivity(uses)
+	if !ok {
+		return nil
 	}
-}
-
-func runPostProcessingActivity(ctx workflow.Context, bindings map[string]any) error {
-	ao := workflow.ActivityOptions{}
-	ao.RetryPolicy = &temporal.RetryPolicy{
-		MaximumAttempts:    1,
-		InitialInterval:    0,
-		BackoffCoefficient: 2,
-		MaximumInterval:    1 * time.Minute,
-	}
-	ao.ScheduleToStartTimeout = 24 * time.Hour
-	ao.StartToCloseTimeout = 24 * time.Hour
-	ao.ScheduleToCloseTimeout = 24 * time.Hour
-
-	ctx = workflow.WithActivityOptions(ctx, ao)
-
-	bindingsB, err := json.Marshal(bindings)
+	errs, err := meta.CheckInput(args)
 	if err != nil {
 		return err
 	}
-
-	err = workflow.ExecuteActivity(ctx, "Executor", "PostProcessingV1", string(bindingsB)).Get(ctx, nil)
-	if err != nil {
-		return err
+	if len(errs) == 0 {
+		return nil
+	}
+	msgs := make([]string, 0, len(errs))
+	for _, e := range errs {
+		msgs = append(msgs, e.Error())
 	}
+	return fmt.Errorf("activity %s: invalid arguments for %s: %s", activityKey, uses, strings.Join(msgs, "; "))
+}
 
-	return nil
+func saveOutput(result map[string]any, bindings map[string]any, activityKey string) {
+	for key, value := range result {
+		bindings[fmt.Sprintf("%s.%s", activityKey, key)] = value
+	}
 }
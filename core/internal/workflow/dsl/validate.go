@@ -0,0 +1,518 @@
+package dsl
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uploadpilot/core/internal/workflow/catalog"
+)
+
+const (
+	// MaxActivityTimeoutSeconds is the longest timeout an activity may ask for.
+	MaxActivityTimeoutSeconds = 7 * 24 * 60 * 60
+	MaxActivityRetries        = 100
+	MaxRetryBackoff           = 100
+)
+
+var activityKeyRegex = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+type ValidationError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+type ValidationErrors []ValidationError
+
+func (v ValidationErrors) Error() string {
+	var b strings.Builder
+	b.WriteString("workflow is not valid:")
+	for _, e := range v {
+		b.WriteString("\n- ")
+		b.WriteString(e.Error())
+	}
+	return b.String()
+}
+
+type validator struct {
+	errs     ValidationErrors
+	keys     map[string]string
+	settings *ExecutionSettings
+}
+
+// reserved reports a variable name that would shadow the secrets or the
+// bindings the run sets itself, e.g. a foreach item named workspace_id.
+func (v *validator) reserved(path, name string) {
+	switch {
+	case name == secretsRoot:
+		v.errorf(path, "%s is reserved for workspace secrets", name)
+	case name == "test_run" || slices.Contains(workflowIdentifiers, name):
+		v.errorf(path, "%s is reserved for the workflow identifiers", name)
+	}
+}
+
+// Validate checks the workflow for problems the JSON schema cannot express:
+// unknown activities, duplicate keys, references to names that are not bound
+// yet at that point of the run and out of range timeouts. All problems are
+// reported together, each with its YAML path. When the processor settings are
+// set, the activities are also checked against them.
+func (w *Workflow) Validate() error {
+	v := &validator{keys: map[string]string{}, settings: w.Settings}
+
+	scope := newBindingScope()
+	for name := range w.Variables {
+		v.reserved("variables."+name, name)
+		scope.names[name] = true
+	}
+
+	after := v.statement("root", &w.Root, scope)
+	if w.OnWorkflowSuccess != nil {
+		v.statement("on_workflow_success", w.OnWorkflowSuccess, after)
+	}
+	if w.OnWorkflowFailure != nil {
+		v.statement("on_workflow_failure", w.OnWorkflowFailure, after)
+	}
+
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+func (v *validator) errorf(path, format string, args ...any) {
+	v.errs = append(v.errs, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// statement validates stmt with the names bound before it runs and returns the
+// names bound once it has finished.
+func (v *validator) statement(path string, stmt *Statement, scope *bindingScope) *bindingScope {
+	if stmt == nil {
+		v.errorf(path, "statement is missing")
+		return scope
+	}
+
+	kinds := 0
+	for _, set := range []bool{stmt.Activity != nil, stmt.Sequence != nil, stmt.Parallel != nil,
+		stmt.Condition != nil, stmt.Loop != nil, stmt.ForEach != nil, stmt.Call != nil, stmt.Approval != nil,
+		stmt.Sleep != nil, stmt.WaitUntil != nil, stmt.Try != nil} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		v.errorf(path, "statement must define exactly one of activity, sequence, parallel, condition, loop, foreach, call, approval, sleep, wait_until or try")
+		return scope
+	}
+
+	switch {
+	case stmt.Activity != nil:
+		return v.activity(path+".activity", stmt.Activity, scope)
+
+	case stmt.Sequence != nil:
+		for i, el := range stmt.Sequence.Elements {
+			scope = v.statement(fmt.Sprintf("%s.sequence.elements[%d]", path, i), el, scope)
+		}
+		return scope
+
+	case stmt.Parallel != nil:
+		p := stmt.Parallel
+		ppath := path + ".parallel"
+		if p.Policy != "" && !slices.Contains(ParallelPolicies, p.Policy) {
+			v.errorf(ppath+".policy", "unknown policy %q", p.Policy)
+		}
+		if p.Policy == ParallelQuorum {
+			if p.Quorum < 1 || p.Quorum > len(p.Branches) {
+				v.errorf(ppath+".quorum", "must be between 1 and the number of branches (%d)", len(p.Branches))
+			}
+		} else if p.Quorum != 0 {
+			v.errorf(ppath+".quorum", "is only allowed with the %q policy", ParallelQuorum)
+		}
+		if p.Policy == ParallelAllSettled && p.Key == "" {
+			v.errorf(ppath+".key", "is required with the %q policy, branch errors are only recorded under the key", ParallelAllSettled)
+		}
+		if p.Key != "" {
+			v.key(ppath+".key", p.Key)
+		}
+
+		// branches run side by side, so they cannot see each other's outputs
+		result := scope.clone()
+		for i, br := range p.Branches {
+			result.merge(v.statement(fmt.Sprintf("%s.branches[%d]", ppath, i), br, scope.clone()))
+		}
+		if p.Key != "" {
+			result.outputs[p.Key] = true
+		}
+		return result
+
+	case stmt.Condition != nil:
+		c := stmt.Condition
+		cpath := path + ".condition"
+		if c.Operator != "" && !slices.Contains(ConditionOperators, c.Operator) {
+			v.errorf(cpath+".operator", "unknown operator %q", c.Operator)
+		}
+		if c.Operator == OpMatches {
+			if pattern, ok := c.Value.(string); !ok {
+				v.errorf(cpath+".value", "value must be a regular expression")
+			} else if _, err := regexp.Compile(pattern); err != nil {
+				v.errorf(cpath+".value", "invalid regular expression: %s", err)
+			}
+		}
+		if (c.Operator == OpIn || c.Operator == OpNotIn) && c.Value != nil {
+			if _, ok := asList(c.Value); !ok {
+				v.errorf(cpath+".value", "value for operator %q must be a list", c.Operator)
+			}
+		}
+		if c.Operator != OpExists && c.Operator != OpNotExists {
+			v.variable(cpath+".variable", c.Variable, scope)
+		}
+		result := scope.clone()
+		result.merge(v.statement(cpath+".then", c.Then, scope.clone()))
+		if c.Else != nil {
+			result.merge(v.statement(cpath+".else", c.Else, scope.clone()))
+		}
+		return result
+
+	case stmt.Loop != nil:
+		l := stmt.Loop
+		lpath := path + ".loop"
+		if l.Iterations < 1 || l.Iterations > MaxLoopIterations {
+			v.errorf(lpath+".iterations", "must be between 1 and %d", MaxLoopIterations)
+		}
+		if l.IntervalSeconds < 0 || l.IntervalSeconds > MaxActivityTimeoutSeconds {
+			v.errorf(lpath+".intervalSeconds", "must be between 0 and %d", MaxActivityTimeoutSeconds)
+		}
+		if l.BreakOperator != "" && !slices.Contains(ConditionOperators, l.BreakOperator) {
+			v.errorf(lpath+".breakOperator", "unknown operator %q", l.BreakOperator)
+		}
+		if l.IndexVariable != "" {
+			v.reserved(lpath+".indexVariable", l.IndexVariable)
+		}
+		body := scope.clone()
+		body.names[orDefault(l.IndexVariable, DefaultLoopIndexVariable)] = true
+		result := v.statement(lpath+".body", l.Body, body)
+		if l.BreakVariable != "" && l.BreakOperator != OpExists && l.BreakOperator != OpNotExists {
+			v.variable(lpath+".breakVariable", l.BreakVariable, result)
+		}
+		return result
+
+	case stmt.ForEach != nil:
+		f := stmt.ForEach
+		fpath := path + ".foreach"
+		v.variable(fpath+".items", f.Items, scope)
+		if f.MaxConcurrency < 0 || f.MaxConcurrency > MaxForEachConcurrency {
+			v.errorf(fpath+".max_concurrency", "must be between 1 and %d", MaxForEachConcurrency)
+		}
+		if f.ErrorMode != "" && f.ErrorMode != ForEachFailFast && f.ErrorMode != ForEachCollectAll {
+			v.errorf(fpath+".error_mode", "must be %q or %q", ForEachFailFast, ForEachCollectAll)
+		}
+		if f.Key != "" {
+			v.key(fpath+".key", f.Key)
+		}
+		if f.As != "" {
+			v.reserved(fpath+".as", f.As)
+		}
+		if f.IndexAs != "" {
+			v.reserved(fpath+".index_as", f.IndexAs)
+		}
+		body := scope.clone()
+		body.names[orDefault(f.As, DefaultForEachItemVariable)] = true
+		body.names[orDefault(f.IndexAs, DefaultForEachIndexVariable)] = true
+		v.statement(fpath+".body", f.Body, body)
+
+		// every item runs on its own copy of the bindings, only the summary is kept
+		result := scope.clone()
+		if f.Key != "" {
+			result.outputs[f.Key] = true
+		}
+		return result
+
+	case stmt.Call != nil:
+		return v.call(path+".call", stmt.Call, scope)
+
+	case stmt.Approval != nil:
+		return v.approval(path+".approval", stmt.Approval, scope)
+
+	case stmt.Sleep != nil:
+		spath := path + ".sleep.duration"
+		v.templates(spath, stmt.Sleep.Duration, scope)
+		if stmt.Sleep.Duration == "" {
+			v.errorf(spath, "is required")
+		} else if !isTemplated(stmt.Sleep.Duration) {
+			if d, err := parseDuration(stmt.Sleep.Duration); err != nil {
+				v.errorf(spath, "%s", err)
+			} else {
+				v.wait(spath, d)
+			}
+		}
+		return scope
+
+	case stmt.Try != nil:
+		return v.try(path+".try", stmt.Try, scope)
+
+	case stmt.WaitUntil != nil:
+		w := stmt.WaitUntil
+		wpath := path + ".wait_until"
+		v.templates(wpath+".time", w.Time, scope)
+		if w.Timezone != "" {
+			if _, err := time.LoadLocation(w.Timezone); err != nil {
+				v.errorf(wpath+".timezone", "unknown timezone %q", w.Timezone)
+				return scope
+			}
+		}
+		if w.Time == "" {
+			v.errorf(wpath+".time", "is required")
+		} else if !isTemplated(w.Time) {
+			if _, err := parseWaitTime(w.Time, w.Timezone, time.Now()); err != nil {
+				v.errorf(wpath+".time", "%s", err)
+			}
+		}
+		return scope
+	}
+
+	return scope
+}
+
+// call checks a call statement on its own, the called workflow is checked by
+// Workflow.ResolveCalls as it has to be loaded first.
+func (v *validator) call(path string, c *Call, scope *bindingScope) *bindingScope {
+	v.key(path+".key", c.Key)
+	if (c.Processor == "") == (c.Snippet == "") {
+		v.errorf(path, "call must define exactly one of processor or snippet")
+	} else if c.Processor != "" && uuid.Validate(c.Processor) != nil {
+		v.errorf(path+".processor", "%q is not a processor id", c.Processor)
+	}
+
+	for _, arg := range slices.Sorted(maps.Keys(c.With)) {
+		v.templates(fmt.Sprintf("%s.with.%s", path, arg), c.With[arg], scope)
+	}
+	for i, output := range c.Outputs {
+		if _, err := newExprParser(output).parsePathOnly(); err != nil || output == "" {
+			v.errorf(fmt.Sprintf("%s.outputs[%d]", path, i), "invalid binding name %q", output)
+		}
+	}
+
+	result := scope.clone()
+	if c.Key != "" {
+		result.outputs[c.Key] = true
+	}
+	return result
+}
+
+func (v *validator) activity(path string, a *ActivityInvocation, scope *bindingScope) *bindingScope {
+	v.key(path+".key", a.Key)
+
+	if a.Uses == "" {
+		v.errorf(path+".uses", "is required")
+	} else if meta, ok := catalog.GetActivity(a.Uses); !ok {
+		v.errorf(path+".uses", "unknown activity %q", a.Uses)
+	} else {
+		v.arguments(path+".with", meta, a.With)
+	}
+
+	if a.Input != nil && *a.Input != "" && !scope.outputs[*a.Input] {
+		v.errorf(path+".input", "activity %q has not run before this activity", *a.Input)
+	}
+
+	for _, arg := range slices.Sorted(maps.Keys(a.With)) {
+		v.templates(fmt.Sprintf("%s.with.%s", path, arg), a.With[arg], scope)
+	}
+
+	v.timeout(path+".schedule_to_close_timeout_seconds", a.ScheduleToCloseTimeoutSeconds)
+	v.timeout(path+".schedule_to_start_timeout_seconds", a.ScheduleToStartTimeoutSeconds)
+	v.timeout(path+".start_to_close_timeout_seconds", a.StartToCloseTimeoutSeconds)
+	if a.StartToCloseTimeoutSeconds != nil && a.ScheduleToCloseTimeoutSeconds != nil &&
+		*a.StartToCloseTimeoutSeconds > *a.ScheduleToCloseTimeoutSeconds {
+		v.errorf(path+".start_to_close_timeout_seconds", "must not be greater than schedule_to_close_timeout_seconds")
+	}
+
+	if a.MaxRetries != nil && (*a.MaxRetries < 0 || *a.MaxRetries > MaxActivityRetries) {
+		v.errorf(path+".max_retries", "must be between 0 and %d", MaxActivityRetries)
+	}
+	if a.RetryBackoffCoefficient != nil && (*a.RetryBackoffCoefficient < 1 || *a.RetryBackoffCoefficient > MaxRetryBackoff) {
+		v.errorf(path+".retry_backoff_coefficient", "must be between 1 and %d", MaxRetryBackoff)
+	}
+	v.timeout(path+".retry_initial_interval_seconds", a.RetryInitialIntervalSeconds)
+	v.timeout(path+".retry_max_interval_seconds", a.RetryMaxIntervalSeconds)
+	if a.RetryInitialIntervalSeconds != nil && a.RetryMaxIntervalSeconds != nil &&
+		*a.RetryMaxIntervalSeconds < *a.RetryInitialIntervalSeconds {
+		v.errorf(path+".retry_max_interval_seconds", "must not be less than retry_initial_interval_seconds")
+	}
+	v.checkSettings(path, a, v.settings)
+
+	result := scope.clone()
+	if a.Key != "" {
+		result.outputs[a.Key] = true
+	}
+	if a.OnSuccess != nil {
+		result = v.statement(path+".on_success", a.OnSuccess, result)
+	}
+	if a.OnError != nil {
+		result.merge(v.statement(path+".on_error", a.OnError, scope.clone()))
+	}
+	if a.Compensate != nil {
+		// runs after a later statement failed, its bindings are discarded
+		compensate := scope.clone()
+		compensate.outputs[a.Key] = true
+		v.statement(path+".compensate", a.Compensate, compensate)
+	}
+	return result
+}
+
+func (v *validator) try(path string, t *Try, scope *bindingScope) *bindingScope {
+	v.key(path+".key", t.Key)
+	if t.Catch == nil && t.Finally == nil {
+		v.errorf(path, "must define catch, finally or both")
+	}
+
+	// catch and finally may run after any statement of the body failed
+	handler := scope.clone()
+	if t.Key != "" {
+		handler.outputs[t.Key] = true
+	}
+	result := handler.clone()
+	result.merge(v.statement(path+".body", t.Body, scope.clone()))
+	if t.Catch != nil {
+		result.merge(v.statement(path+".catch", t.Catch, handler.clone()))
+	}
+	if t.Finally != nil {
+		result.merge(v.statement(path+".finally", t.Finally, handler.clone()))
+	}
+	return result
+}
+
+// arguments checks the `with` block against the input schema of the activity.
+// Templated arguments are only known when the workflow runs, so they are left
+// out here and checked again before the activity is scheduled.
+func (v *validator) arguments(path string, meta *catalog.ActivityMetadata, with map[string]any) {
+	static := make(map[string]any, len(with))
+	templated := map[string]bool{}
+	for arg, value := range with {
+		if isTemplated(value) {
+			templated[arg] = true
+			continue
+		}
+		static[arg] = value
+	}
+
+	errs, err := meta.CheckInput(static)
+	if err != nil {
+		v.errorf(path, "%s", err)
+		return
+	}
+	for _, e := range errs {
+		if templated[e.Property] {
+			continue
+		}
+		if e.Field == "" {
+			v.errorf(path, "%s", e.Message)
+		} else {
+			v.errorf(path+"."+e.Field, "%s", e.Message)
+		}
+	}
+}
+
+// wait checks a fixed pause against the limit and the run timeout of the processor.
+func (v *validator) wait(path string, d time.Duration) {
+	switch {
+	case d <= 0 || d > time.Duration(MaxWaitSeconds)*time.Second:
+		v.errorf(path, "must be between 1 and %d seconds", MaxWaitSeconds)
+	case v.settings != nil && v.settings.WorkflowRunTimeoutSeconds > 0 && d > seconds(v.settings.WorkflowRunTimeoutSeconds):
+		v.errorf(path, "must not be greater than the workflow run timeout of the processor (%ds)",
+			v.settings.WorkflowRunTimeoutSeconds)
+	}
+}
+
+func (v *validator) approval(path string, a *Approval, scope *bindingScope) *bindingScope {
+	v.key(path+".key", a.Key)
+	v.templates(path+".message", a.Message, scope)
+	v.wait(path+".timeout_seconds", seconds(a.TimeoutSeconds))
+	if a.DefaultAction != "" && a.DefaultAction != ApprovalApprove && a.DefaultAction != ApprovalReject {
+		v.errorf(path+".default_action", "must be %q or %q", ApprovalApprove, ApprovalReject)
+	}
+
+	result := scope.clone()
+	if a.Key != "" {
+		result.outputs[a.Key] = true
+	}
+	return result
+}
+
+// templates checks that every reference in value is bound at this point of the
+// workflow.
+func (v *validator) templates(path string, value any, scope *bindingScope) {
+	valueTemplates(value, path, func(argPath string, t *template, err error) {
+		if err != nil {
+			v.errorf(argPath, "%s", err)
+			return
+		}
+		for _, ref := range t.references() {
+			if !ref.optional && !scope.resolves(ref.path) {
+				v.errorf(argPath, "reference %q is not defined at this point of the workflow", ref.path.raw)
+			}
+		}
+	})
+}
+
+func (v *validator) key(path, key string) {
+	if key == "" {
+		v.errorf(path, "is required")
+		return
+	}
+	if !activityKeyRegex.MatchString(key) {
+		v.errorf(path, "key %q must start with a letter and contain only letters, digits and underscores", key)
+		return
+	}
+	if first, ok := v.keys[key]; ok {
+		v.errorf(path, "duplicate key %q, already used at %s", key, first)
+		return
+	}
+	v.keys[key] = path
+}
+
+func (v *validator) variable(path, name string, scope *bindingScope) {
+	if name == "" {
+		v.errorf(path, "is required")
+		return
+	}
+	p, err := newExprParser(name).parsePathOnly()
+	if err != nil {
+		v.errorf(path, "invalid variable name %q", name)
+		return
+	}
+	if !scope.resolves(p) {
+		v.errorf(path, "variable %q is not defined at this point of the workflow", name)
+	}
+}
+
+func (v *validator) timeout(path string, seconds *int64) {
+	if seconds != nil && (*seconds < 1 || *seconds > MaxActivityTimeoutSeconds) {
+		v.errorf(path, "must be between 1 and %d seconds", MaxActivityTimeoutSeconds)
+	}
+}
+
+func isTemplated(value any) bool {
+	templated := false
+	valueTemplates(value, "", func(_ string, t *template, err error) {
+		if err != nil || t.hasExpressions() {
+			templated = true
+		}
+	})
+	return templated
+}
+
+func (s *bindingScope) clone() *bindingScope {
+	return &bindingScope{names: maps.Clone(s.names), outputs: maps.Clone(s.outputs)}
+}
+
+func (s *bindingScope) merge(other *bindingScope) {
+	maps.Copy(s.names, other.names)
+	maps.Copy(s.outputs, other.outputs)
+}
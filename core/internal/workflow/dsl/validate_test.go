@@ -0,0 +1,200 @@
+package dsl
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func validate(t *testing.T, source string) ValidationErrors {
+	t.Helper()
+
+	var wf Workflow
+	require.NoError(t, yaml.Unmarshal([]byte(source), &wf))
+
+	err := wf.Validate()
+	if err == nil {
+		return nil
+	}
+	var errs ValidationErrors
+	require.True(t, errors.As(err, &errs), "unexpected error type %T", err)
+	return errs
+}
+
+func TestValidateAcceptsValidWorkflow(t *testing.T) {
+	errs := validate(t, `
+variables:
+  size: 200
+root:
+  sequence:
+    elements:
+      - activity:
+          key: convert
+          uses: ImageFormatConvertorV1
+          with:
+            format: png
+      - activity:
+          key: resize
+          uses: ImageResize@v1.0
+          input: convert
+          with:
+            width: ${size}
+          start_to_close_timeout_seconds: 60
+      - condition:
+          variable: resize.status_code
+          value: 200
+          then:
+            activity:
+              key: notify
+              uses: HTTP_V_01
+              with:
+                url: https://example.com
+                body: ${resize.output_key}
+`)
+	assert.Empty(t, errs)
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	errs := validate(t, `
+variables: {}
+root:
+  sequence:
+    elements:
+      - activity:
+          key: convert
+          uses: ImageFormatConvertorV9
+          input: resize
+      - activity:
+          key: convert
+          uses: HTTP_V_01
+          with:
+            url: ${hook.url}
+          start_to_close_timeout_seconds: 0
+          max_retries: 1000
+      - activity:
+          key: resize
+          uses: ImageResize@v1.0
+`)
+
+	assert.ElementsMatch(t, ValidationErrors{
+		{Path: "root.sequence.elements[0].activity.uses", Message: `unknown activity "ImageFormatConvertorV9"`},
+		{Path: "root.sequence.elements[0].activity.input", Message: `activity "resize" has not run before this activity`},
+		{Path: "root.sequence.elements[1].activity.key", Message: `duplicate key "convert", already used at root.sequence.elements[0].activity.key`},
+		{Path: "root.sequence.elements[1].activity.with.url", Message: `reference "hook.url" is not defined at this point of the workflow`},
+		{Path: "root.sequence.elements[1].activity.start_to_close_timeout_seconds", Message: "must be between 1 and 604800 seconds"},
+		{Path: "root.sequence.elements[1].activity.max_retries", Message: "must be between 0 and 100"},
+	}, errs)
+}
+
+func TestValidateParallelBranchesDoNotSeeEachOther(t *testing.T) {
+	errs := validate(t, `
+variables: {}
+root:
+  parallel:
+    branches:
+      - activity:
+          key: left
+          uses: HTTP_V_01
+          with:
+            url: https://example.com
+      - activity:
+          key: right
+          uses: HTTP_V_01
+          with:
+            url: https://example.com
+            body: ${left.body}
+`)
+
+	require.Len(t, errs, 1)
+	assert.Equal(t, "root.parallel.branches[1].activity.with.body", errs[0].Path)
+}
+
+func TestValidateChecksArgumentsAgainstCatalogSchema(t *testing.T) {
+	errs := validate(t, `
+variables:
+  target: png
+root:
+  sequence:
+    elements:
+      - activity:
+          key: convert
+          uses: ImageFormatConvertorV1
+          with:
+            format: heic
+      - activity:
+          key: templated
+          uses: ImageFormatConvertorV1
+          with:
+            format: ${target}
+      - activity:
+          key: missing
+          uses: ImageFormatConvertorV1
+`)
+
+	require.Len(t, errs, 2)
+	assert.Equal(t, "root.sequence.elements[0].activity.with.format", errs[0].Path)
+	assert.Contains(t, errs[0].Message, "must be one of")
+	assert.Equal(t, "root.sequence.elements[2].activity.with", errs[1].Path)
+	assert.Equal(t, "format is required", errs[1].Message)
+}
+
+func TestValidateSecretReferences(t *testing.T) {
+	errs := validate(t, `
+variables:
+  secrets: shadowed
+root:
+  activity:
+    key: notify
+    uses: HTTP_V_01
+    with:
+      url: https://example.com
+      headers:
+        Authorization: Bearer ${secrets.API_TOKEN}
+        X-Token: ${secrets.API_TOKEN | upper}
+`)
+
+	assert.ElementsMatch(t, ValidationErrors{
+		{Path: "variables.secrets", Message: "secrets is reserved for workspace secrets"},
+		{Path: "root.activity.with.headers.X-Token", Message: `invalid expression "${secrets.API_TOKEN | upper}": secrets can only be used as ${secrets.NAME}`},
+	}, errs)
+}
+
+func TestValidateRejectsShadowedIdentifiers(t *testing.T) {
+	errs := validate(t, `
+variables:
+  files: []
+  run_id: fixed
+root:
+  sequence:
+    elements:
+      - foreach:
+          items: files
+          as: workspace_id
+          index_as: upload_id
+          body:
+            activity:
+              key: notify
+              uses: HTTP_V_01
+              with:
+                url: https://example.com
+      - loop:
+          iterations: 2
+          indexVariable: test_run
+          body:
+            activity:
+              key: poll
+              uses: HTTP_V_01
+              with:
+                url: https://example.com
+`)
+
+	assert.ElementsMatch(t, ValidationErrors{
+		{Path: "variables.run_id", Message: "run_id is reserved for the workflow identifiers"},
+		{Path: "root.sequence.elements[0].foreach.as", Message: "workspace_id is reserved for the workflow identifiers"},
+		{Path: "root.sequence.elements[0].foreach.index_as", Message: "upload_id is reserved for the workflow identifiers"},
+		{Path: "root.sequence.elements[1].loop.indexVariable", Message: "test_run is reserved for the workflow identifiers"},
+	}, errs)
+}
@@ -5,79 +5,171 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"os"
+	"strings"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/lambda"
-	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
 	"github.com/phuslu/log"
+	"github.com/uploadpilot/core/internal/workflow/activities"
+	"github.com/uploadpilot/core/internal/workflow/catalog"
+	"github.com/uploadpilot/core/internal/workflow/dsl"
 )
 
+// Backend runs an activity payload in one execution environment and returns
+// the raw output of the activity.
+type Backend interface {
+	Invoke(ctx context.Context, target string, payload []byte) ([]byte, error)
+}
+
 type Executor struct {
-	lambdaClient *lambda.Client
+	backends  map[catalog.ExecutorType]Backend
+	overrides map[string]catalog.ExecutorSpec
+	secrets   activities.SecretResolver
 }
 
-func NewExecutor(lambdaClient *lambda.Client) *Executor {
+// NewExecutor creates an executor dispatching to the given backends. Overrides
+// replace the catalog executor of single activities, e.g. to run them locally.
+// Secrets resolve the ${secrets.NAME} references in the activity arguments.
+func NewExecutor(backends map[catalog.ExecutorType]Backend, overrides map[string]catalog.ExecutorSpec,
+	secrets activities.SecretResolver) *Executor {
 	return &Executor{
-		lambdaClient: lambdaClient,
+		backends:  backends,
+		overrides: overrides,
+		secrets:   secrets,
 	}
 }
 
-func (e *Executor) ExecuteLambdaContainerActivity(ctx context.Context, functionName, marshaledPayload string) ([]byte, error) {
+// Execute is registered as the "Executor" activity. It looks up where the
+// activity runs, invokes the matching backend and checks the output. The
+// identifiers of the payload are replaced by the ones of the scope, which the
+// workflow sets itself, before the secrets are resolved.
+func (e *Executor) Execute(ctx context.Context, uses, marshaledPayload string, scope dsl.ActivityScope) ([]byte, error) {
+	spec := e.executorSpec(uses)
+	backend, ok := e.backends[spec.Type]
+	if !ok {
+		return nil, fmt.Errorf("no %s backend is configured to run %s", spec.Type, uses)
+	}
 
-	input := &lambda.InvokeInput{
-		FunctionName: aws.String(functionName),
-		Payload:      []byte(marshaledPayload),
-		LogType:      types.LogTypeTail,
+	var bindings map[string]any
+	if err := json.Unmarshal([]byte(marshaledPayload), &bindings); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal activity payload: %w", err)
+	}
+	bindScope(bindings, scope)
+	if err := resolveSecrets(ctx, e.secrets, bindings, scope); err != nil {
+		log.Error().Err(err).Str("uses", uses).Msg("failed to resolve secrets")
+		return nil, err
+	}
+	resolved, err := json.Marshal(bindings)
+	if err != nil {
+		return nil, err
 	}
 
-	log.Info().Str("functionName", functionName).Str("payload", marshaledPayload).Msg("invoking lambda")
-	op, err := e.lambdaClient.Invoke(context.TODO(), input)
+	target := os.ExpandEnv(spec.Target)
+	// the payload is logged before its secrets are resolved
+	log.Info().Str("uses", uses).Str("backend", string(spec.Type)).Str("target", target).
+		Str("payload", marshaledPayload).Msg("executing activity")
+	payload, err := backend.Invoke(ctx, target, resolved)
 	if err != nil {
-		log.Error().Err(err).Msg("failed to invoke lambda")
-		return nil, fmt.Errorf("failed to run activity: %w", err)
+		log.Error().Err(err).Str("uses", uses).Msg("failed to run activity")
+		return nil, err
 	}
 
-	if op.FunctionError != nil {
-		log.Error().Str("error", *op.FunctionError).Msg("lambda error")
-		return nil, fmt.Errorf("error: %s", string(op.Payload))
+	if err := checkOutput(uses, payload); err != nil {
+		return nil, err
 	}
 
+	log.Info().Str("output", string(payload)).Msg("activity output")
+	return payload, nil
+}
+
+// bindScope overwrites the identifiers the activities read from the payload,
+// e.g. the workspace used as bucket, with the ones of the scope.
+func bindScope(bindings map[string]any, scope dsl.ActivityScope) {
+	bindings["workspace_id"] = scope.WorkspaceID
+	bindings["upload_id"] = scope.UploadID
+	bindings["processor_id"] = scope.ProcessorID
+	bindings["run_id"] = scope.RunID
+	bindings["current_activity_key"] = scope.ActivityKey
+}
+
+func (e *Executor) executorSpec(uses string) catalog.ExecutorSpec {
+	if spec, ok := e.overrides[uses]; ok {
+		return spec
+	}
+	if meta, ok := catalog.GetActivity(uses); ok {
+		return meta.GetExecutor()
+	}
+	// activities outside the catalog are lambda functions
+	return catalog.ExecutorSpec{Type: catalog.ExecutorLambda, Target: uses}
+}
+
+// checkOutput verifies the status code reported by the activity and the output schema.
+func checkOutput(uses string, payload []byte) error {
 	var output map[string]interface{}
-	if err := json.Unmarshal(op.Payload, &output); err != nil {
-		log.Error().Err(err).Msg("failed to unmarshal lambda output")
-		return nil, fmt.Errorf("failed to unmarshal lambda output: %w", err)
+	if err := json.Unmarshal(payload, &output); err != nil {
+		log.Error().Err(err).Msg("failed to unmarshal activity output")
+		return fmt.Errorf("failed to unmarshal activity output: %w", err)
 	}
 
-	log.Debug().Interface("output", output).Msg("lambda output")
+	log.Debug().Interface("output", output).Msg("activity output")
 	success, ok := output["status_code"]
 	if !ok {
-		log.Error().Str("output", string(op.Payload)).Msg("lambda output")
-		return nil, fmt.Errorf("failed to unmarshal lambda output: status_code field not found")
+		log.Error().Str("output", string(payload)).Msg("activity output")
+		return fmt.Errorf("failed to unmarshal activity output: status_code field not found")
 	}
 
 	statusFloat, ok := success.(float64)
 	if !ok {
 		log.Error().Interface("success", success).Msg("status_code is not a float64")
-		return nil, fmt.Errorf("failed to unmarshal lambda output: status_code is not a float64")
+		return fmt.Errorf("failed to unmarshal activity output: status_code is not a float64")
 	}
 	s := int(statusFloat)
-	log.Debug().Int("s", s).Msg("lambda status code")
+	log.Debug().Int("s", s).Msg("activity status code")
 	if s < 200 || s > 299 {
-		err := output["error"].(string)
-		log.Error().Str("error", err).Msg("lambda execution failed")
-		return nil, fmt.Errorf("error: %s", err)
+		errMsg, _ := output["error"].(string)
+		log.Error().Str("error", errMsg).Msg("activity execution failed")
+		return fmt.Errorf("error: %s", errMsg)
 	}
 
-	if op.FunctionError != nil {
-		log.Error().Str("error", *op.FunctionError).Msg("lambda error")
-		return nil, fmt.Errorf("error: %s", string(op.Payload))
+	if meta, ok := catalog.GetActivity(uses); ok {
+		errs, err := meta.CheckOutput(output)
+		if err != nil {
+			return err
+		}
+		if len(errs) > 0 {
+			log.Error().Interface("errors", errs).Msg("activity output does not match the activity output schema")
+			return fmt.Errorf("output of %s does not match its schema: %s", uses, errs[0].Error())
+		}
 	}
 
-	log.Info().Str("output", string(op.Payload)).Msg("lambda output")
-	logs, _ := DecodeBase64(*op.LogResult)
-	log.Info().Str("logs", logs).Msg("lambda logs")
+	return nil
+}
 
-	return op.Payload, nil
+// ParseExecutorOverrides parses overrides in the form
+// "Name=type:target;Name=type:target", e.g.
+// "ImageFormatConvertorV1=http:http://localhost:9000/convert".
+func ParseExecutorOverrides(value string) (map[string]catalog.ExecutorSpec, error) {
+	overrides := make(map[string]catalog.ExecutorSpec)
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, spec, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid executor override %q: expected Name=type:target", entry)
+		}
+		typ, target, _ := strings.Cut(spec, ":")
+		switch catalog.ExecutorType(typ) {
+		case catalog.ExecutorLambda, catalog.ExecutorNative, catalog.ExecutorCommand, catalog.ExecutorHTTP:
+		default:
+			return nil, fmt.Errorf("invalid executor override %q: unknown backend %q", entry, typ)
+		}
+		if target == "" {
+			target = strings.TrimSpace(name)
+		}
+		overrides[strings.TrimSpace(name)] = catalog.ExecutorSpec{Type: catalog.ExecutorType(typ), Target: target}
+	}
+	return overrides, nil
 }
 
 // DecodeBase64 takes a Base64-encoded string and returns the decoded string
@@ -0,0 +1,166 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/phuslu/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uploadpilot/core/internal/workflow/catalog"
+	"github.com/uploadpilot/core/internal/workflow/dsl"
+)
+
+func TestExecutorDispatchesToBackend(t *testing.T) {
+	native := NewNativeBackend()
+	native.Register("convert", func(ctx context.Context, payload []byte) ([]byte, error) {
+		return []byte(`{"status_code": 200, "output_key": "out.png"}`), nil
+	})
+	native.Register("broken", func(ctx context.Context, payload []byte) ([]byte, error) {
+		return []byte(`{"status_code": 500, "error": "boom"}`), nil
+	})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"status_code": 201}`))
+	}))
+	defer srv.Close()
+
+	exe := NewExecutor(map[catalog.ExecutorType]Backend{
+		catalog.ExecutorNative:  native,
+		catalog.ExecutorCommand: NewCommandBackend(),
+		catalog.ExecutorHTTP:    NewHTTPBackend(srv.Client()),
+	}, map[string]catalog.ExecutorSpec{
+		"ImageFormatConvertorV1": {Type: catalog.ExecutorNative, Target: "convert"},
+		"Broken":                 {Type: catalog.ExecutorNative, Target: "broken"},
+		"Echo":                   {Type: catalog.ExecutorCommand, Target: "cat"},
+		"Remote":                 {Type: catalog.ExecutorHTTP, Target: srv.URL},
+	}, nil)
+
+	scope := dsl.ActivityScope{WorkspaceID: "ws", UploadID: "up", ProcessorID: "proc", RunID: "run", ActivityKey: "echo"}
+	out, err := exe.Execute(context.Background(), "ImageFormatConvertorV1", `{}`, scope)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"status_code": 200, "output_key": "out.png"}`, string(out))
+
+	_, err = exe.Execute(context.Background(), "Broken", `{}`, scope)
+	assert.EqualError(t, err, "error: boom")
+
+	// the identifiers of the payload are replaced by the ones of the scope
+	out, err = exe.Execute(context.Background(), "Echo", `{"status_code": 200, "workspace_id": "other"}`, scope)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"status_code": 200,
+		"workspace_id": "ws",
+		"upload_id": "up",
+		"processor_id": "proc",
+		"run_id": "run",
+		"current_activity_key": "echo"
+	}`, string(out))
+
+	out, err = exe.Execute(context.Background(), "Remote", `{}`, scope)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"status_code": 201}`, string(out))
+
+	// lambda is not configured, so catalog activities running there cannot be executed
+	_, err = exe.Execute(context.Background(), "DetectDocumentTextV1", `{}`, scope)
+	assert.ErrorContains(t, err, "no lambda backend")
+}
+
+type staticSecrets map[string]string
+
+func (s staticSecrets) Resolve(ctx context.Context, workspaceID, name string) (string, error) {
+	v, ok := s[workspaceID+"/"+name]
+	if !ok {
+		return "", fmt.Errorf("secret %s not found", name)
+	}
+	return v, nil
+}
+
+func TestExecutorResolvesSecretsOfCurrentActivity(t *testing.T) {
+	var got map[string]any
+	native := NewNativeBackend()
+	native.Register("echo", func(ctx context.Context, payload []byte) ([]byte, error) {
+		got = nil
+		if err := json.Unmarshal(payload, &got); err != nil {
+			return nil, err
+		}
+		return []byte(`{"status_code": 200}`), nil
+	})
+	exe := NewExecutor(map[catalog.ExecutorType]Backend{catalog.ExecutorNative: native},
+		map[string]catalog.ExecutorSpec{"Echo": {Type: catalog.ExecutorNative, Target: "echo"}},
+		staticSecrets{"ws/API_TOKEN": "t0ken"})
+
+	scope := dsl.ActivityScope{WorkspaceID: "ws", ActivityKey: "notify"}
+	payload := `{
+		"notify.headers": {"Authorization": "Bearer ${secrets.API_TOKEN}"},
+		"notify.tags": ["${secrets.API_TOKEN}"],
+		"previous.headers": {"Authorization": "Bearer ${secrets.API_TOKEN}"}
+	}`
+	_, err := exe.Execute(context.Background(), "Echo", payload, scope)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"Authorization": "Bearer t0ken"}, got["notify.headers"])
+	assert.Equal(t, []any{"t0ken"}, got["notify.tags"])
+	// bindings of other activities are passed on untouched
+	assert.Equal(t, map[string]any{"Authorization": "Bearer ${secrets.API_TOKEN}"}, got["previous.headers"])
+
+	// the workspace of the payload is not trusted, secrets are looked up in the
+	// workspace of the scope
+	_, err = exe.Execute(context.Background(), "Echo", `{
+		"workspace_id": "ws",
+		"current_activity_key": "notify",
+		"notify.token": "${secrets.API_TOKEN}"
+	}`, dsl.ActivityScope{WorkspaceID: "other", ActivityKey: "notify"})
+	assert.ErrorContains(t, err, "failed to resolve secret API_TOKEN")
+}
+
+func TestExecutorNeverLogsResolvedSecrets(t *testing.T) {
+	var received []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = io.ReadAll(r.Body)
+		_, _ = w.Write([]byte(`{"status_code": 200}`))
+	}))
+	defer srv.Close()
+
+	var logs bytes.Buffer
+	defaultLogger := log.DefaultLogger
+	log.DefaultLogger = log.Logger{Level: log.TraceLevel, Writer: &log.IOWriter{Writer: &logs}}
+	defer func() { log.DefaultLogger = defaultLogger }()
+
+	lambdaClient := lambda.New(lambda.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(srv.URL),
+		Credentials:  credentials.NewStaticCredentialsProvider("key", "secret", ""),
+	})
+	exe := NewExecutor(map[catalog.ExecutorType]Backend{catalog.ExecutorLambda: NewLambdaBackend(lambdaClient)},
+		map[string]catalog.ExecutorSpec{"Notify": {Type: catalog.ExecutorLambda, Target: "notify"}},
+		staticSecrets{"ws/API_TOKEN": "t0ken"})
+
+	_, err := exe.Execute(context.Background(), "Notify", `{
+		"notify.token": "${secrets.API_TOKEN}"
+	}`, dsl.ActivityScope{WorkspaceID: "ws", ActivityKey: "notify"})
+	require.NoError(t, err)
+	assert.Contains(t, string(received), "t0ken")
+	assert.Contains(t, logs.String(), "${secrets.API_TOKEN}")
+	assert.NotContains(t, logs.String(), "t0ken")
+}
+
+func TestParseExecutorOverrides(t *testing.T) {
+	overrides, err := ParseExecutorOverrides("A=http:http://localhost:9000/a; B=command:python3 b.py;C=native")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]catalog.ExecutorSpec{
+		"A": {Type: catalog.ExecutorHTTP, Target: "http://localhost:9000/a"},
+		"B": {Type: catalog.ExecutorCommand, Target: "python3 b.py"},
+		"C": {Type: catalog.ExecutorNative, Target: "C"},
+	}, overrides)
+
+	_, err = ParseExecutorOverrides("A=ftp:somewhere")
+	assert.Error(t, err)
+}
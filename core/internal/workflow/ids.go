@@ -0,0 +1,33 @@
+package workflow
+
+import (
+	"fmt"
+	"strings"
+
+	"go.temporal.io/api/enums/v1"
+)
+
+// RunWorkflowID returns the ID of the workflow that processes an upload with a
+// processor. Uploads are processed once per attempt, the first run is attempt 1
+// and every manual re-run increments it, so the same trigger always maps to
+// the same ID and Temporal deduplicates it.
+func RunWorkflowID(processorID, uploadID string, attempt int) string {
+	return fmt.Sprintf("%s-%s-%d", processorID, uploadID, attempt)
+}
+
+// ParseIDReusePolicy parses the workflow ID reuse policy from the config. It
+// decides if a closed run can be started again with the same workflow ID, so
+// the triggers are only idempotent with reject_duplicate: allow_duplicate
+// starts a retried trigger again once its run closed, and
+// allow_duplicate_failed_only once its run failed.
+func ParseIDReusePolicy(value string) (enums.WorkflowIdReusePolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "reject_duplicate":
+		return enums.WORKFLOW_ID_REUSE_POLICY_REJECT_DUPLICATE, nil
+	case "allow_duplicate":
+		return enums.WORKFLOW_ID_REUSE_POLICY_ALLOW_DUPLICATE, nil
+	case "allow_duplicate_failed_only":
+		return enums.WORKFLOW_ID_REUSE_POLICY_ALLOW_DUPLICATE_FAILED_ONLY, nil
+	}
+	return enums.WORKFLOW_ID_REUSE_POLICY_UNSPECIFIED, fmt.Errorf("invalid workflow id reuse policy %q: expected reject_duplicate, allow_duplicate or allow_duplicate_failed_only", value)
+}
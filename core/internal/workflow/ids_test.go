@@ -0,0 +1,28 @@
+package workflow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/api/enums/v1"
+)
+
+func TestRunWorkflowID(t *testing.T) {
+	assert.Equal(t, "proc-upload-1", RunWorkflowID("proc", "upload", 1))
+	assert.NotEqual(t, RunWorkflowID("proc", "upload-a", 1), RunWorkflowID("proc", "upload-b", 1))
+	assert.NotEqual(t, RunWorkflowID("proc", "upload", 1), RunWorkflowID("proc", "upload", 2))
+}
+
+func TestParseIDReusePolicy(t *testing.T) {
+	policy, err := ParseIDReusePolicy("")
+	require.NoError(t, err)
+	assert.Equal(t, enums.WORKFLOW_ID_REUSE_POLICY_REJECT_DUPLICATE, policy)
+
+	policy, err = ParseIDReusePolicy("Allow_Duplicate_Failed_Only")
+	require.NoError(t, err)
+	assert.Equal(t, enums.WORKFLOW_ID_REUSE_POLICY_ALLOW_DUPLICATE_FAILED_ONLY, policy)
+
+	_, err = ParseIDReusePolicy("terminate")
+	assert.Error(t, err)
+}
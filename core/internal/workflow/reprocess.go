@@ -0,0 +1,193 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uploadpilot/core/internal/db/models"
+	"github.com/uploadpilot/core/internal/db/repo"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+const (
+	ListReprocessUploadsActivityName = "ListReprocessUploads"
+	ReprocessUploadActivityName      = "ReprocessUpload"
+	// ReprocessProgressQuery returns the ReprocessProgress of a bulk reprocess.
+	ReprocessProgressQuery = "progress"
+
+	DefaultReprocessConcurrency = 5
+	reprocessPageSize           = 100
+	// a run continues as new after this many pages to keep its history small
+	reprocessPagesPerRun = 20
+	maxReprocessErrors   = 20
+)
+
+// Reprocessor starts the processor runs of one upload for a bulk reprocess
+// and returns how many runs it started.
+type Reprocessor interface {
+	ReprocessUpload(ctx context.Context, workspaceID, uploadID string, processorIDs []string, onlyFailed bool) (int, error)
+}
+
+// ReprocessRequest selects the uploads of a bulk reprocess with the search and
+// filter of the uploads API. Uploads derived from the outputs of runs are left
+// out unless IncludeDerived is set. Without processor IDs every enabled
+// processor matching an upload runs, with OnlyFailed only processors whose
+// latest run for the upload failed.
+type ReprocessRequest struct {
+	WorkspaceID      string                  `json:"workspaceId"`
+	Pagination       models.PaginationParams `json:"pagination"`
+	ProcessorIDs     []string                `json:"processorIds,omitempty"`
+	OnlyFailed       bool                    `json:"onlyFailed"`
+	IncludeDerived   bool                    `json:"includeDerived,omitempty"`
+	MaxConcurrency   int                     `json:"maxConcurrency"`
+	UploadsPerMinute int                     `json:"uploadsPerMinute"`
+
+	// AfterID and Progress carry the state over to the next run
+	AfterID  string             `json:"afterId,omitempty"`
+	Progress *ReprocessProgress `json:"progress,omitempty"`
+}
+
+type ReprocessProgress struct {
+	Total     int64     `json:"total"`
+	Processed int       `json:"processed"`
+	Runs      int       `json:"runs"`
+	Skipped   int       `json:"skipped"`
+	Failed    int       `json:"failed"`
+	Errors    []string  `json:"errors,omitempty"`
+	Done      bool      `json:"done"`
+	StartedAt time.Time `json:"startedAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type ReprocessPage struct {
+	UploadIDs []string `json:"uploadIds"`
+	Total     int64    `json:"total"`
+}
+
+// BulkReprocessWorkflow reprocesses the uploads page by page. At most
+// MaxConcurrency uploads are reprocessed at once, and no more than
+// UploadsPerMinute uploads per minute when it is set.
+func BulkReprocessWorkflow(ctx workflow.Context, req ReprocessRequest) (*ReprocessProgress, error) {
+	progress := req.Progress
+	if progress == nil {
+		progress = &ReprocessProgress{StartedAt: workflow.Now(ctx)}
+	}
+	if err := workflow.SetQueryHandler(ctx, ReprocessProgressQuery, func() (*ReprocessProgress, error) {
+		return progress, nil
+	}); err != nil {
+		return nil, err
+	}
+
+	concurrency := req.MaxConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultReprocessConcurrency
+	}
+	listCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			MaximumAttempts:    3,
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2,
+		},
+	})
+	// a retry would start the runs that were started before the failure again
+	reprocessCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			MaximumAttempts: 1,
+		},
+	})
+
+	for page := 0; ; page++ {
+		if page == reprocessPagesPerRun {
+			req.Progress = progress
+			return nil, workflow.NewContinueAsNewError(ctx, BulkReprocessWorkflow, req)
+		}
+
+		var uploads ReprocessPage
+		if err := workflow.ExecuteActivity(listCtx, ListReprocessUploadsActivityName, req.WorkspaceID, req.Pagination,
+			req.AfterID, reprocessPageSize, req.IncludeDerived).Get(ctx, &uploads); err != nil {
+			return progress, err
+		}
+		if req.AfterID == "" {
+			progress.Total = uploads.Total
+		}
+		if len(uploads.UploadIDs) == 0 {
+			break
+		}
+
+		for start := 0; start < len(uploads.UploadIDs); start += concurrency {
+			batch := uploads.UploadIDs[start:min(start+concurrency, len(uploads.UploadIDs))]
+			batchStart := workflow.Now(ctx)
+
+			futures := make([]workflow.Future, len(batch))
+			for i, uploadID := range batch {
+				futures[i] = workflow.ExecuteActivity(reprocessCtx, ReprocessUploadActivityName, req.WorkspaceID, uploadID,
+					req.ProcessorIDs, req.OnlyFailed)
+			}
+			for i, future := range futures {
+				var runs int
+				err := future.Get(ctx, &runs)
+				if temporal.IsCanceledError(err) {
+					return progress, err
+				}
+				progress.Processed++
+				switch {
+				case err != nil:
+					progress.Failed++
+					if len(progress.Errors) < maxReprocessErrors {
+						progress.Errors = append(progress.Errors, fmt.Sprintf("%s: %s", batch[i], err))
+					}
+				case runs == 0:
+					progress.Skipped++
+				default:
+					progress.Runs += runs
+				}
+			}
+			progress.UpdatedAt = workflow.Now(ctx)
+
+			if req.UploadsPerMinute > 0 {
+				minimum := time.Duration(len(batch)) * time.Minute / time.Duration(req.UploadsPerMinute)
+				if elapsed := workflow.Now(ctx).Sub(batchStart); elapsed < minimum {
+					if err := workflow.Sleep(ctx, minimum-elapsed); err != nil {
+						return progress, err
+					}
+				}
+			}
+		}
+		req.AfterID = uploads.UploadIDs[len(uploads.UploadIDs)-1]
+	}
+
+	progress.Done = true
+	progress.UpdatedAt = workflow.Now(ctx)
+	return progress, nil
+}
+
+// ReprocessActivities are the activities of the bulk reprocess workflow.
+type ReprocessActivities struct {
+	uploadRepo  *repo.UploadRepo
+	reprocessor Reprocessor
+}
+
+func NewReprocessActivities(uploadRepo *repo.UploadRepo, reprocessor Reprocessor) *ReprocessActivities {
+	return &ReprocessActivities{
+		uploadRepo:  uploadRepo,
+		reprocessor: reprocessor,
+	}
+}
+
+func (a *ReprocessActivities) ListUploads(ctx context.Context, workspaceID string, pagination models.PaginationParams,
+	afterID string, limit int, includeDerived bool) (*ReprocessPage, error) {
+	ids, total, err := a.uploadRepo.GetIDsAfter(ctx, workspaceID, &pagination, afterID, limit, includeDerived)
+	if err != nil {
+		return nil, err
+	}
+	return &ReprocessPage{UploadIDs: ids, Total: total}, nil
+}
+
+func (a *ReprocessActivities) ReprocessUpload(ctx context.Context, workspaceID, uploadID string, processorIDs []string,
+	onlyFailed bool) (int, error) {
+	return a.reprocessor.ReprocessUpload(ctx, workspaceID, uploadID, processorIDs, onlyFailed)
+}
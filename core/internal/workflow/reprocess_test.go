@@ -0,0 +1,112 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uploadpilot/core/internal/db/models"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/testsuite"
+)
+
+// fakeUploads stands in for the reprocess activities. Reprocessing an upload
+// removes it from the uploads that match, like a status filter would.
+type fakeUploads struct {
+	ids         []string
+	derived     []string
+	reprocessed []string
+}
+
+func (f *fakeUploads) list(ctx context.Context, workspaceID string, pagination models.PaginationParams,
+	afterID string, limit int, includeDerived bool) (*ReprocessPage, error) {
+	var matching []string
+	for _, id := range f.ids {
+		if slices.Contains(f.derived, id) && !includeDerived {
+			continue
+		}
+		if !slices.Contains(f.reprocessed, id) || id <= afterID {
+			matching = append(matching, id)
+		}
+	}
+	page := &ReprocessPage{Total: int64(len(matching))}
+	for _, id := range matching {
+		if id > afterID && len(page.UploadIDs) < limit {
+			page.UploadIDs = append(page.UploadIDs, id)
+		}
+	}
+	return page, nil
+}
+
+func (f *fakeUploads) reprocess(ctx context.Context, workspaceID, uploadID string, processorIDs []string, onlyFailed bool) (int, error) {
+	f.reprocessed = append(f.reprocessed, uploadID)
+	switch uploadID {
+	case "u003":
+		return 0, nil
+	case "u005":
+		return 0, errors.New("processor not found")
+	}
+	return 2, nil
+}
+
+func runBulkReprocess(t *testing.T, uploads *fakeUploads, req ReprocessRequest) *ReprocessProgress {
+	t.Helper()
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(BulkReprocessWorkflow)
+	env.RegisterActivityWithOptions(uploads.list, activity.RegisterOptions{Name: ListReprocessUploadsActivityName})
+	env.RegisterActivityWithOptions(uploads.reprocess, activity.RegisterOptions{Name: ReprocessUploadActivityName})
+
+	env.ExecuteWorkflow(BulkReprocessWorkflow, req)
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	var progress ReprocessProgress
+	require.NoError(t, env.GetWorkflowResult(&progress))
+	return &progress
+}
+
+func TestBulkReprocessVisitsEveryUploadOnce(t *testing.T) {
+	uploads := &fakeUploads{}
+	for i := 1; i <= 250; i++ {
+		uploads.ids = append(uploads.ids, fmt.Sprintf("u%03d", i))
+	}
+
+	progress := runBulkReprocess(t, uploads, ReprocessRequest{WorkspaceID: "ws", MaxConcurrency: 7})
+
+	assert.ElementsMatch(t, uploads.ids, uploads.reprocessed)
+	assert.True(t, progress.Done)
+	assert.Equal(t, int64(250), progress.Total)
+	assert.Equal(t, 250, progress.Processed)
+	assert.Equal(t, 1, progress.Skipped)
+	assert.Equal(t, 1, progress.Failed)
+	assert.Equal(t, 248*2, progress.Runs)
+	if assert.Len(t, progress.Errors, 1) {
+		assert.Contains(t, progress.Errors[0], "u005")
+	}
+}
+
+func TestBulkReprocessIsThrottled(t *testing.T) {
+	uploads := &fakeUploads{ids: []string{"u001", "u002", "u003", "u004", "u006", "u007"}}
+
+	progress := runBulkReprocess(t, uploads, ReprocessRequest{WorkspaceID: "ws", MaxConcurrency: 2, UploadsPerMinute: 6})
+
+	assert.Equal(t, 6, progress.Processed)
+	// 6 uploads at 6 per minute
+	assert.GreaterOrEqual(t, progress.UpdatedAt.Sub(progress.StartedAt), time.Minute)
+}
+
+func TestBulkReprocessLeavesOutDerivedUploads(t *testing.T) {
+	uploads := &fakeUploads{ids: []string{"u001", "u002", "u004"}, derived: []string{"u002"}}
+	progress := runBulkReprocess(t, uploads, ReprocessRequest{WorkspaceID: "ws"})
+	assert.Equal(t, []string{"u001", "u004"}, uploads.reprocessed)
+	assert.Equal(t, int64(2), progress.Total)
+
+	uploads = &fakeUploads{ids: []string{"u001", "u002", "u004"}, derived: []string{"u002"}}
+	runBulkReprocess(t, uploads, ReprocessRequest{WorkspaceID: "ws", IncludeDerived: true})
+	assert.Equal(t, []string{"u001", "u002", "u004"}, uploads.reprocessed)
+}
@@ -0,0 +1,91 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/uploadpilot/core/internal/db/errs"
+	"github.com/uploadpilot/core/internal/db/models"
+	"github.com/uploadpilot/core/internal/db/repo"
+	"github.com/uploadpilot/core/internal/workflow/dsl"
+)
+
+// RunRecorder keeps the processor runs and the processing status of uploads
+// in sync with the workflows.
+type RunRecorder struct {
+	runRepo    *repo.ProcessorRunRepo
+	uploadRepo *repo.UploadRepo
+}
+
+func NewRunRecorder(runRepo *repo.ProcessorRunRepo, uploadRepo *repo.UploadRepo) *RunRecorder {
+	return &RunRecorder{
+		runRepo:    runRepo,
+		uploadRepo: uploadRepo,
+	}
+}
+
+// Record is registered as the "RecordRun" activity. It saves the run and then
+// derives the status of the upload from the latest run of every processor.
+func (r *RunRecorder) Record(ctx context.Context, ev dsl.RunEvent) error {
+	if ev.Status == models.RunStatusRunning {
+		if err := r.runRepo.Create(ctx, newProcessorRun(ev)); err != nil {
+			return err
+		}
+	} else {
+		run, err := r.runRepo.GetByWorkflowRun(ctx, ev.WorkflowID, ev.RunID)
+		if errors.Is(err, errs.ErrRecordNotFound) {
+			// the start of the run was not recorded
+			run, err = newProcessorRun(ev), nil
+		}
+		if err != nil {
+			return err
+		}
+		finishedAt := ev.FinishedAt
+		run.Status = ev.Status
+		run.Error = ev.Error
+		run.ArtifactKey = ev.ArtifactKey
+		run.Artifacts = ev.Artifacts
+		run.FinishedAt = &finishedAt
+		run.DurationMillis = finishedAt.Sub(run.StartedAt).Milliseconds()
+		if err := r.runRepo.Update(ctx, run); err != nil {
+			return err
+		}
+	}
+
+	statuses, err := r.runRepo.GetLatestStatuses(ctx, ev.UploadID)
+	if err != nil {
+		return err
+	}
+	return r.uploadRepo.Patch(ctx, ev.UploadID, map[string]interface{}{
+		"status": uploadStatusForRuns(statuses),
+	})
+}
+
+func newProcessorRun(ev dsl.RunEvent) *models.ProcessorRun {
+	return &models.ProcessorRun{
+		WorkspaceID:     ev.WorkspaceID,
+		UploadID:        ev.UploadID,
+		ProcessorID:     ev.ProcessorID,
+		WorkflowID:      ev.WorkflowID,
+		RunID:           ev.RunID,
+		Attempt:         ev.Attempt,
+		WorkflowVersion: ev.WorkflowVersion,
+		Status:          ev.Status,
+		StartedAt:       ev.StartedAt,
+	}
+}
+
+// uploadStatusForRuns returns the processing status of an upload. The upload
+// is processing while any processor still runs, and has failed if any failed.
+func uploadStatusForRuns(statuses []models.RunStatus) models.UploadStatus {
+	switch {
+	case slices.Contains(statuses, models.RunStatusRunning):
+		return models.UploadStatusProcessing
+	case slices.Contains(statuses, models.RunStatusFailed):
+		return models.UploadStatusProcessingFailed
+	case slices.Contains(statuses, models.RunStatusCanceled):
+		return models.UploadStatusProcessingCancelled
+	}
+	return models.UploadStatusProcessingComplete
+}
@@ -0,0 +1,25 @@
+package workflow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uploadpilot/core/internal/db/models"
+)
+
+func TestUploadStatusForRuns(t *testing.T) {
+	testCases := []struct {
+		statuses []models.RunStatus
+		expected models.UploadStatus
+	}{
+		{statuses: []models.RunStatus{models.RunStatusCompleted}, expected: models.UploadStatusProcessingComplete},
+		{statuses: []models.RunStatus{models.RunStatusFailed, models.RunStatusRunning}, expected: models.UploadStatusProcessing},
+		{statuses: []models.RunStatus{models.RunStatusCompleted, models.RunStatusFailed}, expected: models.UploadStatusProcessingFailed},
+		{statuses: []models.RunStatus{models.RunStatusCanceled, models.RunStatusCompleted}, expected: models.UploadStatusProcessingCancelled},
+		{statuses: []models.RunStatus{models.RunStatusCanceled, models.RunStatusFailed}, expected: models.UploadStatusProcessingFailed},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, uploadStatusForRuns(tc.statuses), "statuses %v", tc.statuses)
+	}
+}
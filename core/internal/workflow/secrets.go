@@ -0,0 +1,78 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/uploadpilot/core/internal/workflow/activities"
+	"github.com/uploadpilot/core/internal/workflow/dsl"
+)
+
+var secretReference = regexp.MustCompile(`\$\{secrets\.([A-Za-z][A-Za-z0-9_]*)\}`)
+
+// resolveSecrets replaces ${secrets.NAME} in the arguments of the current
+// activity with the secret values of the workspace of the scope. It runs inside
+// the activity, so the values are only sent to the backend and never recorded
+// in the workflow history.
+func resolveSecrets(ctx context.Context, resolver activities.SecretResolver, bindings map[string]any, scope dsl.ActivityScope) error {
+	if scope.ActivityKey == "" {
+		return nil
+	}
+
+	values := make(map[string]string)
+	var resolveErr error
+	replace := func(s string) string {
+		if !strings.Contains(s, "${secrets.") {
+			return s
+		}
+		return secretReference.ReplaceAllStringFunc(s, func(ref string) string {
+			name := secretReference.FindStringSubmatch(ref)[1]
+			value, ok := values[name]
+			if !ok {
+				if resolver == nil {
+					resolveErr = fmt.Errorf("secret %s cannot be resolved, workspace secrets are not available", name)
+					return ref
+				}
+				v, err := resolver.Resolve(ctx, scope.WorkspaceID, name)
+				if err != nil {
+					resolveErr = fmt.Errorf("failed to resolve secret %s: %w", name, err)
+					return ref
+				}
+				values[name], value = v, v
+			}
+			return value
+		})
+	}
+
+	for key, value := range bindings {
+		if !strings.HasPrefix(key, scope.ActivityKey+".") {
+			continue
+		}
+		resolved := replaceStrings(value, replace)
+		if resolveErr != nil {
+			return resolveErr
+		}
+		bindings[key] = resolved
+	}
+	return nil
+}
+
+func replaceStrings(value any, fn func(string) string) any {
+	switch v := value.(type) {
+	case string:
+		return fn(v)
+	case map[string]any:
+		for key, item := range v {
+			v[key] = replaceStrings(item, fn)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = replaceStrings(item, fn)
+		}
+		return v
+	}
+	return value
+}
@@ -4,7 +4,10 @@ import (
 	"log"
 	"os"
 
-	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/uploadpilot/core/internal/clients"
+	"github.com/uploadpilot/core/internal/db/repo"
+	"github.com/uploadpilot/core/internal/workflow/activities"
+	"github.com/uploadpilot/core/internal/workflow/catalog"
 	"github.com/uploadpilot/core/internal/workflow/dsl"
 	"go.temporal.io/sdk/activity"
 	"go.temporal.io/sdk/client"
@@ -12,17 +15,47 @@ import (
 )
 
 type Worker struct {
-	lambdaClient   *lambda.Client
 	temporalClient client.Client
 	taskQueue      string
+	executor       *Executor
+	recorder       *RunRecorder
+	finalizer      *ArtifactFinalizer
+	reprocess      *ReprocessActivities
 	wrk            worker.Worker
 }
 
-func NewWorker(lambdaClient *lambda.Client, temporalClient client.Client, taskQueue string) *Worker {
+// NewWorker creates a worker for the DSL workflows and the bulk reprocess
+// workflow. The lambda backend is only available when a lambda client is given,
+// so that the worker can run on-prem.
+func NewWorker(repos *repo.Repositories, clients *clients.Clients, taskQueue string,
+	overrides map[string]catalog.ExecutorSpec, reprocessor Reprocessor) *Worker {
+	storage := activities.NewS3Storage(clients.S3Client)
+	secrets := activities.NewWorkspaceSecrets(repos.SecretsRepo, clients.KMSClient)
+
+	native := NewNativeBackend()
+	for name, fn := range activities.NewImageActivities(storage).Functions() {
+		native.Register(name, fn)
+	}
+	for name, fn := range activities.NewHTTPActivity(storage, secrets, nil).Functions() {
+		native.Register(name, fn)
+	}
+
+	backends := map[catalog.ExecutorType]Backend{
+		catalog.ExecutorNative:  native,
+		catalog.ExecutorCommand: NewCommandBackend(),
+		catalog.ExecutorHTTP:    NewHTTPBackend(nil),
+	}
+	if clients.LambdaClient != nil {
+		backends[catalog.ExecutorLambda] = NewLambdaBackend(clients.LambdaClient)
+	}
+
 	return &Worker{
-		lambdaClient:   lambdaClient,
-		temporalClient: temporalClient,
+		temporalClient: clients.TemporalClient,
 		taskQueue:      taskQueue,
+		executor:       NewExecutor(backends, overrides, secrets),
+		recorder:       NewRunRecorder(repos.ProcessorRunRepo, repos.UploadRepo),
+		finalizer:      NewArtifactFinalizer(storage, repos.UploadRepo),
+		reprocess:      NewReprocessActivities(repos.UploadRepo, reprocessor),
 	}
 }
 
@@ -32,10 +65,23 @@ func (w *Worker) Start() {
 	})
 
 	wrk.RegisterWorkflow(dsl.SimpleDSLWorkflow)
+	wrk.RegisterWorkflow(dsl.CallDSLWorkflow)
+	wrk.RegisterWorkflow(BulkReprocessWorkflow)
 
-	exc := NewExecutor(w.lambdaClient)
-	wrk.RegisterActivityWithOptions(exc.ExecuteLambdaContainerActivity, activity.RegisterOptions{
-		Name: "Executor",
+	wrk.RegisterActivityWithOptions(w.executor.Execute, activity.RegisterOptions{
+		Name: dsl.ExecutorActivityName,
+	})
+	wrk.RegisterActivityWithOptions(w.recorder.Record, activity.RegisterOptions{
+		Name: dsl.RecordRunActivityName,
+	})
+	wrk.RegisterActivityWithOptions(w.finalizer.Finalize, activity.RegisterOptions{
+		Name: dsl.FinalizeArtifactsActivityName,
+	})
+	wrk.RegisterActivityWithOptions(w.reprocess.ListUploads, activity.RegisterOptions{
+		Name: ListReprocessUploadsActivityName,
+	})
+	wrk.RegisterActivityWithOptions(w.reprocess.ReprocessUpload, activity.RegisterOptions{
+		Name: ReprocessUploadActivityName,
 	})
 
 	w.wrk = wrk
@@ -2,12 +2,15 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/jinzhu/copier"
 	"github.com/uploadpilot/core/internal/db/models"
 	"github.com/uploadpilot/core/internal/dto"
 	"github.com/uploadpilot/core/internal/services"
 	"github.com/uploadpilot/core/internal/workflow/catalog"
+	"github.com/uploadpilot/core/internal/workflow/dsl"
+	"github.com/uploadpilot/core/pkg/utils"
 )
 
 type processorHandler struct {
@@ -59,6 +62,15 @@ func (h *processorHandler) CreateProcessor(r *http.Request, params dto.Workspace
 	return &processor.ID, http.StatusOK, nil
 }
 
+func (h *processorHandler) ExplainTriggers(r *http.Request, params dto.WorkspaceParams,
+	query interface{}, body dto.ExplainTriggersRequest) ([]dto.TriggerExplanation, int, error) {
+	explanations, err := h.pSvc.ExplainTriggers(r.Context(), params.TenantID, params.WorkspaceID, &body)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return explanations, http.StatusOK, nil
+}
+
 func (h *processorHandler) UpdateProcessor(r *http.Request, params dto.ProcessorParams,
 	query interface{}, body dto.EditProcRequest) (*string, int, error) {
 	err := h.pSvc.EditNameAndTrigger(r.Context(), params.WorkspaceID, params.ProcessorID, &body)
@@ -84,6 +96,84 @@ func (h *processorHandler) UpdateWorkflow(r *http.Request, params dto.ProcessorP
 	return true, http.StatusOK, nil
 }
 
+func (h *processorHandler) GetWorkflowVersions(r *http.Request, params dto.ProcessorParams,
+	query, body interface{}) ([]models.ProcessorWorkflowVersion, int, error) {
+	versions, err := h.pSvc.GetWorkflowVersions(r.Context(), params.TenantID, params.WorkspaceID, params.ProcessorID)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return versions, http.StatusOK, nil
+}
+
+func (h *processorHandler) GetWorkflowVersion(r *http.Request, params dto.WorkflowVersionParams,
+	query, body interface{}) (*models.ProcessorWorkflowVersion, int, error) {
+	version, err := strconv.Atoi(params.Version)
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity, err
+	}
+	workflowVersion, err := h.pSvc.GetWorkflowVersion(r.Context(), params.TenantID, params.WorkspaceID, params.ProcessorID, version)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return workflowVersion, http.StatusOK, nil
+}
+
+func (h *processorHandler) DiffWorkflowVersions(r *http.Request, params dto.ProcessorParams,
+	query dto.WorkflowDiffQuery, body interface{}) (*dto.WorkflowDiff, int, error) {
+	from, err := strconv.Atoi(query.From)
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity, err
+	}
+	to, err := strconv.Atoi(query.To)
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity, err
+	}
+	diff, err := h.pSvc.DiffWorkflowVersions(r.Context(), params.TenantID, params.WorkspaceID, params.ProcessorID, from, to)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return diff, http.StatusOK, nil
+}
+
+func (h *processorHandler) RollbackWorkflow(r *http.Request, params dto.WorkflowVersionParams,
+	query, body interface{}) (*dto.RollbackWorkflowResp, int, error) {
+	version, err := strconv.Atoi(params.Version)
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity, err
+	}
+	newVersion, err := h.pSvc.RollbackWorkflow(r.Context(), params.TenantID, params.WorkspaceID, params.ProcessorID, version)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return &dto.RollbackWorkflowResp{Version: newVersion}, http.StatusOK, nil
+}
+
+func (h *processorHandler) TestProcessor(r *http.Request, params dto.ProcessorParams,
+	query interface{}, body dto.TestProcessorRequest) (*dto.TestRunResult, int, error) {
+	result, err := h.pSvc.TestProcessor(r.Context(), params.TenantID, params.WorkspaceID, params.ProcessorID, &body)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return result, http.StatusOK, nil
+}
+
+func (h *processorHandler) GetTestRun(r *http.Request, params dto.TestRunParams,
+	query interface{}, body interface{}) (*dto.TestRunResult, int, error) {
+	result, err := h.pSvc.GetTestRun(r.Context(), params.TenantID, params.WorkspaceID, params.ProcessorID, params.WorkflowID)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return result, http.StatusOK, nil
+}
+
+func (h *processorHandler) UpdateSettings(r *http.Request, params dto.ProcessorParams,
+	query interface{}, body dto.ProcessorSettings) (bool, int, error) {
+	if err := h.pSvc.UpdateSettings(r.Context(), params.TenantID, params.WorkspaceID, params.ProcessorID, &body); err != nil {
+		return false, http.StatusBadRequest, err
+	}
+	return true, http.StatusOK, nil
+}
+
 func (h *processorHandler) EnableProcessor(r *http.Request, params dto.ProcessorParams,
 	query, body interface{}) (bool, int, error) {
 	err := h.pSvc.EnableDisableProcessor(r.Context(), params.WorkspaceID, params.ProcessorID, true)
@@ -110,12 +200,19 @@ func (h *processorHandler) GetAllActivities(r *http.Request, params dto.Workspac
 }
 
 func (h *processorHandler) GetWorkflowRuns(r *http.Request, params dto.ProcessorParams,
-	query, body interface{}) ([]dto.WorkflowRun, int, error) {
-	runs, err := h.pSvc.GetWorkflowRuns(r.Context(), params.TenantID, params.WorkspaceID, params.ProcessorID)
+	query dto.PaginatedQuery, body interface{}) (*dto.PaginatedResponse[models.ProcessorRun], int, error) {
+	paginationParams, err := utils.GetPaginatedQueryParams(&query)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	runs, totalRecords, err := h.pSvc.GetWorkflowRuns(r.Context(), params.TenantID, params.WorkspaceID, params.ProcessorID, paginationParams)
 	if err != nil {
 		return nil, http.StatusBadRequest, err
 	}
-	return runs, http.StatusOK, nil
+	return &dto.PaginatedResponse[models.ProcessorRun]{
+		TotalRecords: totalRecords,
+		Records:      runs,
+	}, http.StatusOK, nil
 }
 
 func (h *processorHandler) GetWorkflowLogs(r *http.Request, params dto.RunParams,
@@ -140,6 +237,45 @@ func (h *processorHandler) CancelWorkflowRun(r *http.Request, params dto.RunPara
 	return true, http.StatusOK, nil
 }
 
+func (h *processorHandler) RerunWorkflowRun(r *http.Request, params dto.RunParams,
+	query dto.WorkflowQuery, body interface{}) (*dto.TriggerWorkflowResp, int, error) {
+	resp, err := h.pSvc.RerunWorkflowRun(r.Context(), params.TenantID, params.WorkspaceID,
+		params.ProcessorID, query.WorkflowID, params.RunID)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return resp, http.StatusOK, nil
+}
+
+func (h *processorHandler) GetPendingApprovals(r *http.Request, params dto.RunParams,
+	query dto.WorkflowQuery, body interface{}) ([]dsl.PendingApproval, int, error) {
+	approvals, err := h.pSvc.GetPendingApprovals(r.Context(), params.TenantID, params.WorkspaceID,
+		params.ProcessorID, query.WorkflowID, params.RunID)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return approvals, http.StatusOK, nil
+}
+
+func (h *processorHandler) DecideApproval(r *http.Request, params dto.ApprovalParams,
+	query dto.WorkflowQuery, body dto.ApprovalDecisionRequest) (bool, int, error) {
+	err := h.pSvc.DecideApproval(r.Context(), params.TenantID, params.WorkspaceID,
+		params.ProcessorID, query.WorkflowID, params.RunID, params.ApprovalKey, &body)
+	if err != nil {
+		return false, http.StatusBadRequest, err
+	}
+	return true, http.StatusOK, nil
+}
+
+func (h *processorHandler) RerunProcessor(r *http.Request, params dto.ProcessorParams,
+	query interface{}, body dto.RerunProcessorRequest) (*dto.TriggerWorkflowResp, int, error) {
+	resp, err := h.pSvc.RerunProcessor(r.Context(), params.TenantID, params.WorkspaceID, params.ProcessorID, body.UploadID)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return resp, http.StatusOK, nil
+}
+
 func (h *processorHandler) DownloadRunArtifacts(r *http.Request, params dto.RunParams,
 	query dto.UploadQuery, body interface{}) (string, int, error) {
 	url, err := h.pSvc.GetRunArtifactsSignedURL(r.Context(), params.TenantID,
@@ -150,3 +286,33 @@ func (h *processorHandler) DownloadRunArtifacts(r *http.Request, params dto.RunP
 
 	return url, http.StatusOK, nil
 }
+
+func (h *processorHandler) GetRunArtifacts(r *http.Request, params dto.RunParams,
+	query dto.WorkflowQuery, body interface{}) ([]models.Artifact, int, error) {
+	artifacts, err := h.pSvc.GetRunArtifacts(r.Context(), params.TenantID, params.WorkspaceID,
+		params.ProcessorID, query.WorkflowID, params.RunID)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return artifacts, http.StatusOK, nil
+}
+
+func (h *processorHandler) GetRunArtifactURL(r *http.Request, params dto.RunParams,
+	query dto.ArtifactQuery, body interface{}) (*dto.ArtifactURL, int, error) {
+	url, err := h.pSvc.GetRunArtifactSignedURL(r.Context(), params.TenantID, params.WorkspaceID,
+		params.ProcessorID, params.RunID, &query)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return url, http.StatusOK, nil
+}
+
+func (h *processorHandler) PromoteRunArtifact(r *http.Request, params dto.RunParams,
+	query dto.WorkflowQuery, body dto.PromoteArtifactRequest) (*models.Upload, int, error) {
+	upload, err := h.pSvc.PromoteRunArtifact(r.Context(), params.TenantID, params.WorkspaceID,
+		params.ProcessorID, query.WorkflowID, params.RunID, &body)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return upload, http.StatusOK, nil
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/uploadpilot/core/internal/db/models"
+	"github.com/uploadpilot/core/internal/dto"
+	"github.com/uploadpilot/core/internal/services"
+)
+
+type secretHandler struct {
+	secretSvc *services.SecretService
+}
+
+func NewSecretHandler(secretSvc *services.SecretService) *secretHandler {
+	return &secretHandler{
+		secretSvc: secretSvc,
+	}
+}
+
+func (h *secretHandler) GetSecrets(r *http.Request, params dto.WorkspaceParams,
+	query, body interface{}) ([]models.Secret, int, error) {
+	secrets, err := h.secretSvc.GetAllSecrets(r.Context(), params.TenantID, params.WorkspaceID)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return secrets, http.StatusOK, nil
+}
+
+func (h *secretHandler) GetSecret(r *http.Request, params dto.SecretParams,
+	query, body interface{}) (*models.Secret, int, error) {
+	secret, err := h.secretSvc.GetSecret(r.Context(), params.TenantID, params.WorkspaceID, params.SecretKey)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return secret, http.StatusOK, nil
+}
+
+func (h *secretHandler) CreateSecret(r *http.Request, params dto.WorkspaceParams,
+	query interface{}, body dto.CreateSecretData) (*models.Secret, int, error) {
+	secret, err := h.secretSvc.CreateSecret(r.Context(), params.TenantID, params.WorkspaceID, &body)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return secret, http.StatusOK, nil
+}
+
+func (h *secretHandler) UpdateSecret(r *http.Request, params dto.SecretParams,
+	query interface{}, body dto.UpdateSecretData) (bool, int, error) {
+	if err := h.secretSvc.UpdateSecret(r.Context(), params.TenantID, params.WorkspaceID, params.SecretKey, &body); err != nil {
+		return false, http.StatusBadRequest, err
+	}
+	return true, http.StatusOK, nil
+}
+
+func (h *secretHandler) DeleteSecret(r *http.Request, params dto.SecretParams,
+	query, body interface{}) (bool, int, error) {
+	if err := h.secretSvc.DeleteSecret(r.Context(), params.TenantID, params.WorkspaceID, params.SecretKey); err != nil {
+		return false, http.StatusBadRequest, err
+	}
+	return true, http.StatusOK, nil
+}
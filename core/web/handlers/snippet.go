@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/uploadpilot/core/internal/db/models"
+	"github.com/uploadpilot/core/internal/dto"
+	"github.com/uploadpilot/core/internal/services"
+)
+
+type snippetHandler struct {
+	snippetSvc *services.SnippetService
+}
+
+func NewSnippetHandler(snippetSvc *services.SnippetService) *snippetHandler {
+	return &snippetHandler{
+		snippetSvc: snippetSvc,
+	}
+}
+
+func (h *snippetHandler) GetSnippets(r *http.Request, params dto.WorkspaceParams,
+	query, body interface{}) ([]models.WorkflowSnippet, int, error) {
+	snippets, err := h.snippetSvc.GetAllSnippets(r.Context(), params.TenantID, params.WorkspaceID)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return snippets, http.StatusOK, nil
+}
+
+func (h *snippetHandler) GetSnippet(r *http.Request, params dto.SnippetParams,
+	query, body interface{}) (*models.WorkflowSnippet, int, error) {
+	snippet, err := h.snippetSvc.GetSnippet(r.Context(), params.TenantID, params.WorkspaceID, params.SnippetName)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return snippet, http.StatusOK, nil
+}
+
+func (h *snippetHandler) CreateSnippet(r *http.Request, params dto.WorkspaceParams,
+	query interface{}, body dto.CreateSnippetRequest) (*models.WorkflowSnippet, int, error) {
+	snippet, err := h.snippetSvc.CreateSnippet(r.Context(), params.TenantID, params.WorkspaceID, &body)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return snippet, http.StatusOK, nil
+}
+
+func (h *snippetHandler) UpdateSnippet(r *http.Request, params dto.SnippetParams,
+	query interface{}, body dto.UpdateSnippetRequest) (bool, int, error) {
+	if err := h.snippetSvc.UpdateSnippet(r.Context(), params.TenantID, params.WorkspaceID, params.SnippetName, &body); err != nil {
+		return false, http.StatusBadRequest, err
+	}
+	return true, http.StatusOK, nil
+}
+
+func (h *snippetHandler) DeleteSnippet(r *http.Request, params dto.SnippetParams,
+	query, body interface{}) (bool, int, error) {
+	if err := h.snippetSvc.DeleteSnippet(r.Context(), params.TenantID, params.WorkspaceID, params.SnippetName); err != nil {
+		return false, http.StatusBadRequest, err
+	}
+	return true, http.StatusOK, nil
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/uploadpilot/core/internal/db/models"
 	"github.com/uploadpilot/core/internal/dto"
@@ -47,6 +48,25 @@ func (h *uploadHandler) GetUploadDetailsByID(r *http.Request, params dto.UploadP
 	return details, http.StatusOK, nil
 }
 
+func (h *uploadHandler) DeleteUpload(r *http.Request, params dto.UploadParams, query dto.DeleteUploadQuery,
+	body interface{}) (bool, int, error) {
+	cascade, _ := strconv.ParseBool(query.Cascade)
+	detach, _ := strconv.ParseBool(query.Detach)
+	if err := h.uploadSvc.DeleteUpload(r.Context(), params.TenantID, params.WorkspaceID, params.UploadID, cascade, detach); err != nil {
+		return false, http.StatusBadRequest, err
+	}
+	return true, http.StatusOK, nil
+}
+
+func (h *uploadHandler) GetUploadLineage(r *http.Request, params dto.UploadParams, query interface{},
+	body interface{}) (*dto.UploadLineage, int, error) {
+	lineage, err := h.uploadSvc.GetUploadLineage(r.Context(), params.TenantID, params.WorkspaceID, params.UploadID)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return lineage, http.StatusOK, nil
+}
+
 func (h *uploadHandler) GetUploadURL(r *http.Request, params dto.UploadParams, query interface{}, body interface{}) (string, int, error) {
 	url, err := h.uploadSvc.GetUploadSignedURL(r.Context(), params.TenantID, params.WorkspaceID, params.UploadID)
 	if err != nil {
@@ -64,6 +84,32 @@ func (h *uploadHandler) ProcessUpload(r *http.Request, params dto.UploadParams,
 	return "OK", http.StatusOK, nil
 }
 
+func (h *uploadHandler) ReprocessUploads(r *http.Request, params dto.WorkspaceParams, query interface{},
+	body dto.ReprocessUploadsRequest) (string, int, error) {
+	jobID, err := h.uploadSvc.ReprocessUploads(r.Context(), params.TenantID, params.WorkspaceID, &body)
+	if err != nil {
+		return "", http.StatusBadRequest, err
+	}
+	return jobID, http.StatusOK, nil
+}
+
+func (h *uploadHandler) GetReprocessJob(r *http.Request, params dto.ReprocessJobParams, query interface{},
+	body interface{}) (*dto.ReprocessJob, int, error) {
+	job, err := h.uploadSvc.GetReprocessJob(r.Context(), params.TenantID, params.WorkspaceID, params.JobID)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return job, http.StatusOK, nil
+}
+
+func (h *uploadHandler) CancelReprocessJob(r *http.Request, params dto.ReprocessJobParams, query interface{},
+	body interface{}) (bool, int, error) {
+	if err := h.uploadSvc.CancelReprocessJob(r.Context(), params.TenantID, params.WorkspaceID, params.JobID); err != nil {
+		return false, http.StatusBadRequest, err
+	}
+	return true, http.StatusOK, nil
+}
+
 // UPLOADER API
 func (h *uploadHandler) CreateUpload(r *http.Request, params dto.WorkspaceParams, query interface{}, body dto.CreateUploadRequest) (*dto.CreateUploadResponse, int, error) {
 	res, err := h.uploadSvc.CreateUpload(r.Context(), params.TenantID, params.WorkspaceID, &body)
@@ -19,6 +19,8 @@ func NewAppRoutesV1(services *services.Services, middlewares *middlewares.Middle
 	workspaceHandler := handlers.NewWorkspaceHandler(services.WorkspaceService)
 	uploadHandler := handlers.NewUploadHandler(services.UploadService, services.WorkspaceService)
 	procHandler := handlers.NewProcessorsHandler(services.ProcessorService)
+	secretHandler := handlers.NewSecretHandler(services.SecretService)
+	snippetHandler := handlers.NewSnippetHandler(services.SnippetService)
 
 	router.Use(supertokens.Middleware)
 	router.Use(middlewares.CorsMiddleware)
@@ -59,19 +61,47 @@ func NewAppRoutesV1(services *services.Services, middlewares *middlewares.Middle
 						r.Get("/", webutils.CreateJSONHandler(uploadHandler.GetPaginatedUploads))
 						r.Post("/", webutils.CreateJSONHandler(uploadHandler.CreateUpload))
 						r.Post("/log", webutils.CreateJSONHandler(workspaceHandler.LogUpload))
+						r.Route("/reprocess", func(r chi.Router) {
+							r.Post("/", webutils.CreateJSONHandler(uploadHandler.ReprocessUploads))
+							r.Get("/{jobId}", webutils.CreateJSONHandler(uploadHandler.GetReprocessJob))
+							r.Put("/{jobId}/cancel", webutils.CreateJSONHandler(uploadHandler.CancelReprocessJob))
+						})
 						r.Route("/{uploadId}", func(r chi.Router) {
 							r.Get("/", webutils.CreateJSONHandler(uploadHandler.GetUploadDetailsByID))
+							r.Delete("/", webutils.CreateJSONHandler(uploadHandler.DeleteUpload))
+							r.Get("/lineage", webutils.CreateJSONHandler(uploadHandler.GetUploadLineage))
 							r.Post("/finish", webutils.CreateJSONHandler(uploadHandler.FinishUpload))
 							r.Get("/download", webutils.CreateJSONHandler(uploadHandler.GetUploadURL))
 							r.Post("/process", webutils.CreateJSONHandler(uploadHandler.ProcessUpload))
 						})
 					})
 
+					r.Route("/secrets", func(r chi.Router) {
+						r.Get("/", webutils.CreateJSONHandler(secretHandler.GetSecrets))
+						r.Post("/", webutils.CreateJSONHandler(secretHandler.CreateSecret))
+						r.Route("/{secretKey}", func(r chi.Router) {
+							r.Get("/", webutils.CreateJSONHandler(secretHandler.GetSecret))
+							r.Put("/", webutils.CreateJSONHandler(secretHandler.UpdateSecret))
+							r.Delete("/", webutils.CreateJSONHandler(secretHandler.DeleteSecret))
+						})
+					})
+
+					r.Route("/snippets", func(r chi.Router) {
+						r.Get("/", webutils.CreateJSONHandler(snippetHandler.GetSnippets))
+						r.Post("/", webutils.CreateJSONHandler(snippetHandler.CreateSnippet))
+						r.Route("/{snippetName}", func(r chi.Router) {
+							r.Get("/", webutils.CreateJSONHandler(snippetHandler.GetSnippet))
+							r.Put("/", webutils.CreateJSONHandler(snippetHandler.UpdateSnippet))
+							r.Delete("/", webutils.CreateJSONHandler(snippetHandler.DeleteSnippet))
+						})
+					})
+
 					r.Route("/processors", func(r chi.Router) {
 						r.Get("/", webutils.CreateJSONHandler(procHandler.GetProcessors))
 						r.Post("/", webutils.CreateJSONHandler(procHandler.CreateProcessor))
 						r.Get("/activities", webutils.CreateJSONHandler(procHandler.GetAllActivities))
 						r.Get("/templates", webutils.CreateJSONHandler(procHandler.GetTemplates))
+						r.Post("/explain", webutils.CreateJSONHandler(procHandler.ExplainTriggers))
 						r.Route("/{processorId}", func(r chi.Router) {
 							r.Get("/", webutils.CreateJSONHandler(procHandler.GetProcessorDetailsByID))
 							r.Put("/", webutils.CreateJSONHandler(procHandler.UpdateProcessor))
@@ -79,12 +109,30 @@ func NewAppRoutesV1(services *services.Services, middlewares *middlewares.Middle
 							r.Put("/enable", webutils.CreateJSONHandler(procHandler.EnableProcessor))
 							r.Put("/disable", webutils.CreateJSONHandler(procHandler.DisableProcessor))
 							r.Put("/workflow", webutils.CreateJSONHandler(procHandler.UpdateWorkflow))
+							r.Put("/settings", webutils.CreateJSONHandler(procHandler.UpdateSettings))
+							r.Post("/test", webutils.CreateJSONHandler(procHandler.TestProcessor))
+							r.Get("/test/{workflowId}", webutils.CreateJSONHandler(procHandler.GetTestRun))
+							r.Post("/rerun", webutils.CreateJSONHandler(procHandler.RerunProcessor))
+							r.Route("/versions", func(r chi.Router) {
+								r.Get("/", webutils.CreateJSONHandler(procHandler.GetWorkflowVersions))
+								r.Get("/diff", webutils.CreateJSONHandler(procHandler.DiffWorkflowVersions))
+								r.Get("/{version}", webutils.CreateJSONHandler(procHandler.GetWorkflowVersion))
+								r.Post("/{version}/rollback", webutils.CreateJSONHandler(procHandler.RollbackWorkflow))
+							})
 							r.Route("/runs", func(r chi.Router) {
 								r.Get("/", webutils.CreateJSONHandler(procHandler.GetWorkflowRuns))
 								r.Route("/{runId}", func(r chi.Router) {
 									r.Get("/logs", webutils.CreateJSONHandler(procHandler.GetWorkflowLogs))
 									r.Put("/cancel", webutils.CreateJSONHandler(procHandler.CancelWorkflowRun))
+									r.Post("/rerun", webutils.CreateJSONHandler(procHandler.RerunWorkflowRun))
+									r.Get("/approvals", webutils.CreateJSONHandler(procHandler.GetPendingApprovals))
+									r.Post("/approvals/{approvalKey}", webutils.CreateJSONHandler(procHandler.DecideApproval))
 									r.Get("/download-artifacts", webutils.CreateJSONHandler(procHandler.DownloadRunArtifacts))
+									r.Route("/artifacts", func(r chi.Router) {
+										r.Get("/", webutils.CreateJSONHandler(procHandler.GetRunArtifacts))
+										r.Get("/url", webutils.CreateJSONHandler(procHandler.GetRunArtifactURL))
+										r.Post("/promote", webutils.CreateJSONHandler(procHandler.PromoteRunArtifact))
+									})
 								})
 							})
 						})
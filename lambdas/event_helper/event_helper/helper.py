@@ -88,6 +88,10 @@ def get_output_prefix(event):
     if f"{event['current_activity_key']}.save_output" in event and event[f"{event['current_activity_key']}.save_output"] == True:
         output_folder = "processed"
 
+    if event.get("test_run") == True:
+        # test runs never write next to the outputs of real runs
+        return f'test-runs/{event["processor_id"]}/{event["run_id"]}/{event["current_activity_key"]}/'
+
     return f'{event["upload_id"]}/{output_folder}/{event["processor_id"]}/{event["run_id"]}/{event["current_activity_key"]}/'
 
 def get_input_args(event):
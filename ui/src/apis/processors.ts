@@ -303,7 +303,7 @@ export const useGetProcessorRuns = (
       }
       return axiosTenantInstance
         .get(`/workspaces/${workspaceId}/processors/${processorId}/runs`)
-        .then(res => res.data);
+        .then(res => res.data?.records || []);
     },
   });
 
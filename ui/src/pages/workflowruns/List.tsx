@@ -158,28 +158,27 @@ const ProcessorRunsList = () => {
       },
       {
         title: 'Started At',
-        accessor: 'startTime',
+        accessor: 'startedAt',
         hidden: width < 768,
         render: (item: any) =>
-          new Date(item?.startTime).toLocaleString('en-US'),
+          new Date(item?.startedAt).toLocaleString('en-US'),
       },
       {
         title: 'Ended At',
-        accessor: 'endTime',
+        accessor: 'finishedAt',
         hidden: width < 768,
         render: (item: any) => {
-          if (item?.endTime !== '0001-01-01T00:00:00Z') {
-            return new Date(item?.endTime).toLocaleString('en-US');
+          if (item?.finishedAt) {
+            return new Date(item?.finishedAt).toLocaleString('en-US');
           }
           return '-';
         },
       },
       {
         title: 'Execution Time',
-        accessor: 'executionTimeMillis',
+        accessor: 'durationMillis',
         hidden: width < 768,
-        render: (item: any) =>
-          formatMilliseconds(item?.executionTimeMillis || 0),
+        render: (item: any) => formatMilliseconds(item?.durationMillis || 0),
       },
       {
         accessor: 'status',